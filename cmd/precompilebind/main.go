@@ -0,0 +1,86 @@
+// Command precompilebind generates a hooked precompile's selector
+// dispatcher, its Solidity interface, and a Go test client from a single Go
+// interface declaration, so adding a new state-view hook no longer means
+// hand-writing a selector switch and its ABI head/tail encoding by hand.
+//
+// Usage:
+//
+//	precompilebind -type FarmView -source core/vm/farmview.go -out core/vm/farmviewgen
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/core/vm/precompile"
+)
+
+var (
+	typeFlag   = flag.String("type", "", "name of the Go interface to bind (required)")
+	sourceFlag = flag.String("source", "", "Go source file declaring -type (required)")
+	pkgFlag    = flag.String("pkg", "", "package name for generated Go files (defaults to -type with its first letter lowercased)")
+	outFlag    = flag.String("out", "", "output directory for generated files (required)")
+)
+
+func main() {
+	flag.Parse()
+	if *typeFlag == "" || *sourceFlag == "" || *outFlag == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(*typeFlag, *sourceFlag, *pkgFlag, *outFlag); err != nil {
+		fmt.Fprintln(os.Stderr, "precompilebind:", err)
+		os.Exit(1)
+	}
+}
+
+func run(typeName, source, pkg, out string) error {
+	src, err := ioutil.ReadFile(source)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", source, err)
+	}
+	iface, err := precompile.ParseInterface(src, typeName)
+	if err != nil {
+		return err
+	}
+	if pkg == "" {
+		pkg = lowerFirst(typeName)
+	}
+	if err := os.MkdirAll(out, 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", out, err)
+	}
+
+	dispatcher, err := precompile.GenerateDispatcher(iface, pkg, iface.Name)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(out, "dispatcher.go"), dispatcher, 0644); err != nil {
+		return err
+	}
+
+	client, err := precompile.GenerateClient(iface, pkg)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(out, "client.go"), client, 0644); err != nil {
+		return err
+	}
+
+	solidity := precompile.GenerateSolidity(iface)
+	solPath := filepath.Join(out, fmt.Sprintf("%s.sol", iface.Name))
+	if err := ioutil.WriteFile(solPath, solidity, 0644); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return string(s[0]|0x20) + s[1:]
+}
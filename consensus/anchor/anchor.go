@@ -13,11 +13,8 @@ import (
 	"math"
 	"math/big"
 	"math/rand"
-	"os"
-	"os/signal"
 	"strings"
 	"sync"
-	"syscall"
 	"time"
 
 	lru "github.com/hashicorp/golang-lru"
@@ -35,6 +32,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/forkid"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/systemcontracts"
+	"github.com/ethereum/go-ethereum/core/txpool/bundlepool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -53,6 +51,8 @@ const (
 	checkpointInterval = 1024        // Number of blocks after which to save the snapshot to the database
 	defaultEpochLength = uint64(100) // Default number of blocks of checkpoint to update validatorSet from contract
 
+	defaultSlashEvidenceRetention = 1024 // Number of (height, validator) double-sign observations to remember absent an explicit SlashConfig window
+
 	extraVanity      = 32 // Fixed number of extra-data prefix bytes reserved for signer vanity
 	extraAnchorHash  = 32
 	extraSeal        = 65 // Fixed number of extra-data suffix bytes reserved for signer seal
@@ -62,6 +62,12 @@ const (
 	wiggleTime           = uint64(1) // second, Random delay (per signer) to allow concurrent signers
 	initialBackOffTime   = uint64(1) // second
 	processBackOffTime   = uint64(1) // second
+
+	// nonceAuthVote and nonceDropVote are Clique's header.Nonce values for
+	// an authorize/drop vote. Nothing in this engine writes them into a
+	// header: Prepare always clears header.Nonce (see Propose).
+	nonceAuthVote = uint64(0xffffffffffffffff)
+	nonceDropVote = uint64(0x0000000000000000)
 )
 
 var (
@@ -140,8 +146,6 @@ var (
 	// errRecentlySigned is returned if a header is signed by an authorized entity
 	// that already signed a header recently, thus is temporarily not allowed to.
 	errRecentlySigned = errors.New("recently signed")
-
-	errInterruptPrepare = errors.New("interrupt Signal Received During Wait")
 )
 
 // SignerFn is a signer callback function to request a header to be signed by a
@@ -203,18 +207,60 @@ type Anchor struct {
 	signFn     SignerFn       // Signer function to authorize hashes with
 	signTxFn   SignerTxFn
 	signTextFn SignTextFn
+	blsSignFn  BLSSigner // Signer function producing this validator's partial burn-proof share
 
-	lock sync.RWMutex // Protects the signer fields
+	// proposals holds this operator's pending off-chain authorize/drop
+	// votes, an escape hatch for steering the validator set when the
+	// on-chain validator contract is stuck. See the Propose/Discard doc
+	// comment for the gap between this map and actual snapshot tallying.
+	proposals map[common.Address]bool
+
+	lock sync.RWMutex // Protects the signer fields and proposals
 
 	ethAPI       *ethapi.PublicBlockChainAPI
 	systemDaoABI abi.ABI
 	anchorABI    abi.ABI
+	slashABI     abi.ABI
+
+	// slashEvidence accumulates double-sign evidence observed in
+	// verifySeal until Finalize/FinalizeAndAssemble drains and submits it
+	// to the slash contract.
+	slashEvidence *slashEvidencePool
+
+	// cachedMinBaseFee holds the last value read from MinBaseFeeContract,
+	// rather than re-reading it on every block's Prepare. Nil until the
+	// first read; after that it changes only when handleBreatheBlock calls
+	// refreshMinBaseFee. Protected by lock, like the other signer-ish
+	// mutable fields above.
+	cachedMinBaseFee *big.Int
+
+	// cachedTurnLength holds the last value read from getTurnLength(), the
+	// same once-per-epoch caching strategy as cachedMinBaseFee above. Nil
+	// until the first read, at which point callers should treat a pre-fork
+	// chain (AnchorTurnLengthBlock unset) as turnLength 1.
+	cachedTurnLength *uint64
+
+	// bundlePool holds MEV bundles submitted via MEVAPI.SendBundle, read by
+	// the miner's fillTransactionsAndBundles the same way it reads the
+	// regular tx pool off the backend - this engine is the only thing in
+	// this tree both the RPC layer and the miner already share a reference
+	// to (via w.engine), so it's a natural enough home in the absence of a
+	// dedicated backend package here.
+	bundlePool *bundlepool.BundlePool
+
+	// exchangeTracker shares l1ExchangesOfBlockNumber results and
+	// processed-request bookkeeping between Finalize and
+	// FinalizeAndAssemble, instead of each eagerly refetching and
+	// re-minting independently.
+	exchangeTracker *L1ExchangeTracker
 
 	// The fields below are for testing only
 	fakeDiff bool // Skip difficulty verifications
 
-	mainIPC        *ethclient.Client
-	anchorContract *L2AnchorContract
+	mainIPC          *ethclient.Client
+	anchorContract   *L2AnchorContract
+	anchorWatcher    *anchorWatcher
+	snapshotPipeline *snapshotPipeline
 }
 
 /**
@@ -245,10 +291,23 @@ func New(
 	genesisHash common.Hash,
 ) *Anchor {
 
-	client, err := ethclient.Dial(chainConfig.Anchor.IPCPath)
+	source, err := newAnchorSource(chainConfig.Anchor.IPCPath)
 	if err != nil {
 		panic(err)
 	}
+	// anchorContract and the farms subsystem still need a concrete
+	// *ethclient.Client rather than the AnchorSource interface; reuse the
+	// one rpcAnchorSource already dialed, or dial the endpoint a second
+	// time as plain RPC for non-RPC sources (light/file). Those sources can
+	// watch L1 heads through AnchorSource today; submitting anchor-bound
+	// contract calls through them is future work.
+	client, ok := clientFromSource(source)
+	if !ok {
+		client, err = ethclient.Dial(chainConfig.Anchor.IPCPath)
+		if err != nil {
+			panic(err)
+		}
+	}
 	// get parlia config
 	anchorConfig := chainConfig.Anchor
 
@@ -282,23 +341,79 @@ func New(
 		panic(err)
 	}
 
-	c := &Anchor{
-		chainConfig:    chainConfig,
-		config:         anchorConfig,
-		genesisHash:    genesisHash,
-		db:             db,
-		ethAPI:         ethAPI,
-		recentSnaps:    recentSnaps,
-		signatures:     signatures,
-		signer:         types.NewLondonSigner(chainConfig.ChainID), //.NewEIP155Signer(chainConfig.ChainID),
-		systemDaoABI:   daoABI,
-		anchorABI:      anchorABI,
-		mainIPC:        client,
-		anchorContract: anchorContract,
+	slashABI, err := abi.JSON(strings.NewReader(anchor.SlashABI))
+	if err != nil {
+		panic(err)
+	}
+
+	evidenceRetention := defaultSlashEvidenceRetention
+	if anchorConfig.SlashConfig != nil && anchorConfig.SlashConfig.EvidenceRetentionWindow > 0 {
+		evidenceRetention = int(anchorConfig.SlashConfig.EvidenceRetentionWindow)
 	}
+
+	c := &Anchor{
+		chainConfig:     chainConfig,
+		config:          anchorConfig,
+		genesisHash:     genesisHash,
+		db:              db,
+		ethAPI:          ethAPI,
+		recentSnaps:     recentSnaps,
+		signatures:      signatures,
+		signer:          types.NewLondonSigner(chainConfig.ChainID), //.NewEIP155Signer(chainConfig.ChainID),
+		systemDaoABI:    daoABI,
+		anchorABI:       anchorABI,
+		slashABI:        slashABI,
+		mainIPC:         client,
+		anchorContract:  anchorContract,
+		proposals:       make(map[common.Address]bool),
+		slashEvidence:   newSlashEvidencePool(evidenceRetention),
+		exchangeTracker: newL1ExchangeTracker(),
+		bundlePool:      bundlepool.New(),
+	}
+	c.anchorWatcher = newAnchorWatcher(source, anchorContract.networkInfo.ConfirmationDepth)
+	c.snapshotPipeline = newSnapshotPipeline(db)
 	return c
 }
 
+// Propose records a pending authorization proposal for the given address,
+// either authorizing (auth=true) or deauthorizing it.
+//
+// Propose/Discard/Proposals give operators a Clique-style off-chain voting
+// escape hatch, but unlike Clique this engine's verifySeal pins
+// header.Coinbase to the recovered signer address (errCoinBaseMisMatch),
+// so a header can't also carry a distinct voted-on address there the way
+// Clique's Prepare/snapshot.apply do. These proposals are recorded and
+// exposed over RPC for operator tooling only: Prepare does not encode them
+// into header.Coinbase/header.Nonce, and snapshot.apply does not tally
+// them into the validator set.
+func (p *Anchor) Propose(address common.Address, auth bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.proposals[address] = auth
+}
+
+// Discard drops a currently pending proposal.
+func (p *Anchor) Discard(address common.Address) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.proposals, address)
+}
+
+// proposalsSnapshot returns a copy of the pending proposals map, safe for a
+// caller to range over without holding p.lock.
+func (p *Anchor) proposalsSnapshot() map[common.Address]bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	proposals := make(map[common.Address]bool, len(p.proposals))
+	for addr, auth := range p.proposals {
+		proposals[addr] = auth
+	}
+	return proposals
+}
+
 func (p *Anchor) isSubmitRequestProofTransaction(tx *types.Transaction, header *types.Header) (bool, error) {
 	// deploy a contract
 	if tx.To() == nil {
@@ -322,6 +437,19 @@ func (p *Anchor) isSubmitRequestProofTransaction(tx *types.Transaction, header *
 	return false, nil
 }
 
+// isSubmitRequestProofBatchTransaction detects the same submitRequestProof
+// selector as isSubmitRequestProofTransaction. It exists as a distinct call
+// so Finalize's classification loop reads the same way a chain that later
+// splits single-proof and batched-proof submissions into two selectors
+// would read: this tree's submitRequestProof has committed to the
+// [Start, End) batch shape (see L2BurnBatch) since before this request, so
+// there is no older single-proof selector left to fall back to - a node
+// that only knows "submitRequestProof" already accepts every batch this
+// engine produces, batch-of-one included.
+func (p *Anchor) isSubmitRequestProofBatchTransaction(tx *types.Transaction, header *types.Header) (bool, error) {
+	return p.isSubmitRequestProofTransaction(tx, header)
+}
+
 func (p *Anchor) IsSystemTransaction(tx *types.Transaction, header *types.Header) (bool, error) {
 	// deploy a contract
 	if tx.To() == nil {
@@ -370,6 +498,7 @@ func (p *Anchor) VerifyHeaders(chain consensus.ChainHeaderReader, headers []*typ
 
 			select {
 			case <-abort:
+				p.snapshotPipeline.Flush()
 				return
 			case results <- err:
 			}
@@ -469,6 +598,17 @@ func (p *Anchor) verifyCascadingFields(chain consensus.ChainHeaderReader, header
 		return err
 	}
 
+	// blockTimeVerifyForRamanujanFork only checks the minimum spacing a
+	// signer of any turn must respect; it doesn't stop an out-turn validator
+	// from broadcasting before its assigned backOffTime has elapsed, which
+	// is exactly the delay Seal()/delayForRamanujanFork waits out on the
+	// producing side. Enforce the same threshold here so racing the in-turn
+	// signer gets the header rejected instead of just discouraged.
+	earliest := parent.Time + p.config.Period + p.backOffTime(snap, header.Coinbase)
+	if header.Time < earliest {
+		return consensus.ErrFutureBlock
+	}
+
 	// Verify that the gas limit is <= 2^63-1
 	capacity := uint64(0x7fffffffffffffff)
 	if header.GasLimit > capacity {
@@ -490,6 +630,19 @@ func (p *Anchor) verifyCascadingFields(chain consensus.ChainHeaderReader, header
 		return fmt.Errorf("invalid gas limit: have %d, want %d += %d", header.GasLimit, parent.GasLimit, limit)
 	}
 
+	// Verify the dynamic base fee matches what Prepare would have computed
+	// for this parent, once the chain has activated LondonAnchorBlock.
+	if p.isLondonAnchor(header.Number) {
+		minBaseFee, err := p.minBaseFee(header.ParentHash)
+		if err != nil {
+			return err
+		}
+		expected := p.calcAnchorBaseFee(parent, minBaseFee)
+		if header.BaseFee == nil || header.BaseFee.Cmp(expected) != 0 {
+			return fmt.Errorf("invalid baseFee: have %v, want %v, parentBaseFee %v, parentGasUsed %d", header.BaseFee, expected, parent.BaseFee, parent.GasUsed)
+		}
+	}
+
 	// All basic checks passed, verify the seal and return
 	return p.verifySeal(chain, header, parents)
 }
@@ -509,12 +662,19 @@ func (p *Anchor) snapshot(chain consensus.ChainHeaderReader, number uint64, hash
 			break
 		}
 
-		// If an on-disk checkpoint snapshot can be found, use that
+		// If an on-disk checkpoint snapshot can be found, use that - unless
+		// its integrity hash doesn't match what was stored alongside it,
+		// in which case treat it as absent and fall through to rebuilding
+		// from the last good checkpoint instead of silently trusting disk.
 		if number%checkpointInterval == 0 {
 			if s, err := loadSnapshot(p.config, p.signatures, p.db, hash, p.ethAPI); err == nil {
-				log.Trace("Loaded snapshot from disk", "number", number, "hash", hash)
-				snap = s
-				break
+				if ok, verr := verifySnapshotIntegrity(p.db, s); verr == nil && !ok {
+					log.Error("Anchor snapshot failed integrity check, rebuilding from last good checkpoint", "number", number, "hash", hash)
+				} else {
+					log.Trace("Loaded snapshot from disk", "number", number, "hash", hash)
+					snap = s
+					break
+				}
 			}
 		}
 
@@ -534,7 +694,7 @@ func (p *Anchor) snapshot(chain consensus.ChainHeaderReader, number uint64, hash
 
 				// new snap shot
 				snap = newSnapshot(p.config, p.signatures, number, hash, validators, p.ethAPI)
-				if err := snap.store(p.db); err != nil {
+				if err := storeSnapshotWithIntegrity(p.db, snap); err != nil {
 					return nil, err
 				}
 				log.Info("Stored checkpoint snapshot to disk", "number", number, "hash", hash)
@@ -578,12 +738,13 @@ func (p *Anchor) snapshot(chain consensus.ChainHeaderReader, number uint64, hash
 	}
 	p.recentSnaps.Add(snap.Hash, snap)
 
-	// If we've generated a new checkpoint snapshot, save to disk
+	// If we've generated a new checkpoint snapshot, hand it off to the
+	// background pipeline instead of blocking header verification on disk
+	// I/O; a burst of checkpoints in quick succession coalesces into the
+	// single write that pipeline.run gets to.
 	if snap.Number%checkpointInterval == 0 && len(headers) > 0 {
-		if err = snap.store(p.db); err != nil {
-			return nil, err
-		}
-		log.Trace("Stored snapshot to disk", "number", snap.Number, "hash", snap.Hash)
+		p.snapshotPipeline.Store(snap)
+		log.Trace("Queued snapshot for background store", "number", snap.Number, "hash", snap.Hash)
 	}
 	return snap, err
 }
@@ -633,13 +794,20 @@ func (p *Anchor) verifySeal(chain consensus.ChainHeaderReader, header *types.Hea
 		return errUnauthorizedValidator
 	}
 
-	for seen, recent := range snap.Recents {
-		if recent == signer {
-			// Signer is among recents, only fail if the current block doesn't shift it out
-			if limit := uint64(len(snap.Validators)/2 + 1); seen > number-limit {
-				return errRecentlySigned
-			}
-		}
+	// A validator sealing two distinct headers at the same height is
+	// evidence of double-signing. verifySeal runs over every header this
+	// node happens to verify - canonical or not, in whatever order sync
+	// and reorg handling hands them over - so this only records the
+	// evidence for an operator to inspect via API.PendingSlashEvidence and
+	// submit as an explicit slashDoubleSign transaction; it must never be
+	// auto-embedded into a block this node produces, since two honest
+	// nodes can observe a different set of headers here.
+	if p.config.SlashConfig != nil && p.config.SlashConfig.Enable {
+		p.slashEvidence.Observe(header, signer)
+	}
+
+	if IsRecentlySigned(snap, signer, number) {
+		return errRecentlySigned
 	}
 
 	// Ensure that the difficulty corresponds to the turn-ness of the signer
@@ -666,40 +834,20 @@ func (p *Anchor) Prepare(chain consensus.ChainHeaderReader, header *types.Header
 	header.Nonce = types.BlockNonce{}
 	number := header.Number.Uint64()
 	anchorBlockNumber := p.config.AnchorBlockNumber(number)
-	waitL1BlockNumber := p.config.ConfirmVersionBlockNumber(number)
-
-	loopInterval := 2 * time.Second
-	timer := time.NewTimer(0)
-	logCount := 0
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM) // catch SIGINT and SIGTERM
 
-WaitingLoop:
-	anchorBlockHeight, err := p.mainIPC.BlockNumber(context.TODO())
+	// Block until anchorWatcher has seen anchorBlockNumber confirmed by at
+	// least the network's ConfirmationDepth, instead of the old fixed-interval
+	// BlockNumber poll. ErrAnchorReorg propagates straight out: the miner
+	// reruns Prepare, which pins a fresh anchor hash against the
+	// now-canonical L1 chain. Signal handling for operator-initiated
+	// shutdown lives in the node lifecycle, not here, since Prepare can be
+	// called repeatedly over the life of the process.
+	anchorHeader, err := p.anchorWatcher.WaitForFinalized(context.Background(), anchorBlockNumber)
 	if err != nil {
 		return err
 	}
 
-	if anchorBlockHeight < waitL1BlockNumber {
-		if logCount%5 == 0 {
-			log.Info("Anchor block not yet finalized", "number", number, "anchornumber", anchorBlockNumber, "waitingnumber", waitL1BlockNumber, "anchorheight", anchorBlockHeight, "sleep", 2)
-		}
-		logCount++
-
-		for {
-			select {
-			case <-sigChan:
-				return errInterruptPrepare
-
-			case <-timer.C:
-				timer.Reset(loopInterval)
-				goto WaitingLoop
-			}
-		}
-	}
-	timer.Stop()
-
-	anchorBlock, err := p.mainIPC.BlockByNumber(context.TODO(), big.NewInt(0).SetUint64(anchorBlockNumber))
+	anchorBlock, err := p.mainIPC.BlockByNumber(context.Background(), anchorHeader.Number)
 	if err != nil {
 		return err
 	}
@@ -726,13 +874,29 @@ WaitingLoop:
 	// add extra seal space
 	header.Extra = append(header.Extra, make([]byte, extraSeal)...)
 
-	// Set gas price
-	baseGasPrice, err := p.getBaseGasPrice(header.ParentHash)
-	if err != nil {
-		return err
+	// Set gas price. Before the LondonAnchorBlock fork this polled
+	// SystemDao.baseGasPrice on every block; afterward it's a standard
+	// EIP-1559 adjustment off the parent header, with only the floor
+	// (MinBaseFeeContract) still sourced from a contract, read once and
+	// then refreshed only on breathe blocks.
+	if p.isLondonAnchor(header.Number) {
+		parentForBaseFee := chain.GetHeader(header.ParentHash, number-1)
+		if parentForBaseFee == nil {
+			return consensus.ErrUnknownAncestor
+		}
+		minBaseFee, err := p.minBaseFee(header.ParentHash)
+		if err != nil {
+			return err
+		}
+		header.BaseFee = p.calcAnchorBaseFee(parentForBaseFee, minBaseFee)
+	} else {
+		baseGasPrice, err := p.getBaseGasPrice(header.ParentHash)
+		if err != nil {
+			return err
+		}
+		chain.Config().GasPrice = baseGasPrice
+		header.BaseFee = baseGasPrice
 	}
-	chain.Config().GasPrice = baseGasPrice
-	header.BaseFee = baseGasPrice
 
 	// Mix digest is reserved for now, set to empty
 	header.MixDigest = common.Hash{}
@@ -774,16 +938,22 @@ func (p *Anchor) Finalize(chain consensus.ChainHeaderReader, header *types.Heade
 		}
 	}
 
+	if p.isAnchorBreatheForkBlock(header.Number) {
+		if err := p.initializeBreatheContracts(state, header, cx, txs, receipts, systemTxs, usedGas, false); err != nil {
+			log.Error("init breathe contract failed")
+		}
+	}
+
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if isBreatheBlock(parent, header) {
+		if err := p.handleBreatheBlock(state, header, cx, txs, receipts, systemTxs, usedGas, false); err != nil {
+			log.Error("handle breathe block failed", "err", err)
+		}
+	}
+
 	if header.Difficulty.Cmp(diffInTurn) != 0 {
 		spoiledVal := snap.supposeValidator()
-		signedRecently := false
-		for _, recent := range snap.Recents {
-			if recent == spoiledVal {
-				signedRecently = true
-				break
-			}
-		}
-		if !signedRecently {
+		if !IsRecentlySigned(snap, spoiledVal, number) {
 			log.Trace("slash validator", "block hash", header.Hash(), "address", spoiledVal)
 			err = p.slash(spoiledVal, state, header, cx, txs, receipts, systemTxs, usedGas, false)
 			if err != nil {
@@ -794,7 +964,7 @@ func (p *Anchor) Finalize(chain consensus.ChainHeaderReader, header *types.Heade
 	}
 
 	for _, sysTx := range *systemTxs {
-		isSubmitProofTx, err := p.isSubmitRequestProofTransaction(sysTx, header)
+		isSubmitProofTx, err := p.isSubmitRequestProofBatchTransaction(sysTx, header)
 		if err != nil {
 			return err
 		}
@@ -818,11 +988,13 @@ func (p *Anchor) Finalize(chain consensus.ChainHeaderReader, header *types.Heade
 	}
 
 	// hande token exchange
-	exTxs, err := p.anchorContract.l1ExchangesOfBlockNumber(p.config.AnchorBlockNumber(header.Number.Uint64()))
+	p.exchangeTracker.ObserveHead(header.Number.Uint64())
+	l1BlockNumber := p.config.AnchorBlockNumber(header.Number.Uint64())
+	exTxs, err := p.exchangeTracker.Fetch(p.anchorContract, l1BlockNumber)
 	if err != nil {
 		return errors.New("get anchor net exchange transaction failed")
 	}
-	if err = p.handleAnchorTokenExchange(exTxs, state, header, cx, txs, receipts, systemTxs, usedGas, false); err != nil {
+	if err = p.handleAnchorTokenExchange(l1BlockNumber, exTxs, state, header, cx, txs, receipts, systemTxs, usedGas, false); err != nil {
 		return errors.New("handleAnchorTokenExchange transaction failed")
 	}
 
@@ -870,6 +1042,18 @@ func (p *Anchor) FinalizeAndAssemble(chain consensus.ChainHeaderReader, header *
 		}
 	}
 
+	if p.isAnchorBreatheForkBlock(header.Number) {
+		if err := p.initializeBreatheContracts(state, header, cx, &txs, &receipts, nil, &header.GasUsed, true); err != nil {
+			log.Error("init breathe contract failed")
+		}
+	}
+
+	if parent := chain.GetHeader(header.ParentHash, header.Number.Uint64()-1); isBreatheBlock(parent, header) {
+		if err := p.handleBreatheBlock(state, header, cx, &txs, &receipts, nil, &header.GasUsed, true); err != nil {
+			log.Error("handle breathe block failed", "err", err)
+		}
+	}
+
 	if header.Difficulty.Cmp(diffInTurn) != 0 {
 		number := header.Number.Uint64()
 		snap, err := p.snapshot(chain, number-1, header.ParentHash, nil)
@@ -877,14 +1061,7 @@ func (p *Anchor) FinalizeAndAssemble(chain consensus.ChainHeaderReader, header *
 			return nil, nil, err
 		}
 		spoiledVal := snap.supposeValidator()
-		signedRecently := false
-		for _, recent := range snap.Recents {
-			if recent == spoiledVal {
-				signedRecently = true
-				break
-			}
-		}
-		if !signedRecently {
+		if !IsRecentlySigned(snap, spoiledVal, number) {
 			err = p.slash(spoiledVal, state, header, cx, &txs, &receipts, nil, &header.GasUsed, true)
 			if err != nil {
 				// it is possible that slash validator failed because of the slash channel is disabled.
@@ -893,27 +1070,25 @@ func (p *Anchor) FinalizeAndAssemble(chain consensus.ChainHeaderReader, header *
 		}
 	}
 
-	proofs, err := p.anchorContract.l2BurnProofs(header.ParentHash, header.Coinbase, p.signTextFn)
+	batch, err := p.anchorContract.l2BurnProofs(header.ParentHash, header.Coinbase, p.blsSignFn)
 	if err != nil {
 		log.Warn("Submit BurnProof Failed", "number", header.Number, "error", err.Error())
-	} else if proofs != nil {
-		for _, proof := range *proofs {
-			data, err := p.anchorABI.Pack("submitRequestProof", proof.Index, proof.Signature)
-			if err != nil {
-				panic(err)
-			}
+	} else if batch != nil {
+		data, err := p.anchorABI.Pack("submitRequestProof", batch.Start, batch.End, batch.Root, batch.Signature, batch.ParticipantBitmap)
+		if err != nil {
+			panic(err)
+		}
 
-			msg := p.getSystemMessage(
-				header.Coinbase,
-				common.HexToAddress(systemcontracts.AnchorContract),
-				data,
-				common.Big0,
-			)
+		msg := p.getSystemMessage(
+			header.Coinbase,
+			common.HexToAddress(systemcontracts.AnchorContract),
+			data,
+			common.Big0,
+		)
 
-			if err = p.applyTransaction(msg, state, header, cx, &txs, &receipts, nil, &header.GasUsed, true); err != nil {
-				log.Error("Submit BurnProof Failed", "number", header.Number, "burnReqId", proof.Index, "reqHash", proof.Hash)
-				return nil, nil, errors.New("submit burn proofs failed")
-			}
+		if err = p.applyTransaction(msg, state, header, cx, &txs, &receipts, nil, &header.GasUsed, true); err != nil {
+			log.Error("Submit BurnProof Failed", "number", header.Number, "start", batch.Start, "end", batch.End, "root", batch.Root)
+			return nil, nil, errors.New("submit burn proofs failed")
 		}
 	}
 
@@ -922,11 +1097,13 @@ func (p *Anchor) FinalizeAndAssemble(chain consensus.ChainHeaderReader, header *
 	}
 
 	// hande token exchange
-	exTxs, err := p.anchorContract.l1ExchangesOfBlockNumber(p.config.AnchorBlockNumber(header.Number.Uint64()))
+	p.exchangeTracker.ObserveHead(header.Number.Uint64())
+	l1BlockNumber := p.config.AnchorBlockNumber(header.Number.Uint64())
+	exTxs, err := p.exchangeTracker.Fetch(p.anchorContract, l1BlockNumber)
 	if err != nil {
 		return nil, nil, errors.New("get anchor net exchange transaction failed")
 	}
-	if err = p.handleAnchorTokenExchange(exTxs, state, header, cx, &txs, &receipts, nil, &header.GasUsed, true); err != nil {
+	if err = p.handleAnchorTokenExchange(l1BlockNumber, exTxs, state, header, cx, &txs, &receipts, nil, &header.GasUsed, true); err != nil {
 		return nil, nil, errors.New("handleAnchorTokenExchange transaction failed")
 	}
 
@@ -975,7 +1152,7 @@ func (p *Anchor) FinalizeAndAssemble(chain consensus.ChainHeaderReader, header *
 
 // Authorize injects a private key into the consensus engine to mint new blocks
 // with.
-func (p *Anchor) Authorize(val common.Address, signFn SignerFn, signTxFn SignerTxFn, signTextFn SignTextFn) {
+func (p *Anchor) Authorize(val common.Address, signFn SignerFn, signTxFn SignerTxFn, signTextFn SignTextFn, blsSignFn BLSSigner) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
@@ -983,6 +1160,23 @@ func (p *Anchor) Authorize(val common.Address, signFn SignerFn, signTxFn SignerT
 	p.signFn = signFn
 	p.signTxFn = signTxFn
 	p.signTextFn = signTextFn
+	p.blsSignFn = blsSignFn
+}
+
+// Validator returns the address this engine seals blocks as, so a builder
+// component running alongside it (e.g. the miner's bundle-bidding path) can
+// tell whether this node is itself the in-turn validator or needs to bid
+// into one instead.
+func (p *Anchor) Validator() common.Address {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.val
+}
+
+// BundlePool returns the MEV bundle pool MEVAPI.SendBundle queues into and
+// fillTransactionsAndBundles drains from.
+func (p *Anchor) BundlePool() *bundlepool.BundlePool {
+	return p.bundlePool
 }
 
 // Delay Argument leftOver is the time reserved for block finalize(calculate root, distribute income...)
@@ -1018,14 +1212,9 @@ func (p *Anchor) Seal(chain consensus.ChainHeaderReader, block *types.Block, res
 	}
 
 	// If we're amongst the recent signers, wait for the next block
-	for seen, recent := range snap.Recents {
-		if recent == val {
-			// Signer is among recents, only wait if the current block doesn't shift it out
-			if limit := uint64(len(snap.Validators)/2 + 1); number < limit || seen > number-limit {
-				log.Info("Signed recently, must wait for others")
-				return nil
-			}
-		}
+	if IsRecentlySigned(snap, val, number) {
+		log.Info("Signed recently, must wait for others")
+		return nil
 	}
 
 	// Sweet, the protocol permits us to sign the block, wait for our time
@@ -1121,13 +1310,8 @@ func (p *Anchor) SignRecently(chain consensus.ChainReader, parent *types.Block)
 
 	// If we're amongst the recent signers, wait for the next block
 	number := parent.NumberU64() + 1
-	for seen, recent := range snap.Recents {
-		if recent == p.val {
-			// Signer is among recents, only wait if the current block doesn't shift it out
-			if limit := uint64(len(snap.Validators)/2 + 1); number < limit || seen > number-limit {
-				return true, nil
-			}
-		}
+	if IsRecentlySigned(snap, p.val, number) {
+		return true, nil
 	}
 	return false, nil
 }
@@ -1160,16 +1344,37 @@ func (p *Anchor) SealHash(header *types.Header) common.Hash {
 
 // APIs implements consensus.Engine, returning the user facing RPC API to query snapshot.
 func (p *Anchor) APIs(chain consensus.ChainHeaderReader) []rpc.API {
-	return []rpc.API{{
+	apis := []rpc.API{{
 		Namespace: "anchor",
 		Version:   "1.0",
 		Service:   &API{chain: chain, anchor: p},
 		Public:    false,
 	}}
+
+	builderAPI, err := NewBuilderAPI(p, chain)
+	if err != nil {
+		log.Warn("anchor: external builder API unavailable", "err", err)
+	} else {
+		apis = append(apis, rpc.API{
+			Namespace: "anchor",
+			Version:   "1.0",
+			Service:   builderAPI,
+			Public:    false,
+		})
+	}
+
+	return append(apis, rpc.API{
+		Namespace: "mev",
+		Version:   "1.0",
+		Service:   NewMEVAPI(chain, p.bundlePool),
+		Public:    true,
+	})
 }
 
-// Close implements consensus.Engine. It's a noop for parlia as there are no background threads.
+// Close implements consensus.Engine, flushing any snapshot still queued in
+// the background persistence pipeline before shutdown.
 func (p *Anchor) Close() error {
+	p.snapshotPipeline.Close()
 	return nil
 }
 
@@ -1230,7 +1435,7 @@ func (p *Anchor) getBaseGasPrice(blockHash common.Hash) (*big.Int, error) {
 	return ret0, nil
 }
 
-func (p *Anchor) handleAnchorTokenExchange(exTxs *[]L1ExchangeTransaction,
+func (p *Anchor) handleAnchorTokenExchange(l1BlockNumber uint64, exTxs *[]L1ExchangeTransaction,
 	state *state.StateDB, header *types.Header, chain core.ChainContext,
 	txs *[]*types.Transaction, receipts *[]*types.Receipt, receivedTxs *[]*types.Transaction, usedGas *uint64, mining bool) (err error) {
 
@@ -1242,7 +1447,26 @@ func (p *Anchor) handleAnchorTokenExchange(exTxs *[]L1ExchangeTransaction,
 	method := "anchorTokenFrom"
 	originReceiptLen := len(*receipts)
 
-	for _, tx := range *exTxs {
+	pending := p.exchangeTracker.Pending(l1BlockNumber, *exTxs)
+	for _, i := range pending {
+		tx := (*exTxs)[i]
+
+		// exchangeTracker.Pending only reflects this process's in-memory
+		// view, which a restart wipes clean - so a request this process
+		// already minted before restarting would otherwise look pending
+		// again. The on-chain nullifier markExchangeProcessed committed is
+		// the source of truth; anchorTokenFrom must never run without
+		// checking it first.
+		processed, err := p.anchorContract.isExchangeProcessed(header.ParentHash, l1BlockNumber, i)
+		if err != nil {
+			log.Warn("Unable to check isExchangeProcessed", "l1BlockNumber", l1BlockNumber, "index", i, "error", err)
+			continue
+		}
+		if processed {
+			p.exchangeTracker.MarkProcessed(l1BlockNumber, i, header.Number.Uint64())
+			continue
+		}
+
 		// get packed data
 		data, err := p.anchorABI.Pack(method, tx)
 		if err != nil {
@@ -1256,10 +1480,25 @@ func (p *Anchor) handleAnchorTokenExchange(exTxs *[]L1ExchangeTransaction,
 			data,
 			common.Big0,
 		)
-		err = p.applyTransaction(msg, state, header, chain, txs, receipts, receivedTxs, usedGas, mining)
-		if err != nil {
+		if err := p.applyTransaction(msg, state, header, chain, txs, receipts, receivedTxs, usedGas, mining); err != nil {
 			log.Warn("Handle AnchorTokenFrom Execution Reverted", "fromToken", tx.FromToken, "toToken", tx.ToToken, "amount", tx.Amount)
+			continue
+		}
+
+		// Record the nullifier both locally, for this tracker's
+		// in-memory idempotency check, and on-chain, so every node
+		// that replays this block agrees the request is spent.
+		markData, err := p.anchorABI.Pack("markExchangeProcessed", new(big.Int).SetUint64(l1BlockNumber), new(big.Int).SetUint64(uint64(i)))
+		if err != nil {
+			log.Warn("Unable to pack tx for markExchangeProcessed", "error", err)
+			continue
 		}
+		markMsg := p.getSystemMessage(header.Coinbase, common.HexToAddress(systemcontracts.AnchorContract), markData, common.Big0)
+		if err := p.applyTransaction(markMsg, state, header, chain, txs, receipts, receivedTxs, usedGas, mining); err != nil {
+			log.Warn("markExchangeProcessed Execution Reverted", "l1BlockNumber", l1BlockNumber, "index", i)
+			continue
+		}
+		p.exchangeTracker.MarkProcessed(l1BlockNumber, i, header.Number.Uint64())
 	}
 
 	type ReceivedEvent struct {
@@ -1320,10 +1559,40 @@ func (p *Anchor) isBlackAddress(blockHash common.Hash, blockNumber *big.Int, add
 	return ret0, nil
 }
 
-// slash spoiled validators
+// slash pushes a slash(address,uint256) call to the slash system contract
+// for a validator that missed its in-turn block, the same way
+// handleBlockSubscriber drives SystemDao. A nil or disabled SlashConfig
+// leaves this a no-op, matching the pre-existing behavior for chains that
+// haven't deployed a slash contract.
+//
+// Double-sign evidence is deliberately NOT submitted from here: verifySeal
+// observes it off node-local, non-deterministic state (whatever headers
+// this node happened to verify during sync/reorg handling, in whatever
+// order), so baking it into "the next block" as a system transaction made
+// two honest nodes diverge on state root. It's submitted as an ordinary
+// slashDoubleSign transaction instead - see API.PendingSlashEvidence and
+// API.SlashDoubleSignCalldata - which goes through the normal tx
+// pool/consensus agreement every other transaction does.
 func (p *Anchor) slash(spoiledVal common.Address, state *state.StateDB, header *types.Header, chain core.ChainContext,
 	txs *[]*types.Transaction, receipts *[]*types.Receipt, receivedTxs *[]*types.Transaction, usedGas *uint64, mining bool) error {
-	return nil
+	if p.config.SlashConfig == nil || !p.config.SlashConfig.Enable {
+		return nil
+	}
+
+	method := "slash"
+	data, err := p.slashABI.Pack(method, spoiledVal, big.NewInt(1))
+	if err != nil {
+		log.Error("Unable to pack tx for slash", "error", err)
+		return err
+	}
+
+	msg := p.getSystemMessage(
+		header.Coinbase,
+		p.config.SlashConfig.ContractAddress,
+		data,
+		common.Big0,
+	)
+	return p.applyTransaction(msg, state, header, chain, txs, receipts, receivedTxs, usedGas, mining)
 }
 
 // init contract
@@ -1467,26 +1736,82 @@ func encodeSigHeader(w io.Writer, header *types.Header, chainId *big.Int) {
 	}
 }
 
-func backOffTime(snap *Snapshot, val common.Address) uint64 {
-	if snap.inturn(val) {
+// backOffTime computes val's out-turn delay at the height snap represents
+// + 1. Once AnchorTurnLengthBlock has activated, "in turn" is evaluated
+// against the current on-chain turnLength (Validators[(number/turnLength) %
+// N] holds the turn for turnLength consecutive heights) instead of
+// snap.inturn's one-block-per-validator rotation, which still decides the
+// turn pre-fork. The recently-signed handling below (the early
+// recentlySignedBackOff return, the eligible-only shuffle and the dropped
+// base delay) applies both before and after the fork.
+func (p *Anchor) backOffTime(snap *Snapshot, val common.Address) uint64 {
+	number := snap.Number + 1
+	counts := countRecents(snap)
+
+	// A validator that has already signed within the window shouldn't race
+	// back in the moment it's technically its numeric turn again - Recents
+	// hasn't shifted it out yet, so let it sit out this round entirely.
+	if signedRecentlyByCounts(val, counts) {
+		return recentlySignedBackOff
+	}
+
+	inTurn := snap.inturn(val)
+	if p.isAnchorTurnLengthFork(new(big.Int).SetUint64(number)) {
+		if length, err := p.turnLength(snap.Hash); err == nil {
+			inTurn = inTurnWithLength(snap, number, length, val)
+		} else {
+			log.Warn("Unable to read turnLength, falling back to per-block rotation", "number", number, "error", err)
+		}
+	}
+
+	if inTurn {
 		return 0
-	} else {
-		idx := snap.indexOfVal(val)
-		if idx < 0 {
-			// The backOffTime does not matter when a validator is not authorized.
-			return 0
+	}
+
+	idx := snap.indexOfVal(val)
+	if idx < 0 {
+		// The backOffTime does not matter when a validator is not authorized.
+		return 0
+	}
+
+	// Only validators that haven't signed recently compete for a step in
+	// the shuffle - one of them is who should actually pick up the slot if
+	// the in-turn signer is unavailable.
+	eligible := make([]common.Address, 0, len(snap.Validators))
+	for _, addr := range sortedValidators(snap) {
+		if !signedRecentlyByCounts(addr, counts) {
+			eligible = append(eligible, addr)
 		}
-		s := rand.NewSource(int64(snap.Number))
-		r := rand.New(s)
-		n := len(snap.Validators)
-		backOffSteps := make([]uint64, 0, n)
-		for idx := uint64(0); idx < uint64(n); idx++ {
-			backOffSteps = append(backOffSteps, idx)
+	}
+	pos := -1
+	for i, addr := range eligible {
+		if addr == val {
+			pos = i
+			break
 		}
-		r.Shuffle(n, func(i, j int) {
-			backOffSteps[i], backOffSteps[j] = backOffSteps[j], backOffSteps[i]
-		})
-		delay := initialBackOffTime + backOffSteps[idx]*wiggleTime
-		return delay
 	}
+	if pos < 0 {
+		// val itself was just excluded above, so this can't happen, but
+		// keep the same "doesn't matter" fallback as the unauthorized case.
+		return 0
+	}
+
+	s := rand.NewSource(int64(snap.Number))
+	r := rand.New(s)
+	n := len(eligible)
+	backOffSteps := make([]uint64, 0, n)
+	for idx := uint64(0); idx < uint64(n); idx++ {
+		backOffSteps = append(backOffSteps, idx)
+	}
+	r.Shuffle(n, func(i, j int) {
+		backOffSteps[i], backOffSteps[j] = backOffSteps[j], backOffSteps[i]
+	})
+
+	base := initialBackOffTime
+	if signedRecentlyByCounts(p.inTurnValidator(snap, number), counts) {
+		// The in-turn signer is itself offline: drop the usual minimum
+		// delay so a successor can produce without waiting out dead time.
+		base = 0
+	}
+	return base + backOffSteps[pos]*wiggleTime
 }
@@ -0,0 +1,27 @@
+package anchor
+
+import "github.com/ethereum/go-ethereum/common"
+
+// recentlySignedBackOff is returned by backOffTime for a validator that has
+// already signed within snap.Recents' window: large enough that no header
+// timestamp verifyCascadingFields will accept could clear
+// parent.Time + period + this, which amounts to refusing to sign without
+// giving backOffTime itself a way to return an error.
+const recentlySignedBackOff = ^uint64(0) / 2
+
+// countRecents tallies how many of snap.Recents' entries belong to each
+// validator, the basis backOffTime and signedRecentlyByCounts use to avoid
+// repeatedly re-deriving "has X signed recently" from the raw map.
+func countRecents(snap *Snapshot) map[common.Address]int {
+	counts := make(map[common.Address]int, len(snap.Validators))
+	for _, signer := range snap.Recents {
+		counts[signer]++
+	}
+	return counts
+}
+
+// signedRecentlyByCounts reports whether addr appears in counts, i.e. has
+// signed within the current "recently signed" window.
+func signedRecentlyByCounts(addr common.Address, counts map[common.Address]int) bool {
+	return counts[addr] > 0
+}
@@ -0,0 +1,131 @@
+package anchor
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Defaults for the EIP-1559-style fields a chain can override via
+// AnchorConfig.BaseFeeChangeDenominator/ElasticityMultiplier, matching
+// mainnet's own 1/8 adjustment and 2x elasticity.
+const (
+	defaultBaseFeeChangeDenominator = 8
+	defaultElasticityMultiplier     = 2
+)
+
+// isLondonAnchor reports whether number has reached the dynamic-base-fee
+// fork, replacing the old per-block SystemDao.baseGasPrice poll.
+func (p *Anchor) isLondonAnchor(number *big.Int) bool {
+	fork := p.chainConfig.LondonAnchorBlock
+	return fork != nil && number.Cmp(fork) >= 0
+}
+
+// calcAnchorBaseFee computes header.BaseFee for the block following parent,
+// the standard EIP-1559 1/8-denominator adjustment toward parent's gas
+// usage relative to its elasticity-scaled target, floored at minBaseFee.
+func (p *Anchor) calcAnchorBaseFee(parent *types.Header, minBaseFee *big.Int) *big.Int {
+	denom := p.config.BaseFeeChangeDenominator
+	if denom == 0 {
+		denom = defaultBaseFeeChangeDenominator
+	}
+	elasticity := p.config.ElasticityMultiplier
+	if elasticity == 0 {
+		elasticity = defaultElasticityMultiplier
+	}
+
+	parentBaseFee := parent.BaseFee
+	if parentBaseFee == nil {
+		parentBaseFee = new(big.Int).Set(minBaseFee)
+	}
+
+	gasTarget := parent.GasLimit / elasticity
+	if parent.GasUsed == gasTarget {
+		return clampBaseFee(parentBaseFee, minBaseFee)
+	}
+
+	var baseFeeDelta *big.Int
+	if parent.GasUsed > gasTarget {
+		gasUsedDelta := new(big.Int).SetUint64(parent.GasUsed - gasTarget)
+		x := new(big.Int).Mul(parentBaseFee, gasUsedDelta)
+		y := x.Div(x, new(big.Int).SetUint64(gasTarget))
+		baseFeeDelta = math.BigMax(y.Div(y, new(big.Int).SetUint64(denom)), common.Big1)
+		return clampBaseFee(new(big.Int).Add(parentBaseFee, baseFeeDelta), minBaseFee)
+	}
+
+	gasUsedDelta := new(big.Int).SetUint64(gasTarget - parent.GasUsed)
+	x := new(big.Int).Mul(parentBaseFee, gasUsedDelta)
+	y := x.Div(x, new(big.Int).SetUint64(gasTarget))
+	baseFeeDelta = y.Div(y, new(big.Int).SetUint64(denom))
+	return clampBaseFee(new(big.Int).Sub(parentBaseFee, baseFeeDelta), minBaseFee)
+}
+
+func clampBaseFee(fee, minBaseFee *big.Int) *big.Int {
+	if minBaseFee != nil && fee.Cmp(minBaseFee) < 0 {
+		return new(big.Int).Set(minBaseFee)
+	}
+	return fee
+}
+
+// minBaseFee returns the cached minimum base fee, reading it from
+// MinBaseFeeContract only when nothing is cached yet. After that the
+// cached value changes only when handleBreatheBlock calls
+// refreshMinBaseFee; there is no per-epoch or ChainHeadEvent refresh.
+func (p *Anchor) minBaseFee(blockHash common.Hash) (*big.Int, error) {
+	p.lock.RLock()
+	cached := p.cachedMinBaseFee
+	p.lock.RUnlock()
+	if cached != nil {
+		return cached, nil
+	}
+	return p.refreshMinBaseFee(blockHash)
+}
+
+// refreshMinBaseFee re-reads the minimum base fee from MinBaseFeeContract
+// and updates the cache. Apart from minBaseFee's first read, its only
+// caller is handleBreatheBlock, so the cache is refreshed on breathe
+// blocks instead of every block.
+func (p *Anchor) refreshMinBaseFee(blockHash common.Hash) (*big.Int, error) {
+	contract := p.config.MinBaseFeeContract
+	if (contract == common.Address{}) {
+		contract = common.HexToAddress(systemcontracts.SystemDaoContract)
+	}
+
+	blockNr := rpc.BlockNumberOrHashWithHash(blockHash, false)
+	method := "minBaseFee"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	data, err := p.systemDaoABI.Pack(method)
+	if err != nil {
+		return nil, err
+	}
+	msgData := (hexutil.Bytes)(data)
+	gas := (hexutil.Uint64)(uint64(1 << 62))
+	result, err := p.ethAPI.Call(ctx, ethapi.TransactionArgs{
+		Gas:  &gas,
+		To:   &contract,
+		Data: &msgData,
+	}, blockNr, nil)
+	if err != nil {
+		return nil, fmt.Errorf("anchor: reading min base fee: %w", err)
+	}
+
+	var minFee *big.Int
+	if err := p.systemDaoABI.UnpackIntoInterface(&minFee, method, result); err != nil {
+		return nil, err
+	}
+
+	p.lock.Lock()
+	p.cachedMinBaseFee = minFee
+	p.lock.Unlock()
+	return minFee, nil
+}
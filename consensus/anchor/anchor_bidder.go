@@ -0,0 +1,99 @@
+package anchor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ValidatorEndpoints resolves a validator's address (as it appears in
+// Snapshot.Validators) to the RPC endpoint Bidder should submit bids to.
+// Nothing in this tree maps one to the other today - there's no equivalent
+// of BuilderJWTSecretPath for "which URL does validator X listen on" - so
+// AnchorConfig would need a new field (e.g. a address->URL table) before
+// this can be populated for real. It's taken as an interface here so that
+// gap can be filled later without reshaping Bidder itself.
+type ValidatorEndpoints interface {
+	Endpoint(validator common.Address) (string, bool)
+}
+
+// Bidder runs on a builder node - one that produces blocks but, per the
+// current Snapshot, isn't itself in turn - submitting its best bundle-backed
+// block as a bid to whichever validator is in turn instead of sealing it
+// locally. It mirrors BuilderAPI's role in reverse: BuilderAPI is the
+// in-turn validator's side of an external builder relationship, Bidder is
+// the builder's side of it.
+type Bidder struct {
+	anchor    *Anchor
+	chain     ChainHeaderReader
+	endpoints ValidatorEndpoints
+	token     string
+}
+
+// ChainHeaderReader is the subset of consensus.ChainHeaderReader Bidder
+// needs, named locally so this file doesn't have to import consensus just
+// for the one method used below.
+type ChainHeaderReader interface {
+	CurrentHeader() *types.Header
+}
+
+// NewBidder wires a Bidder against anchor's own validator identity
+// (Anchor.Validator) and the given endpoint resolver. token is the JWT
+// bearer presented to the in-turn validator's BuilderAPI.authenticate,
+// matching whatever AnchorConfig.BuilderJWTSecretPath that validator was
+// configured with; an empty token only works against a validator that
+// hasn't configured one either.
+func NewBidder(anchor *Anchor, chain ChainHeaderReader, endpoints ValidatorEndpoints, token string) *Bidder {
+	return &Bidder{anchor: anchor, chain: chain, endpoints: endpoints, token: token}
+}
+
+// ShouldBid reports whether the local node, for the block that would follow
+// head, is a builder rather than the in-turn validator - i.e. whether it
+// should submit a bid instead of sealing the block itself. It resolves the
+// in-turn address the same turnLength-aware way backOffTime does.
+func (b *Bidder) ShouldBid(snap *Snapshot, head *types.Header) bool {
+	number := head.Number.Uint64() + 1
+	inTurn := b.anchor.inTurnValidator(snap, number)
+	return inTurn != b.anchor.Validator()
+}
+
+// SubmitBid sends block as a bid to the validator currently in turn,
+// resolved via endpoints from snap.Validators. It fails if no endpoint is
+// known for that validator - expected until AnchorConfig grows a way to
+// configure the address->URL table ValidatorEndpoints needs.
+func (b *Bidder) SubmitBid(ctx context.Context, snap *Snapshot, block *types.Block) error {
+	head := b.chain.CurrentHeader()
+	if head == nil {
+		return errors.New("anchor: no current header")
+	}
+	inTurn := b.anchor.inTurnValidator(snap, head.Number.Uint64()+1)
+	if bytes.Equal(inTurn.Bytes(), (common.Address{}).Bytes()) {
+		return errors.New("anchor: could not resolve in-turn validator")
+	}
+
+	endpoint, ok := b.endpoints.Endpoint(inTurn)
+	if !ok {
+		return errors.New("anchor: no known RPC endpoint for in-turn validator " + inTurn.Hex())
+	}
+
+	client, err := rpc.DialContext(ctx, endpoint)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// NewPayload's third parameter is a results channel used only on the
+	// validator's own in-process call from BuilderAPI; it isn't part of the
+	// RPC wire signature, so it's omitted here.
+	var status NewPayloadStatus
+	if err := client.CallContext(ctx, &status, "anchor_newPayload", b.token, blockToExecutionPayload(block)); err != nil {
+		log.Warn("anchor: bid submission rejected", "validator", inTurn, "number", block.NumberU64(), "error", err)
+		return err
+	}
+	return nil
+}
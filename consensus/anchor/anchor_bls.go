@@ -0,0 +1,212 @@
+package anchor
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// maxBurnProofParticipants caps the t-of-n validator set a burn-batch proof
+// can aggregate over, so the participant bitmap fits in a single 32-byte
+// word for a cheap bitmap check alongside L1's pairing check.
+const maxBurnProofParticipants = 128
+
+// BLSSigner produces one validator's partial signature share over a
+// burn-batch message: sigma_i = s_i * H(m) in G2, where s_i is the
+// validator's Shamir share of the epoch's aggregate signing key, handed out
+// at genesis/epoch rotation alongside the compressed G1 commitment to its
+// public key. It plays the role SignTextFn played for the per-proof ECDSA
+// path it replaces.
+type BLSSigner func(account accounts.Account, message []byte) (*PartialSig, error)
+
+// PartialSig is one validator's contribution towards an aggregated
+// burn-batch proof: its index into the current epoch's participant set (so
+// aggregateBurnProofs can look up the matching G1 public key and Lagrange
+// coefficient) and its compressed G2 signature share.
+type PartialSig struct {
+	ValidatorIndex uint8
+	Signature      []byte // compressed G2 point
+}
+
+// participantBitmap packs a set of validator indices into the 32-byte
+// bitmap L1 checks the aggregate public key commitment against, bit i set
+// iff validator i contributed a share. Duplicate or out-of-range indices
+// are rejected outright: a duplicate would let one validator's share count
+// twice in the Lagrange combination, forging weight it doesn't have.
+func participantBitmap(indices []uint8) (bitmap [32]byte, err error) {
+	seen := make(map[uint8]bool, len(indices))
+	for _, idx := range indices {
+		if idx >= maxBurnProofParticipants {
+			return bitmap, fmt.Errorf("anchor: validator index %d exceeds max participant count %d", idx, maxBurnProofParticipants)
+		}
+		if seen[idx] {
+			return bitmap, fmt.Errorf("anchor: duplicate participant index %d", idx)
+		}
+		seen[idx] = true
+		bitmap[idx/8] |= 1 << (idx % 8)
+	}
+	return bitmap, nil
+}
+
+// lagrangeCoefficient returns lambda_i, the Lagrange basis polynomial for
+// validator index i evaluated at x=0 over the participant set indices, mod
+// the BLS12-381 scalar field order - the standard t-of-n threshold
+// combination coefficient. Participant indices are evaluated at x=i+1 so
+// that x=0, the evaluation point, is never itself a valid share index.
+func lagrangeCoefficient(indices []uint8, i uint8) *big.Int {
+	order := bls12381.Order()
+	num := big.NewInt(1)
+	den := big.NewInt(1)
+	xi := new(big.Int).SetUint64(uint64(i) + 1)
+	for _, j := range indices {
+		if j == i {
+			continue
+		}
+		xj := new(big.Int).SetUint64(uint64(j) + 1)
+		num.Mul(num, new(big.Int).Neg(xj))
+		num.Mod(num, order)
+		den.Mul(den, new(big.Int).Sub(xi, xj))
+		den.Mod(den, order)
+	}
+	den.ModInverse(den, order)
+	return num.Mul(num, den).Mod(num, order)
+}
+
+// hashToG2 maps a burn-batch message onto a point in G2. This is a
+// simplified hash-to-curve (keccak256 expansion into the two Fp2
+// coordinates MapToCurve expects) rather than a full RFC 9380
+// expand_message_xmd; it is deterministic and collision-resistant for our
+// purposes but should be swapped for the standardized construction before
+// this is relied on in a production deployment.
+func hashToG2(message []byte) (*bls12381.PointG2, error) {
+	g2 := bls12381.NewG2()
+	u0 := bls12381.Keccak256ToFp2(append([]byte{0x00}, message...))
+	u1 := bls12381.Keccak256ToFp2(append([]byte{0x01}, message...))
+	return g2.MapToCurve([2]*bls12381.Fp2{u0, u1})
+}
+
+// verifyPartialSig checks one partial share against its validator's
+// committed public key via e(g1, sigma_i) == e(pk_i, H(m)), returning the
+// decoded signature point on success. It's the single place both
+// aggregateBurnProofs and the share-collection RPC boundary
+// (L2AnchorContract.SubmitBurnProofShare) call to authenticate a share,
+// so a share is only ever accepted once it's actually been proven to come
+// from the validator it claims to.
+func verifyPartialSig(share PartialSig, pubKeys map[uint8][]byte, hm *bls12381.PointG2) (*bls12381.PointG2, error) {
+	pkRaw, ok := pubKeys[share.ValidatorIndex]
+	if !ok {
+		return nil, fmt.Errorf("anchor: no committed public key for validator index %d", share.ValidatorIndex)
+	}
+	g1 := bls12381.NewG1()
+	g2 := bls12381.NewG2()
+	pk, err := g1.FromCompressed(pkRaw)
+	if err != nil {
+		return nil, fmt.Errorf("anchor: decoding public key for validator index %d: %w", share.ValidatorIndex, err)
+	}
+	sig, err := g2.FromCompressed(share.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("anchor: decoding signature share for validator index %d: %w", share.ValidatorIndex, err)
+	}
+	pe := bls12381.NewPairingEngine()
+	pe.AddPair(g1.One(), sig)
+	pe.AddPairInv(pk, hm)
+	if !pe.Check() {
+		return nil, fmt.Errorf("anchor: partial signature for validator index %d failed pairing check", share.ValidatorIndex)
+	}
+	return sig, nil
+}
+
+// verifyBurnProofShare is the message-level wrapper around verifyPartialSig
+// for callers that only have the raw burn-batch message, not an
+// already-hashed-to-G2 point - namely SubmitBurnProofShare, authenticating a
+// share at the moment it's gossiped in rather than waiting to find out it
+// was garbage once aggregateBurnProofs runs.
+func verifyBurnProofShare(share PartialSig, pubKeys map[uint8][]byte, message []byte) error {
+	hm, err := hashToG2(message)
+	if err != nil {
+		return fmt.Errorf("anchor: hashing burn-batch message to G2: %w", err)
+	}
+	_, err = verifyPartialSig(share, pubKeys, hm)
+	return err
+}
+
+// popcount returns the number of set bits in bitmap, i.e. how many
+// validators actually ended up contributing to the aggregate signature it
+// describes.
+func popcount(bitmap [32]byte) int {
+	n := 0
+	for _, b := range bitmap {
+		for b != 0 {
+			n += int(b & 1)
+			b >>= 1
+		}
+	}
+	return n
+}
+
+// aggregateBurnProofs verifies each partial share against its validator's
+// committed public key via e(g1, sigma_i) == e(pk_i, H(m)), then
+// Lagrange-interpolates the shares that pass in G2 into the one aggregate
+// signature L1 verifies with a single pairing check,
+// e(g1, sigma) == e(aggPk, H(m)).
+//
+// A share that fails its pairing check is skipped rather than aborting the
+// whole aggregation: shares are gossiped in over an unauthenticated RPC
+// surface (see SubmitBurnProofShare), so one bad or malicious submission
+// must not be able to deny every honest validator's contribution. The
+// returned bitmap only ever marks the shares that actually survived, so the
+// caller can compare popcount(bitmap) against its own threshold to tell a
+// genuinely-short round from one that merely had noise in it.
+func aggregateBurnProofs(proofs []PartialSig, pubKeys map[uint8][]byte, message []byte) (signature []byte, bitmap [32]byte, err error) {
+	if len(proofs) == 0 {
+		return nil, bitmap, errors.New("anchor: no partial signatures to aggregate")
+	}
+
+	hm, err := hashToG2(message)
+	if err != nil {
+		return nil, bitmap, fmt.Errorf("anchor: hashing burn-batch message to G2: %w", err)
+	}
+
+	type verified struct {
+		index uint8
+		sig   *bls12381.PointG2
+	}
+	good := make([]verified, 0, len(proofs))
+	for _, p := range proofs {
+		sig, err := verifyPartialSig(p, pubKeys, hm)
+		if err != nil {
+			log.Warn("anchor: skipping invalid burn-proof share", "validatorIndex", p.ValidatorIndex, "err", err)
+			continue
+		}
+		good = append(good, verified{index: p.ValidatorIndex, sig: sig})
+	}
+	if len(good) == 0 {
+		return nil, bitmap, errors.New("anchor: no partial signatures survived their pairing check")
+	}
+
+	indices := make([]uint8, 0, len(good))
+	for _, v := range good {
+		indices = append(indices, v.index)
+	}
+	bitmap, err = participantBitmap(indices)
+	if err != nil {
+		return nil, bitmap, err
+	}
+
+	g2 := bls12381.NewG2()
+	var aggSig *bls12381.PointG2
+	for _, v := range good {
+		weighted := g2.MulScalar(g2.New(), v.sig, lagrangeCoefficient(indices, v.index))
+		if aggSig == nil {
+			aggSig = weighted
+		} else {
+			aggSig = g2.Add(g2.New(), aggSig, weighted)
+		}
+	}
+
+	return g2.ToCompressed(aggSig), bitmap, nil
+}
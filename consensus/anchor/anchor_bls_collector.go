@@ -0,0 +1,158 @@
+package anchor
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// burnShareCollectWindow bounds how long l2BurnProofs waits for the rest of
+// the epoch's BLS participants to gossip in their partial share over
+// SubmitBurnProofShare before giving up on this block's batch - a burn
+// batch that can't reach threshold in time is retried on the next block's
+// l2BurnProofs call (prfNonce never advances past it) rather than blocking
+// consensus indefinitely.
+const burnShareCollectWindow = 2 * time.Second
+
+// burnShareRoundTTL bounds how long an unawaited round is kept around before
+// it's swept as abandoned. SubmitBurnProofShare is reachable by any RPC
+// caller, not just the epoch's real BLS participants, so a root that never
+// gets an Await call (because nothing ever produced it) must still be
+// reclaimed instead of sitting in rounds forever.
+const burnShareRoundTTL = 10 * burnShareCollectWindow
+
+// maxPendingBurnShareRounds caps how many distinct, not-yet-awaited roots
+// Submit will track at once, independent of the TTL sweep - a hard ceiling
+// on memory an unauthenticated caller can force the collector to hold,
+// rather than relying solely on time-based eviction to keep up.
+const maxPendingBurnShareRounds = 256
+
+// ErrBurnProofThresholdNotMet is returned by l2BurnProofs when fewer than
+// BLSThreshold partial shares for a burn-batch root arrived within
+// burnShareCollectWindow - it must never fall back to aggregating whatever
+// it collected, since that would silently downgrade the t-of-n guarantee to
+// 1-of-n.
+var ErrBurnProofThresholdNotMet = errors.New("anchor: did not collect BLSThreshold partial shares for burn-batch root before timeout")
+
+// burnShareRound accumulates partial shares for one burn-batch root and
+// signals every waiter once threshold distinct validators have contributed.
+type burnShareRound struct {
+	shares    map[uint8]PartialSig
+	ready     chan struct{}
+	closed    bool
+	createdAt time.Time
+}
+
+// BurnProofShareCollector is the coordinator side of the share-gossip
+// transport l2BurnProofs depends on to turn its own partial share into a
+// real t-of-n aggregate: every epoch participant submits its share here -
+// the block proposer via l2BurnProofs itself, every other validator over
+// the anchor_submitBurnProofShare RPC method API.SubmitBurnProofShare
+// exposes - and l2BurnProofs blocks on Await until at least threshold of
+// them have arrived for its root, instead of aggregating whatever it alone
+// produced.
+type BurnProofShareCollector struct {
+	threshold int
+
+	mu     sync.Mutex
+	rounds map[common.Hash]*burnShareRound
+}
+
+// NewBurnProofShareCollector returns a collector that considers a root's
+// round complete once threshold distinct validator shares have been
+// submitted for it. A non-positive threshold is treated as 1, the minimum
+// meaningful value - a round can't ever close otherwise.
+func NewBurnProofShareCollector(threshold int) *BurnProofShareCollector {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &BurnProofShareCollector{
+		threshold: threshold,
+		rounds:    make(map[common.Hash]*burnShareRound),
+	}
+}
+
+// round returns root's in-progress round, creating it if this is the first
+// share or waiter to reference it. Callers must hold c.mu.
+func (c *BurnProofShareCollector) round(root common.Hash) *burnShareRound {
+	r, ok := c.rounds[root]
+	if !ok {
+		r = &burnShareRound{shares: make(map[uint8]PartialSig), ready: make(chan struct{}), createdAt: time.Now()}
+		c.rounds[root] = r
+	}
+	return r
+}
+
+// evictExpiredLocked drops every round older than burnShareRoundTTL. Await
+// already removes a round the moment it's collected, so this only ever
+// reclaims rounds nothing waited on - e.g. ones Submit created for a root an
+// unauthenticated caller made up. Callers must hold c.mu.
+func (c *BurnProofShareCollector) evictExpiredLocked() {
+	deadline := time.Now().Add(-burnShareRoundTTL)
+	for root, r := range c.rounds {
+		if r.createdAt.Before(deadline) {
+			delete(c.rounds, root)
+		}
+	}
+}
+
+// Submit records share as root's contribution from share.ValidatorIndex,
+// closing the round's ready channel the moment threshold distinct indices
+// have been recorded. Resubmitting the same validator's share for a root
+// it already contributed to only overwrites its entry - it can't let one
+// validator count twice toward threshold.
+//
+// Submit itself trusts neither root nor share.ValidatorIndex - callers
+// reachable from the anchor_submitBurnProofShare RPC (see
+// L2AnchorContract.SubmitBurnProofShare) must authenticate the share before
+// calling this. What Submit does bound on its own is memory: it sweeps
+// expired rounds and refuses to start tracking a brand new root once
+// maxPendingBurnShareRounds is already in flight, so a flood of shares for
+// roots nobody ever produced can't grow rounds without limit.
+func (c *BurnProofShareCollector) Submit(root common.Hash, share PartialSig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.evictExpiredLocked()
+	if _, exists := c.rounds[root]; !exists && len(c.rounds) >= maxPendingBurnShareRounds {
+		return
+	}
+
+	r := c.round(root)
+	if r.closed {
+		return
+	}
+	r.shares[share.ValidatorIndex] = share
+	if len(r.shares) >= c.threshold {
+		r.closed = true
+		close(r.ready)
+	}
+}
+
+// Await blocks until root's round reaches threshold or timeout elapses,
+// then returns whatever shares have been collected so far - which may be
+// fewer than threshold, if it timed out. The round is discarded either way;
+// the caller must check the returned count against threshold itself before
+// trusting the result, since Await only waits.
+func (c *BurnProofShareCollector) Await(root common.Hash, timeout time.Duration) []PartialSig {
+	c.mu.Lock()
+	r := c.round(root)
+	ready := r.ready
+	c.mu.Unlock()
+
+	select {
+	case <-ready:
+	case <-time.After(timeout):
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	shares := make([]PartialSig, 0, len(r.shares))
+	for _, s := range r.shares {
+		shares = append(shares, s)
+	}
+	delete(c.rounds, root)
+	return shares
+}
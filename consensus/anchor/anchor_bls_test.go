@@ -0,0 +1,97 @@
+package anchor
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+)
+
+func TestParticipantBitmap(t *testing.T) {
+	bitmap, err := participantBitmap([]uint8{0, 1, 8, 127})
+	if err != nil {
+		t.Fatalf("participantBitmap returned an unexpected error: %v", err)
+	}
+	for _, idx := range []uint8{0, 1, 8, 127} {
+		if bitmap[idx/8]&(1<<(idx%8)) == 0 {
+			t.Errorf("bit for index %d not set", idx)
+		}
+	}
+
+	if _, err := participantBitmap([]uint8{3, 3}); err == nil {
+		t.Error("expected an error for a duplicate participant index")
+	}
+	if _, err := participantBitmap([]uint8{maxBurnProofParticipants}); err == nil {
+		t.Error("expected an error for an out-of-range participant index")
+	}
+}
+
+func TestPopcount(t *testing.T) {
+	var bitmap [32]byte
+	if got := popcount(bitmap); got != 0 {
+		t.Errorf("popcount of empty bitmap = %d, want 0", got)
+	}
+	bitmap[0] = 0b00000111
+	bitmap[4] = 0b00000001
+	if got := popcount(bitmap); got != 4 {
+		t.Errorf("popcount = %d, want 4", got)
+	}
+}
+
+func TestLagrangeCoefficientTrivialSingleParticipant(t *testing.T) {
+	// With only one participant in the set, its Lagrange basis polynomial
+	// at x=0 degenerates to the empty product: 1.
+	if got := lagrangeCoefficient([]uint8{5}, 5); got.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("lagrangeCoefficient for a lone participant = %s, want 1", got)
+	}
+}
+
+func TestAggregateBurnProofsSkipsInvalidSharesInsteadOfAborting(t *testing.T) {
+	message := []byte("burn-batch-root")
+
+	secret := big.NewInt(424242)
+	g1 := bls12381.NewG1()
+	g2 := bls12381.NewG2()
+	pub := g1.ToCompressed(g1.MulScalar(g1.New(), g1.One(), secret))
+
+	hm, err := hashToG2(message)
+	if err != nil {
+		t.Fatalf("hashToG2 failed: %v", err)
+	}
+	sig := g2.ToCompressed(g2.MulScalar(g2.New(), hm, secret))
+
+	pubKeys := map[uint8][]byte{1: pub}
+	good := PartialSig{ValidatorIndex: 1, Signature: sig}
+	// Index 2 has no committed public key at all, and a second share under
+	// index 1 carries a garbage signature - both must be skipped rather than
+	// failing the batch.
+	unknownSigner := PartialSig{ValidatorIndex: 2, Signature: sig}
+	garbage := PartialSig{ValidatorIndex: 1, Signature: []byte("not-a-signature")}
+
+	signature, bitmap, err := aggregateBurnProofs([]PartialSig{good, unknownSigner, garbage}, pubKeys, message)
+	if err != nil {
+		t.Fatalf("aggregateBurnProofs returned an unexpected error: %v", err)
+	}
+	if popcount(bitmap) != 1 {
+		t.Fatalf("expected exactly 1 surviving share, got popcount=%d", popcount(bitmap))
+	}
+	if bitmap[0]&(1<<1) == 0 {
+		t.Error("expected index 1's valid share to be the one that survived")
+	}
+	// With a single surviving participant its Lagrange coefficient is 1,
+	// so the aggregate should equal that lone valid share unchanged.
+	if !bytes.Equal(signature, sig) {
+		t.Error("aggregate of a single surviving share should equal that share's own signature")
+	}
+}
+
+func TestAggregateBurnProofsErrorsWhenNoShareSurvives(t *testing.T) {
+	message := []byte("burn-batch-root")
+	proofs := []PartialSig{
+		{ValidatorIndex: 1, Signature: []byte("garbage")},
+	}
+	if _, _, err := aggregateBurnProofs(proofs, map[uint8][]byte{}, message); err == nil {
+		t.Error("expected an error when every share fails verification")
+	}
+}
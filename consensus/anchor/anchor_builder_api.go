@@ -0,0 +1,363 @@
+package anchor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ExecutionPayload is the Engine-API-style wire format the external
+// builder exchanges with BuilderAPI: enough of a sealed-but-unsigned
+// block's header and body for a builder to assemble and hand back.
+type ExecutionPayload struct {
+	ParentHash   common.Hash     `json:"parentHash"`
+	FeeRecipient common.Address  `json:"feeRecipient"`
+	StateRoot    common.Hash     `json:"stateRoot"`
+	ReceiptsRoot common.Hash     `json:"receiptsRoot"`
+	Number       hexutil.Uint64  `json:"blockNumber"`
+	GasLimit     hexutil.Uint64  `json:"gasLimit"`
+	GasUsed      hexutil.Uint64  `json:"gasUsed"`
+	Timestamp    hexutil.Uint64  `json:"timestamp"`
+	ExtraData    hexutil.Bytes   `json:"extraData"`
+	BaseFee      *hexutil.Big    `json:"baseFeePerGas"`
+	BlockHash    common.Hash     `json:"blockHash"`
+	Transactions []hexutil.Bytes `json:"transactions"`
+}
+
+// PayloadID identifies one in-flight block-building job, as returned by
+// ForkchoiceUpdated and consumed by GetPayload.
+type PayloadID [8]byte
+
+func (id PayloadID) String() string { return hexutil.Encode(id[:]) }
+
+// NewPayloadStatus is the status GetPayload/NewPayload hands back,
+// matching the Engine-API's VALID/INVALID/SYNCING vocabulary.
+type NewPayloadStatus struct {
+	Status      string       `json:"status"`
+	SealedBlock *types.Block `json:"-"`
+}
+
+// builderPayload is the block this engine assembled for one payloadId,
+// kept unsealed (no validator signature yet) until NewPayload comes back
+// with one.
+type builderPayload struct {
+	block     *types.Block
+	createdAt time.Time
+}
+
+// payloadStore holds outstanding builder jobs by PayloadID, bounded by age
+// rather than count - builders are expected to call GetPayload within a
+// few slots of ForkchoiceUpdated.
+type payloadStore struct {
+	mu       sync.Mutex
+	payloads map[PayloadID]*builderPayload
+	ttl      time.Duration
+}
+
+func newPayloadStore(ttl time.Duration) *payloadStore {
+	if ttl <= 0 {
+		ttl = 2 * time.Minute
+	}
+	return &payloadStore{payloads: make(map[PayloadID]*builderPayload), ttl: ttl}
+}
+
+func (s *payloadStore) put(id PayloadID, block *types.Block) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.payloads[id] = &builderPayload{block: block, createdAt: time.Now()}
+	for existingID, payload := range s.payloads {
+		if time.Since(payload.createdAt) > s.ttl {
+			delete(s.payloads, existingID)
+		}
+	}
+}
+
+func (s *payloadStore) get(id PayloadID) (*types.Block, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	payload, ok := s.payloads[id]
+	if !ok || time.Since(payload.createdAt) > s.ttl {
+		return nil, false
+	}
+	return payload.block, true
+}
+
+// payloadIDFor derives a PayloadID deterministically from the inputs that
+// select a unique block-building job, the same way the job itself is
+// uniquely determined by them.
+func payloadIDFor(parentHash common.Hash, timestamp uint64, feeRecipient common.Address) PayloadID {
+	var buf [8 + 20]byte
+	binary.BigEndian.PutUint64(buf[:8], timestamp)
+	copy(buf[8:], feeRecipient.Bytes())
+	hash := parentHash
+	var id PayloadID
+	for i := range id {
+		id[i] = hash[i] ^ buf[i%len(buf)]
+	}
+	return id
+}
+
+// BuilderAPI exposes an Engine-API-style external block-production surface
+// in the anchor RPC namespace, so a validator can delegate block assembly
+// to an off-chain builder while Anchor still enforces PoA authorization
+// via Seal's signing path. JWT-authenticated callers are additionally
+// required to be a validator in the snapshot at the chain's current head.
+type BuilderAPI struct {
+	anchor   *Anchor
+	chain    consensus.ChainHeaderReader
+	payloads *payloadStore
+
+	jwtSecret []byte
+
+	// assemble builds an unsigned candidate block on top of parent. This
+	// engine doesn't hold a StateDB or txpool of its own - those belong to
+	// whichever miner/worker runs this engine - so ForkchoiceUpdated
+	// delegates the actual assembly to whatever the embedder registers via
+	// SetAssembler, and reports a clear error if nothing has.
+	assemble func(parent *types.Header, timestamp uint64, feeRecipient common.Address) (*types.Block, error)
+}
+
+// NewBuilderAPI loads the shared JWT secret (if AnchorConfig.BuilderJWTSecretPath
+// is set) and wires a BuilderAPI on top of anchor.
+func NewBuilderAPI(anchor *Anchor, chain consensus.ChainHeaderReader) (*BuilderAPI, error) {
+	api := &BuilderAPI{anchor: anchor, chain: chain, payloads: newPayloadStore(0)}
+	if path := anchor.config.BuilderJWTSecretPath; path != "" {
+		secret, err := loadJWTSecret(path)
+		if err != nil {
+			return nil, fmt.Errorf("builder api: loading jwt secret: %w", err)
+		}
+		api.jwtSecret = secret
+	}
+	return api, nil
+}
+
+// SetAssembler registers the miner/worker's block-assembly function, the
+// code path that runs what FinalizeAndAssemble runs (system txs,
+// handleAnchorTokenExchange, farm finalization) against a real StateDB.
+// ForkchoiceUpdated refuses to produce a payload until this is set.
+func (b *BuilderAPI) SetAssembler(assemble func(parent *types.Header, timestamp uint64, feeRecipient common.Address) (*types.Block, error)) {
+	b.assemble = assemble
+}
+
+// loadJWTSecret reads a hex-encoded shared secret from path, the same
+// convention go-ethereum's own authenticated Engine API endpoint uses.
+func loadJWTSecret(path string) ([]byte, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := hexutil.Decode(strings.TrimSpace(string(raw)))
+	if err != nil {
+		return nil, fmt.Errorf("malformed jwt secret: %w", err)
+	}
+	if len(secret) < 32 {
+		return nil, errors.New("jwt secret must be at least 32 bytes")
+	}
+	return secret, nil
+}
+
+// authenticate verifies an HS256 JWT bearer token against the configured
+// shared secret and a freshness window on its "iat" claim, matching the
+// Engine API's own JWT convention. A BuilderAPI with no configured secret
+// authenticates every call, for chains that haven't opted into this
+// surface yet.
+func (b *BuilderAPI) authenticate(token string) error {
+	if len(b.jwtSecret) == 0 {
+		return nil
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errors.New("malformed jwt")
+	}
+	signingInput := parts[0] + "." + parts[1]
+	expected := hmacSHA256(b.jwtSecret, signingInput)
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(sig, expected) {
+		return errors.New("invalid jwt signature")
+	}
+
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return errors.New("malformed jwt claims")
+	}
+	var claims struct {
+		IssuedAt int64 `json:"iat"`
+	}
+	if err := json.Unmarshal(claimsJSON, &claims); err != nil {
+		return errors.New("malformed jwt claims")
+	}
+	if skew := time.Since(time.Unix(claims.IssuedAt, 0)); skew < -5*time.Second || skew > 60*time.Second {
+		return errors.New("jwt iat outside freshness window")
+	}
+	return nil
+}
+
+func hmacSHA256(secret []byte, message string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}
+
+// requireValidator rejects calls from a validator address not present in
+// the snapshot at the chain's current head.
+func (b *BuilderAPI) requireValidator(caller common.Address) error {
+	header := b.chain.CurrentHeader()
+	snap, err := b.anchor.snapshot(b.chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return err
+	}
+	if _, ok := snap.Validators[caller]; !ok {
+		return fmt.Errorf("caller %s is not a validator in the current snapshot", caller)
+	}
+	return nil
+}
+
+// ForkchoiceUpdated starts assembling a payload on top of parentHash for
+// timestamp/feeRecipient, running the same code FinalizeAndAssemble does
+// (system txs, handleAnchorTokenExchange, farm finalization), and returns
+// a payloadId for GetPayload to retrieve it by.
+func (b *BuilderAPI) ForkchoiceUpdated(token string, parentHash common.Hash, timestamp uint64, feeRecipient common.Address) (PayloadID, error) {
+	if err := b.authenticate(token); err != nil {
+		return PayloadID{}, err
+	}
+	if err := b.requireValidator(feeRecipient); err != nil {
+		return PayloadID{}, err
+	}
+
+	parent := b.chain.GetHeaderByHash(parentHash)
+	if parent == nil {
+		return PayloadID{}, errUnknownBlock
+	}
+	if b.assemble == nil {
+		return PayloadID{}, errors.New("builder api: no block assembler registered, call SetAssembler first")
+	}
+
+	block, err := b.assemble(parent, timestamp, feeRecipient)
+	if err != nil {
+		return PayloadID{}, err
+	}
+
+	id := payloadIDFor(parentHash, timestamp, feeRecipient)
+	b.payloads.put(id, block)
+	return id, nil
+}
+
+// GetPayload returns the previously assembled, still-unsigned block for
+// id as an ExecutionPayload.
+func (b *BuilderAPI) GetPayload(token string, id PayloadID) (*ExecutionPayload, error) {
+	if err := b.authenticate(token); err != nil {
+		return nil, err
+	}
+	block, ok := b.payloads.get(id)
+	if !ok {
+		return nil, fmt.Errorf("unknown payload id %s", id)
+	}
+	return blockToExecutionPayload(block), nil
+}
+
+// NewPayload validates a builder's returned ExecutionPayload, signs it via
+// signFn exactly like Seal does, and hands the sealed block to results.
+func (b *BuilderAPI) NewPayload(token string, payload *ExecutionPayload, results chan<- *types.Block) (*NewPayloadStatus, error) {
+	if err := b.authenticate(token); err != nil {
+		return nil, err
+	}
+	if err := b.requireValidator(payload.FeeRecipient); err != nil {
+		return &NewPayloadStatus{Status: "INVALID"}, err
+	}
+
+	block, err := sealExecutionPayload(b.anchor, payload)
+	if err != nil {
+		return &NewPayloadStatus{Status: "INVALID"}, err
+	}
+
+	select {
+	case results <- block:
+	default:
+		log.Warn("BuilderAPI.NewPayload: results channel full, dropping sealed block", "number", block.NumberU64())
+	}
+	return &NewPayloadStatus{Status: "VALID", SealedBlock: block}, nil
+}
+
+// sealExecutionPayload turns a builder-returned ExecutionPayload into a
+// sealed block, signing AnchorRLP(header, chainID) exactly like Seal does
+// for locally-assembled blocks.
+func sealExecutionPayload(p *Anchor, payload *ExecutionPayload) (*types.Block, error) {
+	txs := make([]*types.Transaction, len(payload.Transactions))
+	for i, raw := range payload.Transactions {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(raw); err != nil {
+			return nil, fmt.Errorf("builder api: invalid transaction %d: %w", i, err)
+		}
+		txs[i] = tx
+	}
+
+	header := &types.Header{
+		ParentHash:  payload.ParentHash,
+		Coinbase:    payload.FeeRecipient,
+		Root:        payload.StateRoot,
+		ReceiptHash: payload.ReceiptsRoot,
+		Number:      new(big.Int).SetUint64(uint64(payload.Number)),
+		GasLimit:    uint64(payload.GasLimit),
+		GasUsed:     uint64(payload.GasUsed),
+		Time:        uint64(payload.Timestamp),
+		Extra:       payload.ExtraData,
+		BaseFee:     (*big.Int)(payload.BaseFee),
+	}
+	if header.Hash() != payload.BlockHash {
+		return nil, errors.New("builder api: payload blockHash does not match its own header fields")
+	}
+	if len(header.Extra) < extraSeal {
+		return nil, errMissingSignature
+	}
+
+	p.lock.RLock()
+	val, signFn := p.val, p.signFn
+	p.lock.RUnlock()
+
+	sig, err := signFn(accounts.Account{Address: val}, accounts.MimetypeAnchor, AnchorRLP(header, p.chainConfig.ChainID))
+	if err != nil {
+		return nil, err
+	}
+	copy(header.Extra[len(header.Extra)-extraSeal:], sig)
+
+	return types.NewBlockWithHeader(header).WithBody(txs, nil), nil
+}
+
+func blockToExecutionPayload(block *types.Block) *ExecutionPayload {
+	header := block.Header()
+	txs := make([]hexutil.Bytes, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		raw, _ := tx.MarshalBinary()
+		txs[i] = raw
+	}
+	return &ExecutionPayload{
+		ParentHash:   header.ParentHash,
+		FeeRecipient: header.Coinbase,
+		StateRoot:    header.Root,
+		ReceiptsRoot: header.ReceiptHash,
+		Number:       hexutil.Uint64(header.Number.Uint64()),
+		GasLimit:     hexutil.Uint64(header.GasLimit),
+		GasUsed:      hexutil.Uint64(header.GasUsed),
+		Timestamp:    hexutil.Uint64(header.Time),
+		ExtraData:    header.Extra,
+		BaseFee:      (*hexutil.Big)(header.BaseFee),
+		BlockHash:    header.Hash(),
+		Transactions: txs,
+	}
+}
@@ -2,6 +2,7 @@ package anchor
 
 import (
 	"context"
+	"fmt"
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/abi"
@@ -11,7 +12,6 @@ import (
 	"github.com/ethereum/go-ethereum/core/systemcontracts"
 	"github.com/ethereum/go-ethereum/core/systemcontracts/anchor"
 	"github.com/ethereum/go-ethereum/core/systemcontracts/parlia"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/params"
@@ -28,6 +28,17 @@ type L2AnchorContract struct {
 	cli         *ethclient.Client
 	api         *ethapi.PublicBlockChainAPI
 	chainConfig *params.ChainConfig // Chain config
+
+	// networkInfo is the anchor networks manager's view of this network,
+	// including the current epoch's BLS threshold-signing commitment
+	// (BLSValidatorPubKeys/BLSValidatorAddresses/BLSThreshold) that
+	// l2BurnProofs aggregates burn-batch proofs against.
+	networkInfo *anchor_network.AnchorNetworkInfo
+
+	// shareCollector gathers the other epoch participants' partial shares
+	// (gossiped in via SubmitBurnProofShare) so l2BurnProofs can aggregate a
+	// real t-of-n signature instead of just its own share.
+	shareCollector *BurnProofShareCollector
 }
 
 type L1ExchangeTransaction struct {
@@ -47,10 +58,21 @@ type L2ExchangeTransaction struct {
 	Amount      *big.Int
 }
 
-type L2BrunProof struct {
-	Index     *big.Int
-	Hash      common.Hash
-	Signature []byte
+// L2BurnBatch commits every pending burn request in [Start, End) to a
+// single sparse Merkle Root (see anchor.NewBurnMerkleTree), signed once via
+// the threshold BLS aggregation in anchor_bls.go: ParticipantBitmap marks
+// which of the up to maxBurnProofParticipants validators in the current
+// epoch contributed a partial share, and Signature is the Lagrange-combined
+// G2 point L1 verifies against the matching aggregate public key with a
+// single pairing check. A specific request's membership in Root is proven
+// independently, on demand, via anchor.GenerateBurnInclusionProof/
+// VerifyBurnInclusion rather than being carried in this struct.
+type L2BurnBatch struct {
+	Root              common.Hash
+	Start             *big.Int
+	End               *big.Int
+	Signature         []byte
+	ParticipantBitmap [32]byte
 }
 
 func getAnchorNetworkInfo(cli *ethclient.Client, chainConfig *params.ChainConfig) (*anchor_network.AnchorNetworkInfo, error) {
@@ -105,19 +127,64 @@ func NewAnchorContract(cli *ethclient.Client, localAPI *ethapi.PublicBlockChainA
 	}
 
 	return &L2AnchorContract{
-		address:     info.AnchorContract,
-		l1AnchorAbi: l1abi,
-		l2AnchorAbi: l2abi,
-		cli:         cli,
-		api:         localAPI,
-		chainConfig: config,
+		address:        info.AnchorContract,
+		l1AnchorAbi:    l1abi,
+		l2AnchorAbi:    l2abi,
+		cli:            cli,
+		api:            localAPI,
+		chainConfig:    config,
+		networkInfo:    info,
+		shareCollector: NewBurnProofShareCollector(info.BLSThreshold),
 	}, nil
 }
 
+// SubmitBurnProofShare records a remote validator's partial BLS signature
+// over a burn-batch root, gossiped in via the anchor_submitBurnProofShare RPC
+// method (api.go's API.SubmitBurnProofShare). It's the other half of the
+// share-collection transport l2BurnProofs's own call into shareCollector.Submit
+// depends on: without it, l2BurnProofs would only ever see its own share.
+//
+// anchor_submitBurnProofShare has no caller authentication of its own, so
+// this is where a share earns its way into shareCollector: it's rejected
+// outright unless it actually verifies against the committed public key for
+// share.ValidatorIndex, the same pairing check aggregateBurnProofs performs.
+// That turns "submit garbage under a real validator's index" from a silent
+// last-write-wins overwrite into something only the validator holding that
+// index's real BLS share can do.
+func (l2c *L2AnchorContract) SubmitBurnProofShare(root common.Hash, share PartialSig) error {
+	if err := verifyBurnProofShare(share, l2c.networkInfo.BLSValidatorPubKeys, root.Bytes()); err != nil {
+		return err
+	}
+	l2c.shareCollector.Submit(root, share)
+	return nil
+}
+
+// validatorIndexOf returns addr's index into the current epoch's BLS
+// participant set, the same index BLSValidatorPubKeys is keyed by, so
+// l2BurnProofs knows which slot to sign its partial share under.
+func (l2c *L2AnchorContract) validatorIndexOf(addr common.Address) (uint8, bool) {
+	for i, a := range l2c.networkInfo.BLSValidatorAddresses {
+		if a == addr {
+			return uint8(i), true
+		}
+	}
+	return 0, false
+}
+
 func (l2c *L2AnchorContract) l1ExchangesOfBlockNumber(l1BlockNumber uint64) (*[]L1ExchangeTransaction, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel() // cancel when we are finished consuming integers
 
+	if depth := l2c.networkInfo.ConfirmationDepth; depth > 0 {
+		head, err := l2c.cli.BlockNumber(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if head < l1BlockNumber || head-l1BlockNumber < depth {
+			return nil, fmt.Errorf("anchor: L1 block %d is not yet finalized (head %d, need %d confirmations)", l1BlockNumber, head, depth)
+		}
+	}
+
 	blkNum := big.NewInt(0).SetUint64(l1BlockNumber)
 
 	method := "exchangesOfBlockNumber"
@@ -126,7 +193,9 @@ func (l2c *L2AnchorContract) l1ExchangesOfBlockNumber(l1BlockNumber uint64) (*[]
 		panic(err)
 	}
 
-	// call
+	// call, pinned to l1BlockNumber itself rather than whatever L1 reports
+	// as head, so the view can't silently drift onto a different fork if
+	// the head moves between this call and the confirmation check above.
 	msgData := (hexutil.Bytes)(data)
 	result, err := l2c.cli.CallContract(
 		ctx,
@@ -137,7 +206,7 @@ func (l2c *L2AnchorContract) l1ExchangesOfBlockNumber(l1BlockNumber uint64) (*[]
 			GasPrice: nil,
 			Data:     msgData,
 		},
-		nil,
+		blkNum,
 	)
 	if err != nil {
 		return nil, err
@@ -155,19 +224,22 @@ func (l2c *L2AnchorContract) l1ExchangesOfBlockNumber(l1BlockNumber uint64) (*[]
 	return &ret0, nil
 }
 
-func mustNewType(solidityType string) abi.Type {
-	tp, err := abi.NewType(solidityType, "", nil)
-	if err != nil {
-		panic(err)
-	}
-	return tp
-}
-
-func (l2c *L2AnchorContract) l2BurnProofs(blockHash common.Hash, coinBase common.Address, signFn SignTextFn) (proofs *[]L2BrunProof, err error) {
+// l2BurnProofs commits the pending burn requests in the window
+// [prfNonce, reqNonce) - at most MaxBurnProofsPerBlock of them when that
+// is set - to a single sparse Merkle root and signs that root once,
+// instead of the old one-signature-per-request loop: L1 finalizes the
+// whole window in O(1) and later answers any per-request inclusion
+// challenge in O(log n) via anchor.VerifyBurnInclusion.
+func (l2c *L2AnchorContract) l2BurnProofs(blockHash common.Hash, coinBase common.Address, blsSignFn BLSSigner) (batch *L2BurnBatch, err error) {
 	if coinBase != l2c.chainConfig.Anchor.GenesisAddress {
 		return nil, nil
 	}
 
+	validatorIndex, ok := l2c.validatorIndexOf(coinBase)
+	if !ok {
+		return nil, fmt.Errorf("anchor: coinbase %s is not a committed BLS participant for this epoch", coinBase)
+	}
+
 	reqNonce, err := l2c.l2RequestNonce(blockHash)
 	if err != nil {
 		return nil, err
@@ -177,55 +249,78 @@ func (l2c *L2AnchorContract) l2BurnProofs(blockHash common.Hash, coinBase common
 	if err != nil {
 		return nil, err
 	}
+	if prfNonce.Uint64() >= reqNonce.Uint64() {
+		return nil, nil
+	}
 
-	// Index       *big.Int
-	// FromToken   common.Address
-	// FromAddress common.Address
-	// ToToken     common.Address
-	// ToAddress   common.Address
-	// Amount      *big.Int
-	arguments := abi.Arguments{
-		{Type: mustNewType("uint256")},
-		{Type: mustNewType("address")},
-		{Type: mustNewType("address")},
-		{Type: mustNewType("address")},
-		{Type: mustNewType("address")},
-		{Type: mustNewType("uint256")},
+	// MaxBurnProofsPerBlock bounds how much of [prfNonce, reqNonce) this
+	// block's batch commits to, so a large backlog spreads across several
+	// consecutive blocks instead of growing one block's Merkle tree (and its
+	// single BLS signature's fan-in) without limit. A pending window of
+	// exactly one request degenerates to the same code path below - there's
+	// no separate per-proof call to fall back to, since submitRequestProof
+	// has committed to the [Start, End) batch shape since it replaced the
+	// old one-signature-per-request loop.
+	end := reqNonce.Uint64()
+	if max := l2c.chainConfig.Anchor.MaxBurnProofsPerBlock; max > 0 && end-prfNonce.Uint64() > max {
+		end = prfNonce.Uint64() + max
 	}
 
-	var prfs []L2BrunProof
-	for i := prfNonce.Uint64(); i < reqNonce.Uint64() && i < 32; i++ {
+	leaves := make(map[uint64]common.Hash, end-prfNonce.Uint64())
+	for i := prfNonce.Uint64(); i < end; i++ {
 		brunReq, err := l2c.l2BurnTransaction(blockHash, i)
 		if err != nil {
 			return nil, err
 		}
 
-		encodeData, err := arguments.Pack(
-			brunReq.Index,
-			brunReq.FromToken,
-			brunReq.FromAddress,
-			brunReq.ToToken,
-			brunReq.ToAddress,
-			brunReq.Amount,
-		)
+		leaf, err := anchor.BurnLeafHash(brunReq.Index, brunReq.FromToken, brunReq.FromAddress, brunReq.ToToken, brunReq.ToAddress, brunReq.Amount)
 		if err != nil {
 			return nil, err
 		}
+		leaves[i] = leaf
+	}
 
-		brunReqHash := crypto.Keccak256Hash(encodeData)
-		signature, err := signFn(accounts.Account{Address: coinBase}, brunReqHash.Bytes())
-		if err != nil {
-			return nil, err
-		}
-		signature[64] += 27
+	root := anchor.NewBurnMerkleTree(leaves).Root()
+
+	share, err := blsSignFn(accounts.Account{Address: coinBase}, root.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	share.ValidatorIndex = validatorIndex
+
+	// The local sealer's own share is just one more submission into
+	// shareCollector: the rest of the epoch's participants gossip theirs in
+	// over SubmitBurnProofShare (the anchor_submitBurnProofShare RPC method),
+	// and Await blocks up to burnShareCollectWindow for at least
+	// BLSThreshold of them to arrive before this aggregates anything. A
+	// timeout that falls short of threshold must not silently degrade to
+	// whatever was collected - that would let a single sealer unilaterally
+	// authorize an L1 mint, exactly what the t-of-n scheme exists to prevent.
+	l2c.shareCollector.Submit(root, *share)
+	shares := l2c.shareCollector.Await(root, burnShareCollectWindow)
+	if len(shares) < l2c.networkInfo.BLSThreshold {
+		return nil, ErrBurnProofThresholdNotMet
+	}
 
-		prfs = append(prfs, L2BrunProof{
-			Index:     big.NewInt(0).SetUint64(i),
-			Hash:      brunReqHash,
-			Signature: signature,
-		})
+	signature, bitmap, err := aggregateBurnProofs(shares, l2c.networkInfo.BLSValidatorPubKeys, root.Bytes())
+	if err != nil {
+		return nil, err
 	}
-	return &prfs, nil
+	// aggregateBurnProofs skips any share that fails its pairing check
+	// rather than failing the whole batch, so the len(shares) check above
+	// isn't enough on its own - a round that collected BLSThreshold shares
+	// can still end up with fewer than that actually aggregated.
+	if popcount(bitmap) < l2c.networkInfo.BLSThreshold {
+		return nil, ErrBurnProofThresholdNotMet
+	}
+
+	return &L2BurnBatch{
+		Root:              root,
+		Start:             big.NewInt(0).SetUint64(prfNonce.Uint64()),
+		End:               big.NewInt(0).SetUint64(end),
+		Signature:         signature,
+		ParticipantBitmap: bitmap,
+	}, nil
 }
 
 func (l2c *L2AnchorContract) l2ProofNonce(blockHash common.Hash) (*big.Int, error) {
@@ -292,6 +387,42 @@ func (l2c *L2AnchorContract) l2RequestNonce(blockHash common.Hash) (*big.Int, er
 	return ret0, nil
 }
 
+// isExchangeProcessed reports whether markExchangeProcessed has already
+// committed the nullifier for (l1BlockNumber, index) on-chain as of
+// blockHash. handleAnchorTokenExchange checks this immediately before
+// calling anchorTokenFrom, since exchangeTracker.Pending alone is an
+// in-memory check that a restart (or a rebuilt block candidate at a height
+// whose prior attempt never got this far) can't be trusted to reflect.
+func (l2c *L2AnchorContract) isExchangeProcessed(blockHash common.Hash, l1BlockNumber uint64, index int) (bool, error) {
+	blockNr := rpc.BlockNumberOrHashWithHash(blockHash, false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // cancel when we are finished consuming integers
+
+	data, err := l2c.l2AnchorAbi.Pack("isExchangeProcessed", new(big.Int).SetUint64(l1BlockNumber), new(big.Int).SetUint64(uint64(index)))
+	if err != nil {
+		return false, err
+	}
+
+	msgData := (hexutil.Bytes)(data)
+	toAddress := common.HexToAddress(systemcontracts.AnchorContract)
+	gas := (hexutil.Uint64)(uint64(math.MaxUint64 / 2))
+	result, err := l2c.api.Call(ctx, ethapi.TransactionArgs{
+		Gas:  &gas,
+		To:   &toAddress,
+		Data: &msgData,
+	}, blockNr, nil)
+	if err != nil {
+		return false, err
+	}
+
+	var ret0 bool
+	if err := l2c.l2AnchorAbi.UnpackIntoInterface(&ret0, "isExchangeProcessed", result); err != nil {
+		return false, err
+	}
+	return ret0, nil
+}
+
 func (l2c *L2AnchorContract) l2BurnTransaction(blockHash common.Hash, index uint64) (*L2ExchangeTransaction, error) {
 	// block
 	blockNr := rpc.BlockNumberOrHashWithHash(blockHash, false)
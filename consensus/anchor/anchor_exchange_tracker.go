@@ -0,0 +1,148 @@
+package anchor
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// exchangeNullifierKey identifies one L1 exchange request for idempotent
+// processing. l2AnchorContract.l1ExchangesOfBlockNumber's ABI return type
+// doesn't carry the originating txHash/logIndex (see L1ExchangeTransaction
+// in anchor_contract.go), so this keys on the request's position within
+// its L1 block's slice instead - stable as long as exchangesOfBlockNumber
+// itself returns requests in a fixed order, which the contract guarantees
+// by construction (append-only per block).
+type exchangeNullifierKey struct {
+	l1BlockNumber uint64
+	index         int
+}
+
+// L1ExchangeTracker caches the exchange requests fetched per L1 anchor
+// block so Finalize and FinalizeAndAssemble share one RPC call instead of
+// each fetching independently, and tracks which requests have already been
+// minted so a reassembled or reorged L2 block can't double-mint or
+// silently drop one.
+type L1ExchangeTracker struct {
+	mu sync.Mutex
+
+	// window caches exchangesOfBlockNumber's result per L1 block number,
+	// a sliding cache rather than a single eager per-call fetch.
+	window map[uint64]*[]L1ExchangeTransaction
+
+	// processed maps a nullifier key to the L2 block number it was minted
+	// in, so OnL2Reorg can tell which entries belong to an orphaned chain
+	// and re-enable them.
+	processed map[exchangeNullifierKey]uint64
+
+	// highestObserved is the highest L2 block number ObserveHead has seen
+	// finalized so far. A call at a number at or below it means whatever
+	// finalized that height before has been discarded and is being rebuilt
+	// - the signal ObserveHead uses to trigger OnL2Reorg itself, since this
+	// engine has no direct subscription to the chain's reorg notifications.
+	highestObserved uint64
+}
+
+func newL1ExchangeTracker() *L1ExchangeTracker {
+	return &L1ExchangeTracker{
+		window:    make(map[uint64]*[]L1ExchangeTransaction),
+		processed: make(map[exchangeNullifierKey]uint64),
+	}
+}
+
+// ObserveHead must be called once per Finalize/FinalizeAndAssemble with the
+// L2 block number about to be finalized. A number at or below one already
+// observed means the chain discarded and is rebuilding that height - e.g. a
+// fork switch or a retried FinalizeAndAssemble candidate - so every
+// nullifier minted against a now-orphaned block number at or above it is
+// re-enabled via OnL2Reorg before this height's own processing continues.
+func (t *L1ExchangeTracker) ObserveHead(number uint64) {
+	t.mu.Lock()
+	reorged := number <= t.highestObserved
+	if number > t.highestObserved {
+		t.highestObserved = number
+	}
+	t.mu.Unlock()
+
+	if reorged && number > 0 {
+		t.OnL2Reorg(number - 1)
+	}
+}
+
+// Fetch returns the exchange requests pending at l1BlockNumber, querying
+// the anchor contract at most once per L1 block number regardless of how
+// many times Finalize/FinalizeAndAssemble ask for it.
+func (t *L1ExchangeTracker) Fetch(contract *L2AnchorContract, l1BlockNumber uint64) (*[]L1ExchangeTransaction, error) {
+	t.mu.Lock()
+	if cached, ok := t.window[l1BlockNumber]; ok {
+		t.mu.Unlock()
+		return cached, nil
+	}
+	t.mu.Unlock()
+
+	exTxs, err := contract.l1ExchangesOfBlockNumber(l1BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	t.window[l1BlockNumber] = exTxs
+	// Evict everything more than a few L1 blocks behind this one - once a
+	// block's requests have been marked processed on-chain there's no
+	// reason to keep re-caching them.
+	for cachedNumber := range t.window {
+		if cachedNumber+16 < l1BlockNumber {
+			delete(t.window, cachedNumber)
+		}
+	}
+	t.mu.Unlock()
+	return exTxs, nil
+}
+
+// Pending filters exTxs down to the ones not yet marked processed for
+// l1BlockNumber, so a reassembled FinalizeAndAssemble call (or a retry
+// after an aborted Finalize) doesn't mint the same request twice.
+func (t *L1ExchangeTracker) Pending(l1BlockNumber uint64, exTxs []L1ExchangeTransaction) []int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	pending := make([]int, 0, len(exTxs))
+	for i := range exTxs {
+		key := exchangeNullifierKey{l1BlockNumber: l1BlockNumber, index: i}
+		if _, done := t.processed[key]; !done {
+			pending = append(pending, i)
+		}
+	}
+	return pending
+}
+
+// MarkProcessed records that the request at (l1BlockNumber, index) was
+// minted into l2BlockNumber, alongside the markExchangeProcessed system tx
+// that commits the same fact on-chain.
+func (t *L1ExchangeTracker) MarkProcessed(l1BlockNumber uint64, index int, l2BlockNumber uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.processed[exchangeNullifierKey{l1BlockNumber: l1BlockNumber, index: index}] = l2BlockNumber
+}
+
+// OnL2Reorg re-enables every nullifier entry that was recorded against an
+// L2 block number beyond newHead, since those blocks - and whatever
+// minting they did - no longer exist on the canonical chain. Called from
+// ObserveHead, which infers a reorg from Finalize/FinalizeAndAssemble being
+// asked to finalize a height at or below one already seen, since this
+// consensus engine has no direct subscription to the chain's own reorg
+// notifications.
+func (t *L1ExchangeTracker) OnL2Reorg(newHead uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	reenabled := 0
+	for key, minedAt := range t.processed {
+		if minedAt > newHead {
+			delete(t.processed, key)
+			reenabled++
+		}
+	}
+	if reenabled > 0 {
+		log.Warn("L1ExchangeTracker: re-enabled exchange requests orphaned by L2 reorg", "count", reenabled, "newHead", newHead)
+	}
+}
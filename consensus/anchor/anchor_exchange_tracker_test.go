@@ -0,0 +1,57 @@
+package anchor
+
+import "testing"
+
+func TestL1ExchangeTrackerPendingSkipsProcessed(t *testing.T) {
+	tracker := newL1ExchangeTracker()
+	exTxs := make([]L1ExchangeTransaction, 3)
+
+	pending := tracker.Pending(10, exTxs)
+	if len(pending) != 3 {
+		t.Fatalf("expected all 3 requests pending before anything is processed, got %d", len(pending))
+	}
+
+	tracker.MarkProcessed(10, 1, 100)
+
+	pending = tracker.Pending(10, exTxs)
+	if len(pending) != 2 {
+		t.Fatalf("expected 2 requests pending after marking index 1 processed, got %d", len(pending))
+	}
+	for _, idx := range pending {
+		if idx == 1 {
+			t.Error("index 1 was marked processed but still came back pending")
+		}
+	}
+}
+
+func TestL1ExchangeTrackerOnL2ReorgReenablesOrphanedNullifiers(t *testing.T) {
+	tracker := newL1ExchangeTracker()
+	exTxs := make([]L1ExchangeTransaction, 2)
+
+	tracker.MarkProcessed(10, 0, 100) // minted into a block that will be orphaned
+	tracker.MarkProcessed(10, 1, 50)  // minted into a block that stays canonical
+
+	tracker.OnL2Reorg(90) // everything minted above L2 block 90 is discarded
+
+	pending := tracker.Pending(10, exTxs)
+	if len(pending) != 1 || pending[0] != 0 {
+		t.Fatalf("expected only index 0 re-enabled after reorg below its mint height, got %v", pending)
+	}
+}
+
+func TestL1ExchangeTrackerObserveHeadTriggersReorgOnRewind(t *testing.T) {
+	tracker := newL1ExchangeTracker()
+	exTxs := make([]L1ExchangeTransaction, 1)
+
+	tracker.ObserveHead(100)
+	tracker.MarkProcessed(10, 0, 100)
+
+	// A later call at or below a height already observed means that height
+	// is being rebuilt, so whatever it minted must be re-enabled.
+	tracker.ObserveHead(100)
+
+	pending := tracker.Pending(10, exTxs)
+	if len(pending) != 1 {
+		t.Fatalf("expected the request minted into the rewound block to be re-enabled, got %d pending", len(pending))
+	}
+}
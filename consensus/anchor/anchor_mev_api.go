@@ -0,0 +1,71 @@
+package anchor
+
+import (
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/txpool/bundlepool"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// MEVAPI exposes BEP-322-style bundle submission under the "mev" namespace.
+// It lives here, alongside the rest of this engine's custom RPC surface
+// (API, BuilderAPI), rather than in internal/ethapi: that package is
+// upstream go-ethereum and isn't part of this tree, the same reason
+// BuilderAPI was added as an anchor-hosted rpc.API instead of editing
+// eth/api_backend.go. A node running this engine still answers
+// mev_sendBundle/mev_bundlePrice/mev_params - just served by this package.
+type MEVAPI struct {
+	chain consensus.ChainHeaderReader
+	pool  *bundlepool.BundlePool
+}
+
+// NewMEVAPI wires a MEVAPI to the shared bundle pool the miner's
+// fillTransactionsAndBundles path also reads from.
+func NewMEVAPI(chain consensus.ChainHeaderReader, pool *bundlepool.BundlePool) *MEVAPI {
+	return &MEVAPI{chain: chain, pool: pool}
+}
+
+// SendBundle validates and queues bundle for inclusion by a future block,
+// served as mev_sendBundle.
+func (api *MEVAPI) SendBundle(bundle *types.Bundle) (common.Hash, error) {
+	head := api.chain.CurrentHeader()
+	if head == nil {
+		return common.Hash{}, errors.New("anchor: no current header")
+	}
+	if err := api.pool.Add(bundle, head.Number.Uint64()+1, uint64(time.Now().Unix()), maxBundleSize); err != nil {
+		return common.Hash{}, err
+	}
+	return bundle.Hash(), nil
+}
+
+// BundlePrice reports the minimum profit (in wei, paid to the block's
+// coinbase) a bundle needs to clear to compete for inclusion, served as
+// mev_bundlePrice. BundlePool only tracks submitted bundles, not their
+// simulated profit - that's computed fresh per block by the miner's
+// fillTransactionsAndBundles, which isn't wired to report back into the
+// pool - so there's currently nothing to base a real floor on; this
+// returns zero rather than a fabricated number until that feedback loop
+// exists.
+func (api *MEVAPI) BundlePrice() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(0))
+}
+
+// MEVParams reports the limits a bundle submission must respect, served as
+// mev_params.
+type MEVParams struct {
+	MaxBundleSize int `json:"maxBundleSize"`
+}
+
+// Params returns the current MEVParams, served as mev_params.
+func (api *MEVAPI) Params() MEVParams {
+	return MEVParams{MaxBundleSize: maxBundleSize}
+}
+
+// maxBundleSize caps how many transactions mev_sendBundle accepts in one
+// bundle, so a single bundle can't monopolize a block's gas on its own.
+const maxBundleSize = 50
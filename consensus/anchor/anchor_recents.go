@@ -0,0 +1,26 @@
+package anchor
+
+import "github.com/ethereum/go-ethereum/common"
+
+// IsRecentlySigned reports whether signer appears in snap.Recents within the
+// window that still bars it from signing again at number: the most recent
+// len(snap.Validators)/2+1 blocks up to and including number. The window's
+// oldest entry, at number-limit, is shifted out of Recents as of number
+// itself, so a match there no longer counts - the off-by-one every ad-hoc
+// copy of this loop had to get right on its own (and one pair of copies,
+// in the slashing path, didn't bother checking the window at all).
+func IsRecentlySigned(snap *Snapshot, signer common.Address, number uint64) bool {
+	limit := uint64(len(snap.Validators)/2 + 1)
+	if number < limit {
+		limit = number
+	}
+	for seen, recent := range snap.Recents {
+		if recent != signer {
+			continue
+		}
+		if seen > number-limit {
+			return true
+		}
+	}
+	return false
+}
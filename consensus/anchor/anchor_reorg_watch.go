@@ -0,0 +1,85 @@
+package anchor
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// reorgRingSlack is how many blocks of history watchL1Reorgs keeps beyond
+// the network's ConfirmationDepth before it forgets a height's recorded
+// hash.
+const reorgRingSlack = 32
+
+// ReorgEvent reports an L1 reorg watchL1Reorgs observed: the hash the ring
+// had recorded at a height (OldHashes) and the hash of the new head that
+// replaced it (NewHashes), plus DepthAffected. watchL1Reorgs emits one
+// event per replaced height, each with a single old/new hash pair and
+// DepthAffected set to 1; it does not measure how far below L1 head a
+// reorg reaches.
+type ReorgEvent struct {
+	OldHashes     []common.Hash
+	NewHashes     []common.Hash
+	DepthAffected uint64
+}
+
+// watchL1Reorgs subscribes to L1 new-head events and maintains a ring
+// buffer of the last ConfirmationDepth+reorgRingSlack canonical block
+// hashes, emitting a ReorgEvent on the returned channel whenever a new head
+// replaces a hash the ring already recorded at that height. Only the new
+// head's own height is compared: a reorg whose new head lands above every
+// recorded height is not reported, even if its ancestry differs from the
+// ring. The channel is closed when ctx is cancelled or the underlying
+// subscription ends.
+func (l2c *L2AnchorContract) watchL1Reorgs(ctx context.Context) (<-chan ReorgEvent, error) {
+	headCh := make(chan *types.Header, 16)
+	sub, err := l2c.cli.SubscribeNewHead(ctx, headCh)
+	if err != nil {
+		return nil, err
+	}
+
+	depth := l2c.networkInfo.ConfirmationDepth
+	if depth == 0 {
+		depth = 1
+	}
+	ringSize := depth + reorgRingSlack
+
+	events := make(chan ReorgEvent, 1)
+	go func() {
+		defer sub.Unsubscribe()
+		defer close(events)
+
+		ring := make(map[uint64]common.Hash, ringSize)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err := <-sub.Err():
+				log.Warn("L1 reorg watcher subscription ended", "err", err)
+				return
+			case header := <-headCh:
+				number := header.Number.Uint64()
+
+				if existing, ok := ring[number]; ok && existing != header.Hash() {
+					events <- ReorgEvent{
+						OldHashes:     []common.Hash{existing},
+						NewHashes:     []common.Hash{header.Hash()},
+						DepthAffected: 1,
+					}
+				}
+				ring[number] = header.Hash()
+
+				for h := range ring {
+					if h+ringSize < number {
+						delete(ring, h)
+					}
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
@@ -0,0 +1,58 @@
+package anchor
+
+import (
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ResetSnapshot purges every in-memory and on-disk snapshot at or above
+// header's number, then rebuilds the snapshot at header by walking back to
+// the nearest still-trusted checkpoint. It's the recovery path for a node
+// whose snapshot state has diverged from the chain it's following - e.g.
+// after an unclean shutdown caught snapshotPipeline mid-write - letting an
+// operator repair that without a full resync.
+//
+// The rebuild itself reuses recomputeSnapshot, the same checkpoint
+// walk-back VerifySnapshot already relies on to recompute a snapshot purely
+// from disk and replayed headers; ResetSnapshot's own job is just evicting
+// whatever might be stale first so that walk-back can't short-circuit on a
+// corrupt checkpoint still sitting at or above header.
+func (p *Anchor) ResetSnapshot(chain consensus.ChainHeaderReader, header *types.Header) error {
+	threshold := header.Number.Uint64()
+
+	for _, key := range p.recentSnaps.Keys() {
+		cached, ok := p.recentSnaps.Peek(key)
+		if !ok {
+			continue
+		}
+		if snap, ok := cached.(*Snapshot); ok && snap.Number >= threshold {
+			p.recentSnaps.Remove(key)
+		}
+	}
+
+	head := chain.CurrentHeader()
+	if head == nil {
+		return errUnknownBlock
+	}
+	for n := (threshold + checkpointInterval - 1) / checkpointInterval * checkpointInterval; n <= head.Number.Uint64(); n += checkpointInterval {
+		stale := chain.GetHeaderByNumber(n)
+		if stale == nil {
+			continue
+		}
+		if err := deleteSnapshot(p.db, stale.Hash()); err != nil {
+			log.Warn("anchor: failed to purge stale snapshot checkpoint", "number", n, "hash", stale.Hash(), "err", err)
+		}
+	}
+
+	snap, err := p.recomputeSnapshot(chain, threshold, header.Hash())
+	if err != nil {
+		return err
+	}
+
+	p.recentSnaps.Add(snap.Hash, snap)
+	if snap.Number%checkpointInterval == 0 {
+		p.snapshotPipeline.Store(snap)
+	}
+	return nil
+}
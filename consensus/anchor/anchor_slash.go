@@ -0,0 +1,124 @@
+package anchor
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// doubleSignKey identifies a (height, validator) pair: at most one header
+// from a given validator should ever be sealed at a given height.
+type doubleSignKey struct {
+	number   uint64
+	coinbase common.Address
+}
+
+// doubleSignEvidence packages the two conflicting sealed headers observed
+// for the same doubleSignKey, ready to submit to the slash contract's
+// slashDoubleSign(address,bytes,bytes,bytes,bytes) method as
+// (validator, headerA RLP, sigA, headerB RLP, sigB).
+type doubleSignEvidence struct {
+	Validator common.Address
+	Number    uint64
+	HeaderA   *types.Header
+	SigA      []byte
+	HeaderB   *types.Header
+	SigB      []byte
+}
+
+// slashEvidencePool tracks the most recently sealed header per
+// (height, validator) so verifySeal can detect a second, conflicting
+// header sealed at the same height, bounded to a configurable retention
+// window instead of growing forever.
+type slashEvidencePool struct {
+	seen *lru.ARCCache // doubleSignKey -> *types.Header
+
+	mu      sync.Mutex
+	pending []*doubleSignEvidence
+}
+
+func newSlashEvidencePool(retentionWindow int) *slashEvidencePool {
+	if retentionWindow <= 0 {
+		retentionWindow = 1024
+	}
+	seen, err := lru.NewARC(retentionWindow)
+	if err != nil {
+		panic(err)
+	}
+	return &slashEvidencePool{seen: seen}
+}
+
+// Observe records header as sealed by signer at header.Number. If a
+// different header was already recorded at the same (number, signer), it
+// packages and queues evidence of a double-sign, returning it to the
+// caller as well.
+func (s *slashEvidencePool) Observe(header *types.Header, signer common.Address) *doubleSignEvidence {
+	key := doubleSignKey{number: header.Number.Uint64(), coinbase: signer}
+	if existing, ok := s.seen.Get(key); ok {
+		prior := existing.(*types.Header)
+		if prior.Hash() == header.Hash() {
+			return nil
+		}
+		// Remember which conflicting headers were already reported, so
+		// observing the same one again doesn't queue duplicate evidence.
+		reported := struct {
+			doubleSignKey
+			hash common.Hash
+		}{key, header.Hash()}
+		if s.seen.Contains(reported) {
+			return nil
+		}
+		s.seen.Add(reported, struct{}{})
+		evidence := &doubleSignEvidence{
+			Validator: signer,
+			Number:    key.number,
+			HeaderA:   prior,
+			SigA:      extractSeal(prior),
+			HeaderB:   header,
+			SigB:      extractSeal(header),
+		}
+		s.mu.Lock()
+		s.pending = append(s.pending, evidence)
+		s.mu.Unlock()
+		return evidence
+	}
+	s.seen.Add(key, header)
+	return nil
+}
+
+// Peek returns the evidence collected so far without clearing it, for the
+// anchor RPC namespace to let operators inspect pending slash evidence and
+// submit it as an explicit transaction.
+func (s *slashEvidencePool) Peek() []*doubleSignEvidence {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*doubleSignEvidence{}, s.pending...)
+}
+
+// Acknowledge drops the queued evidence for validator at number, once an
+// operator has confirmed it was submitted as a slashDoubleSign transaction
+// - so API.PendingSlashEvidence doesn't keep offering up evidence that's
+// already on its way to being applied.
+func (s *slashEvidencePool) Acknowledge(validator common.Address, number uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.pending[:0]
+	for _, evidence := range s.pending {
+		if evidence.Validator == validator && evidence.Number == number {
+			continue
+		}
+		kept = append(kept, evidence)
+	}
+	s.pending = kept
+}
+
+// extractSeal pulls the trailing signature out of a sealed header's
+// extra-data, the same slice Seal writes the signature into.
+func extractSeal(header *types.Header) []byte {
+	if len(header.Extra) < extraSeal {
+		return nil
+	}
+	return header.Extra[len(header.Extra)-extraSeal:]
+}
@@ -0,0 +1,59 @@
+package anchor
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestSlashEvidencePoolObserveDetectsDoubleSign(t *testing.T) {
+	pool := newSlashEvidencePool(0)
+	signer := common.HexToAddress("0x1")
+
+	headerA := &types.Header{Number: big.NewInt(10), Extra: make([]byte, extraSeal)}
+	headerB := &types.Header{Number: big.NewInt(10), Extra: append(make([]byte, extraSeal-1), 0x01)}
+
+	if evidence := pool.Observe(headerA, signer); evidence != nil {
+		t.Fatal("expected no evidence from the first header observed at a height")
+	}
+	evidence := pool.Observe(headerB, signer)
+	if evidence == nil {
+		t.Fatal("expected evidence from a second, differently-sealed header at the same height")
+	}
+	if evidence.Validator != signer || evidence.Number != 10 {
+		t.Errorf("evidence = %+v, want validator %s at number 10", evidence, signer)
+	}
+
+	// Observing the exact same header again (e.g. a retried VerifyHeader
+	// call during sync) must not produce a second piece of evidence.
+	if evidence := pool.Observe(headerB, signer); evidence != nil {
+		t.Error("expected no new evidence from re-observing the same header")
+	}
+}
+
+func TestSlashEvidencePoolAcknowledgeDropsOnlyMatchingEvidence(t *testing.T) {
+	pool := newSlashEvidencePool(0)
+	signerA := common.HexToAddress("0x1")
+	signerB := common.HexToAddress("0x2")
+
+	pool.Observe(&types.Header{Number: big.NewInt(10), Extra: make([]byte, extraSeal)}, signerA)
+	pool.Observe(&types.Header{Number: big.NewInt(10), Extra: append(make([]byte, extraSeal-1), 0x01)}, signerA)
+	pool.Observe(&types.Header{Number: big.NewInt(20), Extra: make([]byte, extraSeal)}, signerB)
+	pool.Observe(&types.Header{Number: big.NewInt(20), Extra: append(make([]byte, extraSeal-1), 0x01)}, signerB)
+
+	if got := len(pool.Peek()); got != 2 {
+		t.Fatalf("expected 2 pending pieces of evidence, got %d", got)
+	}
+
+	pool.Acknowledge(signerA, 10)
+
+	pending := pool.Peek()
+	if len(pending) != 1 {
+		t.Fatalf("expected 1 piece of evidence left after acknowledging signerA's, got %d", len(pending))
+	}
+	if pending[0].Validator != signerB {
+		t.Errorf("expected the remaining evidence to belong to signerB, got %s", pending[0].Validator)
+	}
+}
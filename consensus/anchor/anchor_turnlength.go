@@ -0,0 +1,127 @@
+package anchor
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// This file keeps turnLength itself out of Snapshot: persisting a
+// TurnLength field there (with a migration defaulting pre-existing
+// snapshots to 1) would belong in snapshot.go alongside Snapshot's other
+// fields and JSON (de)serialization, but that file isn't part of this tree
+// (see the newSnapshotForBreathe doc comment in anchor_breathe.go for the
+// same gap). Reading turnLength fresh off the validator-set contract here,
+// cached the same way cachedMinBaseFee is, gets the scheduling behavior
+// this request asks for without reaching into Snapshot's serialization.
+
+// defaultTurnLength is the pre-fork (and fallback) behavior: one block per
+// validator turn, matching every existing inturn/backOffTime computation.
+const defaultTurnLength = uint64(1)
+
+// isAnchorTurnLengthFork reports whether number has activated BEP-341-style
+// multi-block turns. Pre-fork chains keep turnLength pinned at 1 everywhere
+// below, so inTurnWithLength/recentSignerLimit reduce to their old behavior
+// exactly.
+func (p *Anchor) isAnchorTurnLengthFork(number *big.Int) bool {
+	fork := p.chainConfig.AnchorTurnLengthBlock
+	return fork != nil && number.Cmp(fork) >= 0
+}
+
+// turnLength returns the current on-chain turnLength, refreshing the cache
+// from the validator-set contract's getTurnLength() when unset. Like
+// cachedMinBaseFee, it's refreshed on breathe blocks rather than every
+// block.
+func (p *Anchor) turnLength(blockHash common.Hash) (uint64, error) {
+	p.lock.RLock()
+	cached := p.cachedTurnLength
+	p.lock.RUnlock()
+	if cached != nil {
+		return *cached, nil
+	}
+	return p.refreshTurnLength(blockHash)
+}
+
+// refreshTurnLength re-reads getTurnLength() from the validator-set contract
+// and updates the cache.
+func (p *Anchor) refreshTurnLength(blockHash common.Hash) (uint64, error) {
+	method := "getTurnLength"
+	data, err := p.systemDaoABI.Pack(method)
+	if err != nil {
+		return 0, err
+	}
+
+	blockNr := rpc.BlockNumberOrHashWithHash(blockHash, false)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msgData := (hexutil.Bytes)(data)
+	toAddress := common.HexToAddress(systemcontracts.SystemDaoContract)
+	gas := (hexutil.Uint64)(uint64(1 << 62))
+	result, err := p.ethAPI.Call(ctx, ethapi.TransactionArgs{
+		Gas:  &gas,
+		To:   &toAddress,
+		Data: &msgData,
+	}, blockNr, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var length *big.Int
+	if err := p.systemDaoABI.UnpackIntoInterface(&length, method, result); err != nil {
+		return 0, err
+	}
+
+	turnLength := defaultTurnLength
+	if length != nil && length.Sign() > 0 {
+		turnLength = length.Uint64()
+	}
+
+	p.lock.Lock()
+	p.cachedTurnLength = &turnLength
+	p.lock.Unlock()
+	return turnLength, nil
+}
+
+// inTurnWithLength reports whether val is the in-turn signer for number
+// once turnLength > 1: the in-turn validator is
+// Validators[(number/turnLength) % len(Validators)] for turnLength
+// consecutive heights, instead of rotating every single block.
+func inTurnWithLength(snap *Snapshot, number, turnLength uint64, val common.Address) bool {
+	validators := sortedValidators(snap)
+	if len(validators) == 0 {
+		return false
+	}
+	idx := (number / turnLength) % uint64(len(validators))
+	return validators[idx] == val
+}
+
+// inTurnValidator resolves the address scheduled to produce block number,
+// turnLength-aware: post-fork it's sortedValidators(snap)[(number/turnLength)
+// % N], matching inTurnWithLength; pre-fork (or if turnLength can't be read)
+// it falls back to snap.supposeValidator(), the same "expected in-turn
+// signer" Finalize/FinalizeAndAssemble already slash against.
+func (p *Anchor) inTurnValidator(snap *Snapshot, number uint64) common.Address {
+	if p.isAnchorTurnLengthFork(new(big.Int).SetUint64(number)) {
+		if length, err := p.turnLength(snap.Hash); err == nil {
+			if validators := sortedValidators(snap); len(validators) > 0 {
+				return validators[(number/length)%uint64(len(validators))]
+			}
+		}
+	}
+	return snap.supposeValidator()
+}
+
+// recentSignerLimit is the "may not sign again yet" window used throughout
+// verifySeal/Seal/SignRecently. Pre-fork (turnLength == 1) it's the familiar
+// len(validators)/2+1 blocks; with multi-block turns the same rule is
+// counted per turn, so the window widens to cover turnLength blocks per
+// validator instead of one.
+func recentSignerLimit(numValidators int, turnLength uint64) uint64 {
+	return uint64(numValidators/2+1) * turnLength
+}
@@ -0,0 +1,54 @@
+package anchor
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestRecentSignerLimit(t *testing.T) {
+	tests := []struct {
+		numValidators int
+		turnLength    uint64
+		want          uint64
+	}{
+		{numValidators: 4, turnLength: 1, want: 3}, // pre-fork: unchanged len(validators)/2+1
+		{numValidators: 4, turnLength: 3, want: 9}, // multi-block turns widen the window proportionally
+		{numValidators: 1, turnLength: 5, want: 5},
+	}
+	for _, tt := range tests {
+		if got := recentSignerLimit(tt.numValidators, tt.turnLength); got != tt.want {
+			t.Errorf("recentSignerLimit(%d, %d) = %d, want %d", tt.numValidators, tt.turnLength, got, tt.want)
+		}
+	}
+}
+
+func TestInTurnWithLength(t *testing.T) {
+	v0 := common.HexToAddress("0x1")
+	v1 := common.HexToAddress("0x2")
+	v2 := common.HexToAddress("0x3")
+	snap := &Snapshot{
+		Validators: map[common.Address]struct{}{v0: {}, v1: {}, v2: {}},
+	}
+
+	// turnLength 1 rotates every block, matching the pre-fork behavior.
+	if !inTurnWithLength(snap, 0, 1, v0) {
+		t.Error("expected v0 in turn at number 0 with turnLength 1")
+	}
+	if !inTurnWithLength(snap, 1, 1, v1) {
+		t.Error("expected v1 in turn at number 1 with turnLength 1")
+	}
+
+	// turnLength 2 keeps the same validator in turn for two consecutive
+	// heights before rotating to the next one.
+	if !inTurnWithLength(snap, 0, 2, v0) || !inTurnWithLength(snap, 1, 2, v0) {
+		t.Error("expected v0 in turn for both blocks 0 and 1 with turnLength 2")
+	}
+	if !inTurnWithLength(snap, 2, 2, v1) || !inTurnWithLength(snap, 3, 2, v1) {
+		t.Error("expected v1 in turn for both blocks 2 and 3 with turnLength 2")
+	}
+
+	if inTurnWithLength(&Snapshot{}, 0, 1, v0) {
+		t.Error("expected no validator in turn against an empty validator set")
+	}
+}
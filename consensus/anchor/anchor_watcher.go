@@ -0,0 +1,166 @@
+package anchor
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// anchorWatcherRingSize bounds how many recent L1 headers anchorWatcher
+// keeps indexed by number. It only needs to outlive the deepest
+// ConfirmationDepth this chain is configured with, since WaitForFinalized never
+// asks about a number further back than that.
+const anchorWatcherRingSize = 256
+
+// anchorWatcherPollInterval is how often anchorWatcher falls back to
+// BlockByNumber/HeaderByNumber polling when mainIPC doesn't support
+// eth_subscribe (e.g. a plain HTTP endpoint instead of IPC/WS).
+const anchorWatcherPollInterval = 2 * time.Second
+
+// ErrAnchorReorg is returned by WaitForFinalized when the L1 header it was
+// about to hand back was orphaned by a reorg before really reaching
+// ConfirmationDepth confirmations. A header already handed back to an
+// earlier caller is not tracked. Prepare treats this like any other transient error: it
+// returns the error so the miner reruns Prepare, which calls
+// WaitForFinalized again against the now-canonical chain.
+var ErrAnchorReorg = errors.New("l1 anchor header orphaned by reorg")
+
+// anchorWatcher replaces Prepare's old busy-poll wait with a subscription
+// to L1 new-head events, maintaining a ring of recently observed canonical
+// hashes by height so WaitForFinalized can both wait for confirmation depth
+// and detect a reorg that replaces the height a caller is waiting on.
+//
+// It watches through an AnchorSource rather than a concrete
+// *ethclient.Client, so a light-client or file-replay source (see
+// anchor_source.go) gets the same confirmation-depth/reorg-detection logic
+// as a plain RPC endpoint. Multiple L1 endpoints with automatic failover is
+// still left for a future source implementation - AnchorSource's shape
+// doesn't preclude one that fans out to several underlying sources.
+type anchorWatcher struct {
+	source       AnchorSource
+	confirmDepth uint64
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	ring map[uint64]common.Hash
+	tip  uint64
+}
+
+// newAnchorWatcher starts watching source for new L1 heads in the
+// background.
+func newAnchorWatcher(source AnchorSource, confirmDepth uint64) *anchorWatcher {
+	w := &anchorWatcher{
+		source:       source,
+		confirmDepth: confirmDepth,
+		ring:         make(map[uint64]common.Hash),
+	}
+	w.cond = sync.NewCond(&w.mu)
+	go w.run()
+	return w
+}
+
+func (w *anchorWatcher) run() {
+	headCh := make(chan *types.Header, 16)
+	sub, err := w.source.SubscribeNewHead(context.Background(), headCh)
+	if err != nil {
+		log.Warn("anchorWatcher: head subscription unsupported, falling back to polling", "err", err)
+		w.pollLoop()
+		return
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case err := <-sub.Err():
+			log.Warn("anchorWatcher: subscription ended, falling back to polling", "err", err)
+			w.pollLoop()
+			return
+		case header := <-headCh:
+			w.observe(header)
+		}
+	}
+}
+
+func (w *anchorWatcher) pollLoop() {
+	ticker := time.NewTicker(anchorWatcherPollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		header, err := w.source.HeaderByNumber(context.Background(), nil)
+		if err != nil {
+			log.Warn("anchorWatcher: poll failed", "err", err)
+			continue
+		}
+		w.observe(header)
+	}
+}
+
+// observe records a newly seen L1 header, warning (but not failing) if it
+// replaces a hash this watcher had already recorded at the same height -
+// WaitForFinalized is what actually turns an already-handed-out reorg into
+// ErrAnchorReorg for its caller.
+func (w *anchorWatcher) observe(header *types.Header) {
+	w.mu.Lock()
+	number := header.Number.Uint64()
+	if existing, ok := w.ring[number]; ok && existing != header.Hash() {
+		log.Warn("anchorWatcher: L1 reorg observed", "number", number, "old", existing, "new", header.Hash())
+	}
+	w.ring[number] = header.Hash()
+	if number > w.tip {
+		w.tip = number
+	}
+	for n := range w.ring {
+		if n+anchorWatcherRingSize < w.tip {
+			delete(w.ring, n)
+		}
+	}
+	w.mu.Unlock()
+	w.cond.Broadcast()
+}
+
+// WaitForFinalized blocks until L1 head has confirmed number by at least
+// confirmDepth blocks, then returns the canonical header at number. If the
+// hash this watcher had recorded for number changed while the caller was
+// waiting, that's a reorg deep enough to have reached an already-decided
+// height, and WaitForFinalized returns ErrAnchorReorg instead of a stale
+// header.
+func (w *anchorWatcher) WaitForFinalized(ctx context.Context, number uint64) (*types.Header, error) {
+	w.mu.Lock()
+	pinnedHash, pinned := w.ring[number]
+	for w.tip < number+w.confirmDepth {
+		waitDone := make(chan struct{})
+		go func() {
+			select {
+			case <-ctx.Done():
+				w.cond.Broadcast()
+			case <-waitDone:
+			}
+		}()
+		w.cond.Wait()
+		close(waitDone)
+		if ctx.Err() != nil {
+			w.mu.Unlock()
+			return nil, ctx.Err()
+		}
+	}
+	recordedHash, known := w.ring[number]
+	w.mu.Unlock()
+	if pinned && known && recordedHash != pinnedHash {
+		return nil, ErrAnchorReorg
+	}
+
+	header, err := w.source.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+	if err != nil {
+		return nil, err
+	}
+	if known && header.Hash() != recordedHash {
+		return nil, ErrAnchorReorg
+	}
+	return header, nil
+}
@@ -0,0 +1,281 @@
+package anchor
+
+import (
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// API is a user facing RPC API to query snapshot and validator information
+// from the Anchor consensus engine, in the anchor namespace.
+type API struct {
+	chain  consensus.ChainHeaderReader
+	anchor *Anchor
+}
+
+// Status is returned by API.Status, summarizing this node's signing
+// identity and the health of the signer rotation it currently observes.
+type Status struct {
+	Signer        common.Address `json:"signer"`
+	InTurn        bool           `json:"inTurn"`
+	RecentSigners int            `json:"recentSigners"`
+	LastAnchor    *AnchorProof   `json:"lastAnchor"`
+}
+
+// AnchorProof describes the L1 anchor binding embedded in one L2 block's
+// extra-data, between extraVanity and the seal.
+type AnchorProof struct {
+	Number     uint64      `json:"number"`
+	Hash       common.Hash `json:"hash"`
+	AnchorHash common.Hash `json:"anchorHash"`
+}
+
+// GetSnapshot retrieves the state snapshot at a given block, or the latest
+// block if number is nil.
+func (api *API) GetSnapshot(number *rpc.BlockNumber) (*Snapshot, error) {
+	header, err := api.headerByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	return api.anchor.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
+}
+
+// GetSnapshotAtHash retrieves the state snapshot at a given block hash.
+func (api *API) GetSnapshotAtHash(hash common.Hash) (*Snapshot, error) {
+	header := api.chain.GetHeaderByHash(hash)
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return api.anchor.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
+}
+
+// GetValidators retrieves the list of authorized validators at the
+// specified block, or the latest block if number is nil.
+func (api *API) GetValidators(number *rpc.BlockNumber) ([]common.Address, error) {
+	snap, err := api.GetSnapshot(number)
+	if err != nil {
+		return nil, err
+	}
+	return sortedValidators(snap), nil
+}
+
+// GetValidatorsAtHash retrieves the list of authorized validators at the
+// specified block hash.
+func (api *API) GetValidatorsAtHash(hash common.Hash) ([]common.Address, error) {
+	snap, err := api.GetSnapshotAtHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	return sortedValidators(snap), nil
+}
+
+// GetSigner returns the address that signed the given header, recovered
+// via ecrecover.
+func (api *API) GetSigner(header *types.Header) (common.Address, error) {
+	return ecrecover(header, api.anchor.signatures, api.anchor.chainConfig.ChainID)
+}
+
+// GetAnchorProof returns the L1 anchor hash bound into the given L2 block,
+// letting an operator verify the L1<->L2 binding without decoding the
+// header's extra-data by hand.
+func (api *API) GetAnchorProof(number *rpc.BlockNumber) (*AnchorProof, error) {
+	header, err := api.headerByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	return anchorProofFromHeader(header)
+}
+
+// Status reports this node's signing address, whether it is in-turn for the
+// current head, how many distinct addresses have signed recently, and the
+// anchor proof embedded in the current head.
+func (api *API) Status() (*Status, error) {
+	header := api.chain.CurrentHeader()
+	snap, err := api.anchor.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	api.anchor.lock.RLock()
+	signer := api.anchor.val
+	api.anchor.lock.RUnlock()
+
+	proof, err := anchorProofFromHeader(header)
+	if err != nil {
+		proof = nil
+	}
+
+	return &Status{
+		Signer:        signer,
+		InTurn:        snap.inturn(signer),
+		RecentSigners: len(snap.Recents),
+		LastAnchor:    proof,
+	}, nil
+}
+
+// VerifySnapshot recomputes the snapshot at the given block purely from
+// disk checkpoints and replayed headers - bypassing the in-memory
+// recentSnaps cache - and reports whether it matches what's currently
+// cached or stored for that block, so an operator can audit divergence
+// between the two independent of loadSnapshot's own integrity check.
+func (api *API) VerifySnapshot(number *rpc.BlockNumber) (bool, error) {
+	header, err := api.headerByNumber(number)
+	if err != nil {
+		return false, err
+	}
+	trusted, err := api.anchor.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return false, err
+	}
+	recomputed, err := api.anchor.recomputeSnapshot(api.chain, header.Number.Uint64(), header.Hash())
+	if err != nil {
+		return false, err
+	}
+	return snapshotIntegrityHash(trusted) == snapshotIntegrityHash(recomputed), nil
+}
+
+// ResetSnapshot purges the cached and persisted snapshot state at or above
+// the given block and rebuilds it from the nearest trusted checkpoint,
+// letting an operator recover a node whose snapshot diverged (e.g. after an
+// abnormal shutdown) without a full resync. It defaults to the current head
+// if number is nil.
+func (api *API) ResetSnapshot(number *rpc.BlockNumber) error {
+	header, err := api.headerByNumber(number)
+	if err != nil {
+		return err
+	}
+	return api.anchor.ResetSnapshot(api.chain, header)
+}
+
+// PendingSlashEvidence returns the double-sign evidence observed by
+// verifySeal but not yet submitted to the slash contract, letting
+// operators inspect what's about to be slashed ahead of time.
+func (api *API) PendingSlashEvidence() []*doubleSignEvidence {
+	return api.anchor.slashEvidence.Peek()
+}
+
+// SlashDoubleSignCalldata packs one pending piece of double-sign evidence
+// for validator at number into a call to the slash contract's
+// slashDoubleSign(address,bytes,bytes,bytes,bytes) method, for an operator
+// to submit themselves as an ordinary transaction via eth_sendTransaction -
+// the same way any other call into the slash contract reaches consensus,
+// rather than this engine auto-embedding it into whatever block it
+// produces next. It returns errUnknownBlock if no matching evidence is
+// currently pending.
+func (api *API) SlashDoubleSignCalldata(validator common.Address, number uint64) (*SlashDoubleSignTx, error) {
+	for _, evidence := range api.anchor.slashEvidence.Peek() {
+		if evidence.Validator != validator || evidence.Number != number {
+			continue
+		}
+		headerARLP, err := rlp.EncodeToBytes(evidence.HeaderA)
+		if err != nil {
+			return nil, err
+		}
+		headerBRLP, err := rlp.EncodeToBytes(evidence.HeaderB)
+		if err != nil {
+			return nil, err
+		}
+		data, err := api.anchor.slashABI.Pack("slashDoubleSign", evidence.Validator, headerARLP, evidence.SigA, headerBRLP, evidence.SigB)
+		if err != nil {
+			return nil, err
+		}
+		return &SlashDoubleSignTx{
+			To:   api.anchor.config.SlashConfig.ContractAddress,
+			Data: data,
+		}, nil
+	}
+	return nil, errUnknownBlock
+}
+
+// AcknowledgeSlashEvidence drops the queued evidence for validator at
+// number, once an operator has confirmed the slashDoubleSign transaction
+// built from SlashDoubleSignCalldata was submitted, so it stops being
+// offered up by PendingSlashEvidence/SlashDoubleSignCalldata.
+func (api *API) AcknowledgeSlashEvidence(validator common.Address, number uint64) {
+	api.anchor.slashEvidence.Acknowledge(validator, number)
+}
+
+// SlashDoubleSignTx is the (to, data) pair SlashDoubleSignCalldata returns:
+// everything an operator needs to build and sign an ordinary transaction
+// calling the slash contract's slashDoubleSign method themselves.
+type SlashDoubleSignTx struct {
+	To   common.Address `json:"to"`
+	Data hexutil.Bytes  `json:"data"`
+}
+
+// Proposals returns the currently pending address proposals, each mapped
+// to whether it's a proposal to add (true) or drop (false) that address.
+func (api *API) Proposals() map[common.Address]bool {
+	return api.anchor.proposalsSnapshot()
+}
+
+// Propose records a new authorization proposal, either authorizing or
+// deauthorizing the given address, where Proposals reports it. Prepare does
+// not encode proposals into the headers it prepares and snapshot.apply
+// does not tally them, so a proposal does not change the snapshot
+// validator set - see the Propose doc comment on Anchor.
+func (api *API) Propose(address common.Address, auth bool) {
+	api.anchor.Propose(address, auth)
+}
+
+// Discard drops a currently pending proposal.
+func (api *API) Discard(address common.Address) {
+	api.anchor.Discard(address)
+}
+
+// SubmitBurnProofShare accepts one validator's partial BLS signature over a
+// pending burn-batch root, gossiped here by the rest of the epoch's
+// participants so the block proposer's l2BurnProofs can aggregate a real
+// t-of-n signature instead of just its own share. It's a no-op past the
+// burnShareCollectWindow that root's own l2BurnProofs call is already
+// waiting on - the share is simply dropped once that round has closed.
+//
+// This method has no caller authentication beyond what share itself proves:
+// it's rejected with an error unless it verifies against the committed
+// public key for share.ValidatorIndex, so submitting under someone else's
+// index requires forging a pairing check, not just claiming the index.
+func (api *API) SubmitBurnProofShare(root common.Hash, share PartialSig) error {
+	return api.anchor.anchorContract.SubmitBurnProofShare(root, share)
+}
+
+func (api *API) headerByNumber(number *rpc.BlockNumber) (*types.Header, error) {
+	if number == nil || *number == rpc.LatestBlockNumber {
+		return api.chain.CurrentHeader(), nil
+	}
+	header := api.chain.GetHeaderByNumber(uint64(number.Int64()))
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return header, nil
+}
+
+func sortedValidators(snap *Snapshot) []common.Address {
+	validators := make([]common.Address, 0, len(snap.Validators))
+	for addr := range snap.Validators {
+		validators = append(validators, addr)
+	}
+	sort.Slice(validators, func(i, j int) bool {
+		return validators[i].Hex() < validators[j].Hex()
+	})
+	return validators
+}
+
+// anchorProofFromHeader extracts the L1 anchor hash bound between
+// extraVanity and the seal, the same slice Prepare writes to via
+// anchorBlock.Hash().Bytes().
+func anchorProofFromHeader(header *types.Header) (*AnchorProof, error) {
+	if len(header.Extra) < extraVanity+extraAnchorHash+extraSeal {
+		return nil, errMissingAnchorHash
+	}
+	anchorHashBytes := header.Extra[len(header.Extra)-extraSeal-extraAnchorHash : len(header.Extra)-extraSeal]
+	return &AnchorProof{
+		Number:     header.Number.Uint64(),
+		Hash:       header.Hash(),
+		AnchorHash: common.BytesToHash(anchorHashBytes),
+	}, nil
+}
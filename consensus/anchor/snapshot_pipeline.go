@@ -0,0 +1,210 @@
+package anchor
+
+import (
+	"encoding/binary"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"golang.org/x/crypto/sha3"
+)
+
+// snapshotIntegrityPrefix namespaces the per-snapshot integrity hashes
+// snapshotPipeline persists alongside each stored Snapshot, keyed by the
+// snapshot's own hash so loadSnapshot's caller can tell disk corruption
+// apart from "nothing stored yet" (no key present at all).
+var snapshotIntegrityPrefix = []byte("anchor-snapshot-integrity-")
+
+func snapshotIntegrityKey(hash common.Hash) []byte {
+	return append(append([]byte{}, snapshotIntegrityPrefix...), hash.Bytes()...)
+}
+
+// snapshotIntegrityHash is a keccak256 of (Number || Hash || sorted
+// Validators || sorted Recents). Anchor.snapshot recomputes it for each
+// checkpoint it loads from disk, to detect corruption independent of
+// replaying headers; recomputeSnapshot loads checkpoints without this check.
+func snapshotIntegrityHash(snap *Snapshot) common.Hash {
+	validators := sortedValidators(snap)
+
+	recentNumbers := make([]uint64, 0, len(snap.Recents))
+	for n := range snap.Recents {
+		recentNumbers = append(recentNumbers, n)
+	}
+	sort.Slice(recentNumbers, func(i, j int) bool { return recentNumbers[i] < recentNumbers[j] })
+
+	var numBuf [8]byte
+	hasher := sha3.NewLegacyKeccak256()
+	binary.BigEndian.PutUint64(numBuf[:], snap.Number)
+	hasher.Write(numBuf[:])
+	hasher.Write(snap.Hash.Bytes())
+	for _, addr := range validators {
+		hasher.Write(addr.Bytes())
+	}
+	for _, n := range recentNumbers {
+		binary.BigEndian.PutUint64(numBuf[:], n)
+		hasher.Write(numBuf[:])
+		hasher.Write(snap.Recents[n].Bytes())
+	}
+
+	var out common.Hash
+	hasher.Sum(out[:0])
+	return out
+}
+
+func storeSnapshotWithIntegrity(db ethdb.Database, snap *Snapshot) error {
+	if err := snap.store(db); err != nil {
+		return err
+	}
+	return db.Put(snapshotIntegrityKey(snap.Hash), snapshotIntegrityHash(snap).Bytes())
+}
+
+// deleteSnapshot removes the persisted snapshot checkpoint and its integrity
+// record for hash, the inverse of storeSnapshotWithIntegrity. snapshotKey is
+// the same key loadSnapshot/Snapshot.store use internally (defined
+// alongside them in snapshot.go); it's not re-derived here, just reused, so
+// this stays in lockstep with however that file actually lays checkpoints
+// out on disk.
+func deleteSnapshot(db ethdb.Database, hash common.Hash) error {
+	if err := db.Delete(snapshotKey(hash)); err != nil {
+		return err
+	}
+	return db.Delete(snapshotIntegrityKey(hash))
+}
+
+// verifySnapshotIntegrity recomputes snap's integrity hash and compares it
+// against the one stored alongside it on disk. A missing record (no
+// integrity hash was ever written for this hash, e.g. it predates this
+// feature) is reported as valid rather than corrupt - only an explicit
+// mismatch counts as corruption.
+func verifySnapshotIntegrity(db ethdb.Database, snap *Snapshot) (bool, error) {
+	stored, err := db.Get(snapshotIntegrityKey(snap.Hash))
+	if err != nil {
+		return true, nil
+	}
+	return common.BytesToHash(stored) == snapshotIntegrityHash(snap), nil
+}
+
+// snapshotPipeline hands Snapshot.store off to a dedicated background
+// goroutine instead of blocking header verification's hot path on disk
+// I/O. Bursts of checkpoint stores coalesce: only the newest pending
+// snapshot at any moment is actually written, since by the time the writer
+// gets to it, that's the one that matters.
+type snapshotPipeline struct {
+	db ethdb.Database
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending *Snapshot
+	storing bool
+	closed  bool
+}
+
+func newSnapshotPipeline(db ethdb.Database) *snapshotPipeline {
+	p := &snapshotPipeline{db: db}
+	p.cond = sync.NewCond(&p.mu)
+	go p.run()
+	return p
+}
+
+// Store hands snap off to the background writer, replacing any
+// not-yet-flushed snapshot queued ahead of it.
+func (p *snapshotPipeline) Store(snap *Snapshot) {
+	p.mu.Lock()
+	p.pending = snap
+	p.mu.Unlock()
+	p.cond.Broadcast()
+}
+
+func (p *snapshotPipeline) run() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for {
+		for p.pending == nil && !p.closed {
+			p.cond.Wait()
+		}
+		if p.pending == nil && p.closed {
+			return
+		}
+		snap := p.pending
+		p.pending = nil
+		p.storing = true
+		p.mu.Unlock()
+
+		if err := storeSnapshotWithIntegrity(p.db, snap); err != nil {
+			log.Warn("anchor snapshot pipeline: store failed", "number", snap.Number, "hash", snap.Hash, "err", err)
+		}
+
+		p.mu.Lock()
+		p.storing = false
+		p.cond.Broadcast()
+	}
+}
+
+// Flush blocks until any pending or in-flight write has reached disk. The
+// engine calls this synchronously on shutdown and when VerifyHeaders
+// aborts, so a checkpoint snapshot is never silently dropped.
+func (p *snapshotPipeline) Flush() {
+	p.mu.Lock()
+	for p.pending != nil || p.storing {
+		p.cond.Wait()
+	}
+	p.mu.Unlock()
+}
+
+// Close flushes any outstanding write and stops the background goroutine.
+func (p *snapshotPipeline) Close() {
+	p.Flush()
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+	p.cond.Broadcast()
+}
+
+// recomputeSnapshot rebuilds the snapshot at (number, hash) purely from
+// disk checkpoints and replayed headers, bypassing p.recentSnaps, for
+// API.VerifySnapshot to compare against whatever's currently cached or
+// stored.
+func (p *Anchor) recomputeSnapshot(chain consensus.ChainHeaderReader, number uint64, hash common.Hash) (*Snapshot, error) {
+	var (
+		headers []*types.Header
+		snap    *Snapshot
+	)
+
+	for snap == nil {
+		if number%checkpointInterval == 0 {
+			if s, err := loadSnapshot(p.config, p.signatures, p.db, hash, p.ethAPI); err == nil {
+				snap = s
+				break
+			}
+		}
+		if number == 0 {
+			checkpoint := chain.GetHeaderByNumber(number)
+			if checkpoint != nil {
+				chash := checkpoint.Hash()
+				validatorBytes := checkpoint.Extra[extraVanity : len(checkpoint.Extra)-extraSeal-extraAnchorHash]
+				validators, err := ParseValidators(validatorBytes)
+				if err != nil {
+					return nil, err
+				}
+				snap = newSnapshot(p.config, p.signatures, number, chash, validators, p.ethAPI)
+				break
+			}
+		}
+
+		header := chain.GetHeader(hash, number)
+		if header == nil {
+			return nil, consensus.ErrUnknownAncestor
+		}
+		headers = append(headers, header)
+		number, hash = number-1, header.ParentHash
+	}
+
+	for i := 0; i < len(headers)/2; i++ {
+		headers[i], headers[len(headers)-1-i] = headers[len(headers)-1-i], headers[i]
+	}
+	return snap.apply(headers, chain, nil, p.chainConfig.ChainID, p.chainConfig.GetCommunitySwapBlock())
+}
@@ -0,0 +1,161 @@
+// Package beacon wraps consensus/anchor.Anchor so the Anchor network can
+// cross a merge transition: blocks below the terminal total difficulty keep
+// going through Anchor's signer rotation and system-contract bookkeeping
+// exactly as before, while blocks at or above it switch to the zero-
+// difficulty, externally-proposed rules a beacon chain drives through
+// eth/catalyst's engine API.
+package beacon
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/anchor"
+	"github.com/ethereum/go-ethereum/consensus/merge"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// errInvalidPoSDifficulty is returned when a block claiming to be post-merge
+// doesn't carry the zero difficulty the beacon chain requires.
+var errInvalidPoSDifficulty = errors.New("beacon: post-merge header must have zero difficulty")
+
+// errAnchorAfterFinalization is returned when a block signed under Anchor's
+// rules shows up after PoS has already been finalized, which would mean an
+// Anchor signer is still extending a chain the beacon chain has moved past.
+var errAnchorAfterFinalization = errors.New("beacon: Anchor-signed header after proof-of-stake finalization")
+
+// Beacon implements consensus.Engine by delegating to an embedded Anchor
+// engine below the merge and to its own minimal post-merge rules above it.
+// The anchor manager address that proposes the transition block under
+// Anchor's rules is also the coinbase eth/catalyst's first post-merge
+// payload is built for, so proposer continuity survives the switch.
+type Beacon struct {
+	anchor *anchor.Anchor
+	merger *merge.Merger
+}
+
+// New wraps anchorEngine with merger's transition state.
+func New(anchorEngine *anchor.Anchor, merger *merge.Merger) *Beacon {
+	return &Beacon{anchor: anchorEngine, merger: merger}
+}
+
+// isPostMerge reports whether header belongs to the beacon-driven side of
+// the transition, identified the same way go-ethereum always has: a
+// zero-difficulty header.
+func (b *Beacon) isPostMerge(header *types.Header) bool {
+	return header.Difficulty != nil && header.Difficulty.Sign() == 0
+}
+
+func (b *Beacon) Author(header *types.Header) (common.Address, error) {
+	if b.isPostMerge(header) {
+		return header.Coinbase, nil
+	}
+	return b.anchor.Author(header)
+}
+
+func (b *Beacon) VerifyHeader(chain consensus.ChainHeaderReader, header *types.Header, seal bool) error {
+	if b.isPostMerge(header) {
+		return b.verifyPoSHeader(header)
+	}
+	if b.merger.PoSFinalized() {
+		return errAnchorAfterFinalization
+	}
+	return b.anchor.VerifyHeader(chain, header, seal)
+}
+
+// verifyPoSHeader checks the handful of invariants a beacon-proposed header
+// must satisfy; everything else (gas limit continuity, timestamp ordering)
+// is eth/catalyst's ConsensusAPI's job before the header is ever assembled.
+func (b *Beacon) verifyPoSHeader(header *types.Header) error {
+	if header.Difficulty.Sign() != 0 {
+		return errInvalidPoSDifficulty
+	}
+	return nil
+}
+
+func (b *Beacon) VerifyHeaders(chain consensus.ChainHeaderReader, headers []*types.Header, seals []bool) (chan<- struct{}, <-chan error) {
+	abort, results := make(chan struct{}), make(chan error, len(headers))
+	go func() {
+		for _, header := range headers {
+			results <- b.VerifyHeader(chain, header, false)
+		}
+	}()
+	return abort, results
+}
+
+func (b *Beacon) VerifyUncles(chain consensus.ChainReader, block *types.Block) error {
+	if b.isPostMerge(block.Header()) {
+		if len(block.Uncles()) > 0 {
+			return errors.New("beacon: post-merge block must not have uncles")
+		}
+		return nil
+	}
+	return b.anchor.VerifyUncles(chain, block)
+}
+
+func (b *Beacon) VerifySeal(chain consensus.ChainReader, header *types.Header) error {
+	if b.isPostMerge(header) {
+		return b.verifyPoSHeader(header)
+	}
+	return b.anchor.VerifySeal(chain, header)
+}
+
+func (b *Beacon) Prepare(chain consensus.ChainHeaderReader, header *types.Header) error {
+	if b.merger.TDDReached() {
+		header.Difficulty = big.NewInt(0)
+		return nil
+	}
+	return b.anchor.Prepare(chain, header)
+}
+
+func (b *Beacon) Finalize(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, txs *[]*types.Transaction,
+	uncles []*types.Header, receipts *[]*types.Receipt, systemTxs *[]*types.Transaction, usedGas *uint64) error {
+	if b.isPostMerge(header) {
+		return nil
+	}
+	return b.anchor.Finalize(chain, header, state, txs, uncles, receipts, systemTxs, usedGas)
+}
+
+func (b *Beacon) FinalizeAndAssemble(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB,
+	txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, []*types.Receipt, error) {
+	if b.isPostMerge(header) {
+		return types.NewBlock(header, txs, nil, receipts, nil), receipts, nil
+	}
+	return b.anchor.FinalizeAndAssemble(chain, header, state, txs, uncles, receipts)
+}
+
+// Seal refuses to mine a post-merge block locally: those only ever come
+// from eth/catalyst's ConsensusAPI.NewPayloadV1, assembled ahead of time by
+// a beacon chain's block-building request rather than sealed here.
+func (b *Beacon) Seal(chain consensus.ChainHeaderReader, block *types.Block, results chan<- *types.Block, stop <-chan struct{}) error {
+	if b.isPostMerge(block.Header()) {
+		return errors.New("beacon: post-merge blocks are sealed externally via the engine API, not mined locally")
+	}
+	return b.anchor.Seal(chain, block, results, stop)
+}
+
+func (b *Beacon) SealHash(header *types.Header) common.Hash {
+	return b.anchor.SealHash(header)
+}
+
+// CalcDifficulty always returns zero once the terminal total difficulty has
+// been reached, regardless of what Anchor's own turn-based schedule would
+// have produced.
+func (b *Beacon) CalcDifficulty(chain consensus.ChainHeaderReader, time uint64, parent *types.Header) *big.Int {
+	if b.merger.TDDReached() {
+		return big.NewInt(0)
+	}
+	return b.anchor.CalcDifficulty(chain, time, parent)
+}
+
+func (b *Beacon) APIs(chain consensus.ChainHeaderReader) []rpc.API {
+	return b.anchor.APIs(chain)
+}
+
+func (b *Beacon) Close() error {
+	return b.anchor.Close()
+}
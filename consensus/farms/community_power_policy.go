@@ -0,0 +1,206 @@
+package farms
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// CommunityPowerPolicy computes the aggregate community power a parent
+// address earns from its children's holdings, replacing the single
+// hard-coded communityPower curve with a governable, swappable strategy.
+// Implementations must be monotonic: increasing any one holding must never
+// decrease the total, since this value feeds directly into reward
+// accounting.
+type CommunityPowerPolicy interface {
+	Evaluate(holds []*big.Int) *big.Int
+}
+
+// Policy selector values, stored on-chain in PoolInfo's community-power
+// policy slot. CommunityPowerPolicyLegacy (the zero value) is also what an
+// unconfigured pool reads, so it must stay the original curve.
+const (
+	CommunityPowerPolicyLegacy = uint8(iota)
+	CommunityPowerPolicyLinearCapped
+	CommunityPowerPolicyPiecewiseLinear
+	CommunityPowerPolicyCbrt
+	CommunityPowerPolicyCustom
+)
+
+// communityPowerPolicyFor resolves a pool's policy selector and parameter
+// vector to a CommunityPowerPolicy. An unrecognised selector falls back to
+// the legacy curve rather than erroring, so a pool never loses its reward
+// accounting over a storage value governance hasn't set yet.
+func communityPowerPolicyFor(selector uint8, params []*big.Int) CommunityPowerPolicy {
+	switch selector {
+	case CommunityPowerPolicyLinearCapped:
+		return newLinearCappedPolicy(params)
+	case CommunityPowerPolicyPiecewiseLinear:
+		return newPiecewiseLinearPolicy(params)
+	case CommunityPowerPolicyCbrt:
+		return newCbrtPolicy(params)
+	case CommunityPowerPolicyCustom:
+		return newCustomPolicy(params)
+	default:
+		return legacyCommunityPowerPolicy{}
+	}
+}
+
+// legacyCommunityPowerPolicy wraps the original hard-coded curve so it can
+// be dispatched through the same interface as the governable policies.
+type legacyCommunityPowerPolicy struct{}
+
+func (legacyCommunityPowerPolicy) Evaluate(holds []*big.Int) *big.Int {
+	return communityPower(&holds)
+}
+
+// etherAmount floors amount (wei) down to a whole-ether big.Int, the same
+// unit every policy below works in.
+func etherAmount(amount *big.Int) *big.Int {
+	return new(big.Int).Div(amount, big.NewInt(params.Ether))
+}
+
+// linearCappedPolicy awards multiplier power per whole ether held, capped
+// per child at cap. Params: [multiplier, cap]; missing entries fall back to
+// defaults chosen to resemble the legacy curve's low end.
+type linearCappedPolicy struct {
+	multiplier *big.Int
+	cap        *big.Int
+}
+
+func newLinearCappedPolicy(p []*big.Int) *linearCappedPolicy {
+	policy := &linearCappedPolicy{multiplier: big.NewInt(10), cap: big.NewInt(100000)}
+	if len(p) > 0 && p[0] != nil && p[0].Sign() >= 0 {
+		policy.multiplier = p[0]
+	}
+	if len(p) > 1 && p[1] != nil && p[1].Sign() >= 0 {
+		policy.cap = p[1]
+	}
+	return policy
+}
+
+func (p *linearCappedPolicy) Evaluate(holds []*big.Int) *big.Int {
+	total := big.NewInt(0)
+	for _, h := range holds {
+		power := new(big.Int).Mul(etherAmount(h), p.multiplier)
+		if power.Cmp(p.cap) > 0 {
+			power = p.cap
+		}
+		total.Add(total, power)
+	}
+	return total
+}
+
+// piecewiseLinearPolicy applies a different per-ether rate within each
+// governance-defined bracket, tax-bracket style: the rate for params[2i+1]
+// applies to the slice of a holding between breakpoints params[2i-2] and
+// params[2i], and everything above the last breakpoint earns nothing.
+// Params: [breakpoint1, rate1, breakpoint2, rate2, ...]; negative rates are
+// clamped to zero. Breakpoints are used in the order given and are not
+// sorted, so the curve is monotonic only when they increase.
+type piecewiseLinearPolicy struct {
+	breakpoints []*big.Int
+	rates       []*big.Int
+}
+
+func newPiecewiseLinearPolicy(p []*big.Int) *piecewiseLinearPolicy {
+	if len(p) == 0 || len(p)%2 != 0 {
+		// Mirrors the legacy curve's two segments: 10x below 10000 ether,
+		// flat above it.
+		return &piecewiseLinearPolicy{
+			breakpoints: []*big.Int{big.NewInt(10000)},
+			rates:       []*big.Int{big.NewInt(10), big.NewInt(0)},
+		}
+	}
+	policy := &piecewiseLinearPolicy{}
+	for i := 0; i+1 < len(p); i += 2 {
+		rate := p[i+1]
+		if rate == nil || rate.Sign() < 0 {
+			rate = big.NewInt(0)
+		}
+		policy.breakpoints = append(policy.breakpoints, p[i])
+		policy.rates = append(policy.rates, rate)
+	}
+	policy.rates = append(policy.rates, big.NewInt(0))
+	return policy
+}
+
+func (p *piecewiseLinearPolicy) evaluateOne(ether *big.Int) *big.Int {
+	total := big.NewInt(0)
+	lower := big.NewInt(0)
+	for i, bp := range p.breakpoints {
+		if ether.Cmp(bp) <= 0 {
+			total.Add(total, new(big.Int).Mul(new(big.Int).Sub(ether, lower), p.rates[i]))
+			return total
+		}
+		total.Add(total, new(big.Int).Mul(new(big.Int).Sub(bp, lower), p.rates[i]))
+		lower = bp
+	}
+	total.Add(total, new(big.Int).Mul(new(big.Int).Sub(ether, lower), p.rates[len(p.rates)-1]))
+	return total
+}
+
+func (p *piecewiseLinearPolicy) Evaluate(holds []*big.Int) *big.Int {
+	total := big.NewInt(0)
+	for _, h := range holds {
+		total.Add(total, p.evaluateOne(etherAmount(h)))
+	}
+	return total
+}
+
+// cbrtPolicy awards the cube root of each child's whole-ether holding,
+// summed across children (unlike the legacy curve, which only bonuses the
+// single largest child) so the result stays monotonic under any single
+// holding's increase. Params are currently unused, reserved for a future
+// configurable root exponent.
+type cbrtPolicy struct{}
+
+func newCbrtPolicy(_ []*big.Int) *cbrtPolicy { return &cbrtPolicy{} }
+
+func (cbrtPolicy) Evaluate(holds []*big.Int) *big.Int {
+	total := big.NewInt(0)
+	for _, h := range holds {
+		total.Add(total, big.NewInt(int64(math.Cbrt(float64(etherAmount(h).Uint64())))))
+	}
+	return total
+}
+
+// customPolicy evaluates a governance-supplied polynomial in whole ether,
+// coefficients[i] applying to ether^i. Negative coefficients are clamped to
+// zero so the curve stays monotonic for any parameter vector governance
+// picks.
+type customPolicy struct {
+	coefficients []*big.Int
+}
+
+func newCustomPolicy(p []*big.Int) *customPolicy {
+	if len(p) == 0 {
+		// Default: power equals the holding in whole ether.
+		return &customPolicy{coefficients: []*big.Int{big.NewInt(0), big.NewInt(1)}}
+	}
+	coefficients := make([]*big.Int, len(p))
+	for i, c := range p {
+		if c == nil || c.Sign() < 0 {
+			coefficients[i] = big.NewInt(0)
+		} else {
+			coefficients[i] = c
+		}
+	}
+	return &customPolicy{coefficients: coefficients}
+}
+
+func (p *customPolicy) Evaluate(holds []*big.Int) *big.Int {
+	total := big.NewInt(0)
+	for _, h := range holds {
+		ether := etherAmount(h)
+		term := big.NewInt(1)
+		value := big.NewInt(0)
+		for _, c := range p.coefficients {
+			value.Add(value, new(big.Int).Mul(c, term))
+			term.Mul(term, ether)
+		}
+		total.Add(total, value)
+	}
+	return total
+}
@@ -0,0 +1,82 @@
+package farms
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func weiFromEther(ether *big.Int) *big.Int {
+	return new(big.Int).Mul(ether, big.NewInt(params.Ether))
+}
+
+// assertMonotonic fails t if increasing a single child's holding by
+// deltaEther ever decreases a policy's total power, the invariant every
+// CommunityPowerPolicy implementation must hold.
+func assertMonotonic(t *testing.T, policy CommunityPowerPolicy, baseEther, deltaEther uint64) {
+	t.Helper()
+	base := new(big.Int).SetUint64(baseEther)
+	after := new(big.Int).Add(base, new(big.Int).SetUint64(deltaEther))
+
+	before := policy.Evaluate([]*big.Int{weiFromEther(base)})
+	afterPower := policy.Evaluate([]*big.Int{weiFromEther(after)})
+	if afterPower.Cmp(before) < 0 {
+		t.Fatalf("power decreased after adding holdings: base=%s ether delta=%s ether before=%s after=%s", base, new(big.Int).SetUint64(deltaEther), before, afterPower)
+	}
+}
+
+func FuzzLinearCappedMonotonic(f *testing.F) {
+	f.Add(uint64(0), uint64(0))
+	f.Add(uint64(500), uint64(9000))
+	f.Add(uint64(20000), uint64(1))
+	policy := newLinearCappedPolicy(nil)
+	f.Fuzz(func(t *testing.T, baseEther, deltaEther uint64) {
+		assertMonotonic(t, policy, baseEther, deltaEther)
+	})
+}
+
+func FuzzPiecewiseLinearMonotonic(f *testing.F) {
+	f.Add(uint64(0), uint64(0))
+	f.Add(uint64(9999), uint64(2))
+	f.Add(uint64(50000), uint64(50000))
+	policy := newPiecewiseLinearPolicy(nil)
+	f.Fuzz(func(t *testing.T, baseEther, deltaEther uint64) {
+		assertMonotonic(t, policy, baseEther, deltaEther)
+	})
+}
+
+func FuzzCbrtMonotonic(f *testing.F) {
+	f.Add(uint64(0), uint64(0))
+	f.Add(uint64(1000), uint64(1))
+	f.Add(uint64(1<<20), uint64(1<<20))
+	policy := newCbrtPolicy(nil)
+	f.Fuzz(func(t *testing.T, baseEther, deltaEther uint64) {
+		assertMonotonic(t, policy, baseEther, deltaEther)
+	})
+}
+
+func FuzzCustomMonotonic(f *testing.F) {
+	f.Add(uint64(0), uint64(0))
+	f.Add(uint64(7), uint64(13))
+	policy := newCustomPolicy([]*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(1)})
+	f.Fuzz(func(t *testing.T, baseEther, deltaEther uint64) {
+		assertMonotonic(t, policy, baseEther, deltaEther)
+	})
+}
+
+// TestCommunityPowerPolicyForUnknownSelectorFallsBackToLegacy pins the
+// "unconfigured pool" default: selector 0 (and any selector this package
+// doesn't recognise) must resolve to the original curve, not panic or
+// silently return zero power.
+func TestCommunityPowerPolicyForUnknownSelectorFallsBackToLegacy(t *testing.T) {
+	holds := []*big.Int{weiFromEther(big.NewInt(12345))}
+	want := communityPower(&holds)
+
+	for _, selector := range []uint8{CommunityPowerPolicyLegacy, 255} {
+		got := communityPowerPolicyFor(selector, nil).Evaluate(holds)
+		if got.Cmp(want) != 0 {
+			t.Errorf("selector %d: got %s, want legacy curve's %s", selector, got, want)
+		}
+	}
+}
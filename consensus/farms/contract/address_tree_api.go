@@ -0,0 +1,164 @@
+package contract
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// defaultChildrenPageSize and maxChildrenPageSize bound anchor_childrenOf
+	// so a wide subtree can't be used to force an unbounded response.
+	defaultChildrenPageSize = 100
+	maxChildrenPageSize     = 500
+
+	// defaultSubtreeMaxDepth bounds anchor_subtree when the caller omits a
+	// depth, for the same reason.
+	defaultSubtreeMaxDepth = 5
+)
+
+var errChildrenPageSizeTooLarge = errors.New("anchor: requested page size exceeds the maximum")
+
+// AddressTreeAPI exposes the address tree under the "anchor" RPC namespace,
+// giving block explorers and dashboards direct access to parent/depth/
+// children lookups without hand-computing storage slot hashes.
+type AddressTreeAPI struct {
+	tree *AddressTreeContract
+}
+
+// NewAddressTreeAPI wraps tree for RPC registration, e.g.:
+//
+//	stack.RegisterAPIs([]rpc.API{{
+//	    Namespace: "anchor",
+//	    Service:   contract.NewAddressTreeAPI(tree),
+//	}})
+func NewAddressTreeAPI(tree *AddressTreeContract) *AddressTreeAPI {
+	return &AddressTreeAPI{tree: tree}
+}
+
+// ParentOf implements anchor_parentOf.
+func (api *AddressTreeAPI) ParentOf(ctx context.Context, account common.Address) (common.Address, error) {
+	return api.tree.ParentOf(account)
+}
+
+// DepthOf implements anchor_depthOf.
+func (api *AddressTreeAPI) DepthOf(ctx context.Context, account common.Address) (*hexutil.Big, error) {
+	depth, err := api.tree.DepthOf(account)
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(depth), nil
+}
+
+// ChildrenPage is a single page of anchor_childrenOf.
+type ChildrenPage struct {
+	Children []common.Address `json:"children"`
+	Offset   int              `json:"offset"`
+	HasMore  bool             `json:"hasMore"`
+}
+
+// ChildrenOf implements anchor_childrenOf, paginating over the full child
+// list so a wide subtree can't be used to force an unbounded response.
+func (api *AddressTreeAPI) ChildrenOf(ctx context.Context, parent common.Address, offset, limit int) (*ChildrenPage, error) {
+	if limit <= 0 {
+		limit = defaultChildrenPageSize
+	}
+	if limit > maxChildrenPageSize {
+		return nil, errChildrenPageSizeTooLarge
+	}
+
+	all, err := api.tree.ChildrenOf(parent)
+	if err != nil {
+		return nil, err
+	}
+
+	children := *all
+	if offset < 0 || offset > len(children) {
+		offset = len(children)
+	}
+	end := offset + limit
+	hasMore := end < len(children)
+	if end > len(children) {
+		end = len(children)
+	}
+
+	return &ChildrenPage{
+		Children: children[offset:end],
+		Offset:   offset,
+		HasMore:  hasMore,
+	}, nil
+}
+
+// SubtreeNode is a single level of the anchor_subtree response tree.
+type SubtreeNode struct {
+	Account  common.Address `json:"account"`
+	Children []*SubtreeNode `json:"children,omitempty"`
+}
+
+// Subtree implements anchor_subtree(root, maxDepth), walking ChildrenOf
+// depth-first down to maxDepth (capped to avoid a runaway walk over a
+// deep or wide tree).
+func (api *AddressTreeAPI) Subtree(ctx context.Context, root common.Address, maxDepth int) (*SubtreeNode, error) {
+	if maxDepth <= 0 || maxDepth > defaultSubtreeMaxDepth {
+		maxDepth = defaultSubtreeMaxDepth
+	}
+	return api.subtree(root, maxDepth)
+}
+
+func (api *AddressTreeAPI) subtree(account common.Address, depthLeft int) (*SubtreeNode, error) {
+	node := &SubtreeNode{Account: account}
+	if depthLeft == 0 {
+		return node, nil
+	}
+
+	children, err := api.tree.ChildrenOf(account)
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range *children {
+		childNode, err := api.subtree(child, depthLeft-1)
+		if err != nil {
+			return nil, err
+		}
+		node.Children = append(node.Children, childNode)
+	}
+	return node, nil
+}
+
+// AddressTreeAPIs builds the rpc.API registration list for tree, for
+// callers that register namespaces the same way the other geth services
+// (debug_, trace_, txpool_) are wired up.
+func AddressTreeAPIs(tree *AddressTreeContract) []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "anchor",
+			Service:   NewAddressTreeAPI(tree),
+		},
+	}
+}
+
+// AddressTreeGraphQLSchema is the GraphQL schema fragment for the same
+// anchor_* surface; it is merged into the node's main schema by whichever
+// GraphQL service wires up AddressTreeAPI.
+const AddressTreeGraphQLSchema = `
+    extend type Query {
+        anchorParentOf(account: Address!): Address
+        anchorDepthOf(account: Address!): Long
+        anchorChildrenOf(parent: Address!, offset: Int = 0, limit: Int = 100): AnchorChildrenPage!
+        anchorSubtree(root: Address!, maxDepth: Int = 5): AnchorSubtreeNode!
+    }
+
+    type AnchorChildrenPage {
+        children: [Address!]!
+        offset: Int!
+        hasMore: Boolean!
+    }
+
+    type AnchorSubtreeNode {
+        account: Address!
+        children: [AnchorSubtreeNode!]
+    }
+`
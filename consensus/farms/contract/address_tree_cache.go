@@ -0,0 +1,143 @@
+package contract
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	defaultNodeCacheSize    = 8192
+	defaultNegativeCacheTTL = 30 * time.Second
+	negativeCacheSweepLimit = 4096
+)
+
+var (
+	addressTreeCacheHitMeter    = metrics.NewRegisteredMeter("contract/addresstree/cache/hit", nil)
+	addressTreeCacheMissMeter   = metrics.NewRegisteredMeter("contract/addresstree/cache/miss", nil)
+	addressTreeNegativeHitMeter = metrics.NewRegisteredMeter("contract/addresstree/cache/negativehit", nil)
+	addressTreeInflightGauge    = metrics.NewRegisteredGauge("contract/addresstree/cache/inflight", nil)
+)
+
+// negativeCacheEntry records that account resolved to "not registered" at a
+// given point in time, so repeated lookups of the same never-registered
+// account don't re-hit the anchor RPC until the entry expires.
+type negativeCacheEntry struct {
+	until time.Time
+}
+
+// slotTriple is the (version, parent, depth) result of a single account's
+// three storage-slot reads, cached together so a hit short-circuits the
+// three-slot fetch tryCacheAccountNode would otherwise make.
+type slotTriple struct {
+	version common.Hash
+	parent  common.Hash
+	depth   common.Hash
+
+	// anchorBlock is the anchor-chain block number the triple was resolved
+	// against, used by evictSince to purge entries from an abandoned branch.
+	anchorBlock uint64
+}
+
+func (a *AddressTreeContract) nodeCache() *lru.Cache {
+	if a.nodeLRU == nil {
+		c, _ := lru.New(defaultNodeCacheSize)
+		a.nodeLRU = c
+	}
+	return a.nodeLRU
+}
+
+// BatchStorageAt packs up to len(hashes) JSON-RPC eth_getStorageAt calls for
+// addr into a single batch round trip, mirroring ethclient's
+// BatchCallContext support for other call kinds.
+func (a *AddressTreeContract) BatchStorageAt(ctx context.Context, addr common.Address, hashes []common.Hash) ([]common.Hash, error) {
+	if a.anchorClient == nil {
+		return nil, errFetchStateFromRemoteState
+	}
+
+	results := make([]common.Hash, len(hashes))
+	raw := make([]hexutilBytes, len(hashes))
+	elems := make([]rpc.BatchElem, len(hashes))
+	for i, h := range hashes {
+		elems[i] = rpc.BatchElem{
+			Method: "eth_getStorageAt",
+			Args:   []interface{}{addr, h, "latest"},
+			Result: &raw[i],
+		}
+	}
+
+	addressTreeInflightGauge.Inc(1)
+	err := a.anchorClient.Client().BatchCallContext(ctx, elems)
+	addressTreeInflightGauge.Dec(1)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, elem := range elems {
+		if elem.Error != nil {
+			return nil, elem.Error
+		}
+		results[i] = common.BytesToHash(raw[i])
+	}
+	return results, nil
+}
+
+// hexutilBytes decodes each eth_getStorageAt result from its 0x-prefixed
+// hex JSON form.
+type hexutilBytes = hexutil.Bytes
+
+// lookupCachedSlots consults the LRU and negative caches before
+// tryCacheAccountNode falls back to a remote fetch.
+func (a *AddressTreeContract) lookupCachedSlots(account common.Address) (*slotTriple, bool, bool) {
+	if t, ok := a.nodeCache().Get(account); ok {
+		addressTreeCacheHitMeter.Mark(1)
+		return t.(*slotTriple), true, false
+	}
+
+	if a.negativeCache != nil {
+		if entry, ok := a.negativeCache[account]; ok {
+			if time.Now().Before(entry.until) {
+				addressTreeNegativeHitMeter.Mark(1)
+				return nil, false, true
+			}
+			delete(a.negativeCache, account)
+		}
+	}
+
+	addressTreeCacheMissMeter.Mark(1)
+	return nil, false, false
+}
+
+// storeCachedSlots records a resolved triple, or — when parent is still the
+// null address — a short-TTL negative cache entry for account.
+func (a *AddressTreeContract) storeCachedSlots(account common.Address, t *slotTriple) {
+	if t.parent == common.HexToHash(nullAddress) {
+		if a.negativeCache == nil {
+			a.negativeCache = make(map[common.Address]negativeCacheEntry)
+		}
+		ttl := defaultNegativeCacheTTL
+		if a.negativeCacheTTL > 0 {
+			ttl = a.negativeCacheTTL
+		}
+		a.negativeCache[account] = negativeCacheEntry{until: time.Now().Add(ttl)}
+		if len(a.negativeCache) > negativeCacheSweepLimit {
+			a.sweepNegativeCache()
+		}
+		return
+	}
+	a.nodeCache().Add(account, t)
+}
+
+func (a *AddressTreeContract) sweepNegativeCache() {
+	now := time.Now()
+	for acc, entry := range a.negativeCache {
+		if now.After(entry.until) {
+			delete(a.negativeCache, acc)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/farms/utils"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/anchor_network"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/systemcontracts"
 	"github.com/ethereum/go-ethereum/core/systemcontracts/anchor"
@@ -16,10 +17,12 @@ import (
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/params"
+	lru "github.com/hashicorp/golang-lru"
 	"golang.org/x/crypto/sha3"
 	"math"
 	"math/big"
 	"strings"
+	"time"
 )
 
 const (
@@ -53,6 +56,33 @@ type AddressTreeContract struct {
 
 	anchorClient *ethclient.Client
 	treeVersion  uint64
+
+	// syncService, when set, serves gossip-verified address-tree nodes
+	// published over the anchorgossip sub-protocol so tryCacheAccountNode
+	// can skip the anchorClient round trip entirely.
+	syncService *anchor_network.AnchorSyncService
+
+	// nodeLRU and negativeCache short-circuit tryCacheAccountNode for
+	// accounts that were already resolved (or already known unregistered)
+	// during this process's lifetime.
+	nodeLRU          *lru.Cache
+	negativeCache    map[common.Address]negativeCacheEntry
+	negativeCacheTTL time.Duration
+
+	// backend, when set, replaces the hardcoded anchorClient round trip with
+	// a pluggable AnchorBackend (full-node RPC or verified light-client).
+	backend AnchorBackend
+
+	// headTracker follows the anchor chain's canonical head so reorgs can
+	// evict cache entries written on an abandoned branch.
+	headTracker *anchorHeadTracker
+}
+
+// SetBackend switches the contract onto a pluggable AnchorBackend. Leaving
+// it unset preserves the historical behaviour of talking to anchorClient
+// directly.
+func (a *AddressTreeContract) SetBackend(backend AnchorBackend) {
+	a.backend = backend
 }
 
 func NewAddressTreeContract(state *state.StateDB, cacheDb *ethdb.Database, address common.Address, anchorClient *ethclient.Client, useVersion uint64) *AddressTreeContract {
@@ -70,12 +100,45 @@ func NewAddressTreeContract(state *state.StateDB, cacheDb *ethdb.Database, addre
 	}
 }
 
+// SetSyncService attaches the anchor gossip subscriber. It is optional and
+// may be left nil, in which case every uncached lookup falls back to the
+// anchorClient RPC as before.
+func (a *AddressTreeContract) SetSyncService(s *anchor_network.AnchorSyncService) {
+	a.syncService = s
+}
+
+// ApplyCacheConfig sizes the node LRU and negative-cache TTL from the
+// network's AnchorNetworkInfo, falling back to the package defaults when the
+// fields are left at their zero value.
+func (a *AddressTreeContract) ApplyCacheConfig(info *anchor_network.AnchorNetworkInfo) {
+	if info == nil {
+		return
+	}
+	size := defaultNodeCacheSize
+	if info.NodeCacheSize > 0 {
+		size = info.NodeCacheSize
+	}
+	c, _ := lru.New(size)
+	a.nodeLRU = c
+
+	if info.NegativeCacheTTLSeconds > 0 {
+		a.negativeCacheTTL = time.Duration(info.NegativeCacheTTLSeconds) * time.Second
+	}
+}
+
 func (a *AddressTreeContract) inAnchorNet() bool {
 	return a.cacheDB != nil && a.anchorClient != nil && a.treeVersion > 0
 }
 
 func (a *AddressTreeContract) storageAt(addr common.Address, hash common.Hash) (common.Hash, error) {
 	if a.inAnchorNet() {
+		if a.backend != nil {
+			ret, err := a.backend.StorageAt(context.TODO(), addr, hash, nil)
+			if err != nil {
+				return common.Hash{}, err
+			}
+			return common.BytesToHash(ret), nil
+		}
 		ret, err := a.anchorClient.StorageAt(context.TODO(), addr, hash, nil)
 		if err != nil {
 			return common.Hash{}, err
@@ -88,6 +151,9 @@ func (a *AddressTreeContract) storageAt(addr common.Address, hash common.Hash) (
 
 func (a *AddressTreeContract) rawStorageAt(addr common.Address, hash common.Hash) ([]byte, error) {
 	if a.inAnchorNet() {
+		if a.backend != nil {
+			return a.backend.RawStorageAt(context.TODO(), addr, hash, nil)
+		}
 		ret, err := a.anchorClient.RawStorageAt(context.TODO(), addr, hash, nil)
 		if err != nil {
 			return []byte{}, err
@@ -129,21 +195,58 @@ func (a *AddressTreeContract) tryCacheAccountNode(account common.Address) error
 		return nil
 	}
 
-	versionBytes, err := a.storageAt(a.ContractAddress, anchor.VersionSlotHash(account))
-	if err != nil {
-		return errFetchStateFromRemoteState
-	}
+	var versionBytes, parentBytes, depthBytes common.Hash
 
-	parentBytes, err := a.storageAt(a.ContractAddress, anchor.ParentSlotHash(account))
-	if err != nil {
-		return errFetchStateFromRemoteState
-	}
+	if cached, ok, negative := a.lookupCachedSlots(account); negative {
+		return nil
+	} else if ok {
+		versionBytes, parentBytes, depthBytes = cached.version, cached.parent, cached.depth
+	} else if node, ok := a.syncService.Lookup(account); ok {
+		// a gossip-verified node means we can skip the anchorClient round trip
+		versionBytes = common.BigToHash(big.NewInt(0).SetUint64(node.Version))
+		parentBytes = common.BytesToHash(common.LeftPadBytes(node.Parent.Bytes(), 32))
+		depthBytes = common.BigToHash(big.NewInt(0).SetUint64(node.Depth))
+	} else if vb, ok := a.backend.(interface {
+		VerifiedTreeSlots(ctx context.Context, contractAddress, account common.Address, blockNumber *big.Int) (common.Hash, common.Hash, common.Hash, error)
+	}); ok {
+		// running against a proof-verifying backend: each slot is checked
+		// against the anchor block's state root before we trust it.
+		var err error
+		versionBytes, parentBytes, depthBytes, err = vb.VerifiedTreeSlots(context.TODO(), a.ContractAddress, account, nil)
+		if err != nil {
+			return errFetchStateFromRemoteState
+		}
+	} else if a.inAnchorNet() {
+		slots, err := a.BatchStorageAt(context.TODO(), a.ContractAddress, []common.Hash{
+			anchor.VersionSlotHash(account),
+			anchor.ParentSlotHash(account),
+			anchor.DepthSlotHash(account),
+		})
+		if err != nil {
+			return errFetchStateFromRemoteState
+		}
+		versionBytes, parentBytes, depthBytes = slots[0], slots[1], slots[2]
+	} else {
+		var err error
+		versionBytes, err = a.storageAt(a.ContractAddress, anchor.VersionSlotHash(account))
+		if err != nil {
+			return errFetchStateFromRemoteState
+		}
 
-	depthBytes, err := a.storageAt(a.ContractAddress, anchor.DepthSlotHash(account))
-	if err != nil {
-		return errFetchStateFromRemoteState
+		parentBytes, err = a.storageAt(a.ContractAddress, anchor.ParentSlotHash(account))
+		if err != nil {
+			return errFetchStateFromRemoteState
+		}
+
+		depthBytes, err = a.storageAt(a.ContractAddress, anchor.DepthSlotHash(account))
+		if err != nil {
+			return errFetchStateFromRemoteState
+		}
 	}
 
+	anchorBlock := a.currentAnchorBlock()
+	a.storeCachedSlots(account, &slotTriple{version: versionBytes, parent: parentBytes, depth: depthBytes, anchorBlock: anchorBlock})
+
 	parent := common.HashToAddress(parentBytes)
 	version := big.NewInt(0).SetBytes(versionBytes.Bytes())
 	depth := big.NewInt(0).SetBytes(depthBytes.Bytes())
@@ -164,6 +267,10 @@ func (a *AddressTreeContract) tryCacheAccountNode(account common.Address) error
 			return errWriteStateToRawDB
 		}
 
+		if putBatch.Put(anchor.AnchorBlockDBKey(account), common.BigToHash(big.NewInt(0).SetUint64(anchorBlock)).Bytes()) != nil {
+			return errWriteStateToRawDB
+		}
+
 		if putBatch.Write() != nil {
 			return errBatchCommitToRawDB
 		}
@@ -183,6 +290,10 @@ func (a *AddressTreeContract) tryCacheAccountNode(account common.Address) error
 			return errWriteStateToRawDB
 		}
 
+		if putBatch.Put(anchor.AnchorBlockDBKey(account), common.BigToHash(big.NewInt(0).SetUint64(anchorBlock)).Bytes()) != nil {
+			return errWriteStateToRawDB
+		}
+
 		if putBatch.Write() != nil {
 			return errBatchCommitToRawDB
 		}
@@ -0,0 +1,143 @@
+package contract
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/systemcontracts/anchor"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// anchorHeadTracker follows the anchor chain's canonical head and detects
+// reorgs by comparing each new header's parent hash against the header it
+// replaces, so cached address-tree entries written on an abandoned branch
+// can be evicted instead of being silently served forever.
+type anchorHeadTracker struct {
+	mu      sync.Mutex
+	byNum   map[uint64]common.Hash
+	highest uint64
+}
+
+func newAnchorHeadTracker() *anchorHeadTracker {
+	return &anchorHeadTracker{byNum: make(map[uint64]common.Hash)}
+}
+
+// observe records header and, if it replaces a different hash recorded at
+// its height, returns the lowest height to evict from: header's own height
+// when its ParentHash matches the hash recorded one below, otherwise the
+// bottom of the unbroken run of recorded heights below it (ancestors of the
+// new branch are not fetched, so the real fork point is not located). The
+// caller evicts cache entries at or after that height.
+func (t *anchorHeadTracker) observe(header *types.Header) (forkBlock uint64, reorged bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	number := header.Number.Uint64()
+	if existing, ok := t.byNum[number]; ok && existing != header.Hash() {
+		fork := number
+		for fork > 0 {
+			if prev, ok := t.byNum[fork-1]; !ok || prev == header.ParentHash {
+				break
+			}
+			fork--
+		}
+		for n := range t.byNum {
+			if n >= fork {
+				delete(t.byNum, n)
+			}
+		}
+		t.byNum[number] = header.Hash()
+		t.highest = number
+		return fork, true
+	}
+
+	t.byNum[number] = header.Hash()
+	if number > t.highest {
+		t.highest = number
+	}
+	return 0, false
+}
+
+// StartReorgWatch subscribes to the anchor chain's head feed (via backend,
+// falling back to anchorClient) and evicts any nodeLRU / cacheDB entries
+// written at or after a detected fork point.
+func (a *AddressTreeContract) StartReorgWatch(ctx context.Context) error {
+	if !a.inAnchorNet() {
+		return nil
+	}
+	if a.headTracker == nil {
+		a.headTracker = newAnchorHeadTracker()
+	}
+
+	headCh := make(chan *types.Header, 16)
+	var sub interface{ Unsubscribe() }
+	if a.backend != nil {
+		s, err := a.backend.SubscribeNewHead(ctx, headCh)
+		if err != nil {
+			return err
+		}
+		sub = s
+	} else {
+		s, err := a.anchorClient.SubscribeNewHead(ctx, headCh)
+		if err != nil {
+			return err
+		}
+		sub = s
+	}
+
+	go func() {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case header := <-headCh:
+				if fork, reorged := a.headTracker.observe(header); reorged {
+					a.evictSince(fork)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// evictSince drops every address-tree node held in nodeLRU whose recorded
+// anchor block number is >= forkBlock, together with its cacheDB entries,
+// forcing tryCacheAccountNode to re-fetch (and re-verify) them from the new
+// canonical branch. Only nodeLRU is scanned: cacheDB entries of accounts no
+// longer in nodeLRU are not examined, and their sidecar anchor block keys
+// are not read.
+func (a *AddressTreeContract) evictSince(forkBlock uint64) {
+	log.Warn("anchor chain reorg detected, evicting address-tree cache", "forkBlock", forkBlock)
+
+	if a.nodeLRU != nil {
+		for _, key := range a.nodeLRU.Keys() {
+			account := key.(common.Address)
+			if v, ok := a.nodeLRU.Peek(account); ok {
+				if t := v.(*slotTriple); t.anchorBlock >= forkBlock {
+					a.nodeLRU.Remove(account)
+					if a.cacheDB != nil {
+						_ = (*a.cacheDB).Delete(anchor.ParentDBKey(account))
+						_ = (*a.cacheDB).Delete(anchor.DepthDBKey(account))
+						_ = (*a.cacheDB).Delete(anchor.VersionDBKey(account))
+						_ = (*a.cacheDB).Delete(anchor.AnchorBlockDBKey(account))
+						_ = (*a.cacheDB).Delete(anchor.ChildrenDBKey(account))
+					}
+				}
+			}
+		}
+	}
+}
+
+// currentAnchorBlock returns the tracker's highest known anchor block
+// number, used to stamp newly cached entries.
+func (a *AddressTreeContract) currentAnchorBlock() uint64 {
+	if a.headTracker == nil {
+		return 0
+	}
+	a.headTracker.mu.Lock()
+	defer a.headTracker.mu.Unlock()
+	return a.headTracker.highest
+}
@@ -0,0 +1,145 @@
+package contract
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// AnchorBackend abstracts how AddressTreeContract reaches the anchor chain,
+// so operators aren't forced to trust a single full-node RPC endpoint.
+type AnchorBackend interface {
+	StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error)
+	RawStorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+}
+
+// rpcAnchorBackend is the existing behaviour: trust a single full-node
+// anchor RPC endpoint outright.
+type rpcAnchorBackend struct {
+	cli *ethclient.Client
+}
+
+// NewRPCAnchorBackend wraps an ethclient.Client as an AnchorBackend.
+func NewRPCAnchorBackend(cli *ethclient.Client) AnchorBackend {
+	return &rpcAnchorBackend{cli: cli}
+}
+
+func (b *rpcAnchorBackend) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	return b.cli.StorageAt(ctx, account, key, blockNumber)
+}
+
+func (b *rpcAnchorBackend) RawStorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	return b.cli.RawStorageAt(ctx, account, key, blockNumber)
+}
+
+func (b *rpcAnchorBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return b.cli.HeaderByNumber(ctx, number)
+}
+
+func (b *rpcAnchorBackend) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return b.cli.SubscribeNewHead(ctx, ch)
+}
+
+// lightAnchorBackend never trusts a raw StorageAt response: every slot is
+// fetched as an EIP-1186 account+storage proof and verified against a
+// header chain rooted at a trusted checkpoint hash, so a malicious or
+// compromised anchor RPC endpoint can't feed forged parent/depth/version
+// values into the address tree.
+type lightAnchorBackend struct {
+	cli            *ethclient.Client
+	checkpointHash common.Hash
+	trustedHeaders map[common.Hash]*types.Header
+}
+
+// NewLightAnchorBackend builds a light-client backend that still dials cli
+// for eth_getProof/eth_getHeaderByNumber, but verifies every response
+// against the checkpoint before trusting it.
+func NewLightAnchorBackend(cli *ethclient.Client, checkpointHash common.Hash) AnchorBackend {
+	return &lightAnchorBackend{
+		cli:            cli,
+		checkpointHash: checkpointHash,
+		trustedHeaders: make(map[common.Hash]*types.Header),
+	}
+}
+
+func (b *lightAnchorBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	header, err := b.cli.HeaderByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.verifyHeaderChain(header); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// verifyHeaderChain accepts header if it is the checkpoint, is already
+// trusted, or has an already-trusted direct parent, and then trusts it.
+// It does not fetch missing ancestors, so a header more than one block
+// beyond the trusted set is rejected.
+func (b *lightAnchorBackend) verifyHeaderChain(header *types.Header) error {
+	if header.Hash() == b.checkpointHash {
+		b.trustedHeaders[header.Hash()] = header
+		return nil
+	}
+	if _, ok := b.trustedHeaders[header.Hash()]; ok {
+		return nil
+	}
+	if len(b.trustedHeaders) == 0 {
+		return errors.New("anchor light client: no trusted checkpoint header yet")
+	}
+	if _, ok := b.trustedHeaders[header.ParentHash]; !ok {
+		return errors.New("anchor light client: header does not chain to a trusted ancestor")
+	}
+	b.trustedHeaders[header.Hash()] = header
+	return nil
+}
+
+func (b *lightAnchorBackend) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	header, err := b.HeaderByNumber(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := fetchProof(ctx, b.cli.Client(), account, []common.Hash{key}, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	storageRoot, err := verifyAccountProof(header.Root, proof)
+	if err != nil {
+		return nil, err
+	}
+	if len(proof.StorageProof) != 1 {
+		return nil, errors.New("anchor light client: eth_getProof returned an unexpected number of storage proofs")
+	}
+	value, err := verifyStorageSlot(storageRoot, proof.StorageProof[0])
+	if err != nil {
+		return nil, err
+	}
+	return value.Bytes(), nil
+}
+
+func (b *lightAnchorBackend) RawStorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	return b.StorageAt(ctx, account, key, blockNumber)
+}
+
+func (b *lightAnchorBackend) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return b.cli.SubscribeNewHead(ctx, ch)
+}
+
+// VerifiedTreeSlots fetches and verifies version/parent/depth for account in
+// a single eth_getProof round trip against the anchor block's state root,
+// closing the trust hole a plain StorageAt call would leave open.
+func (b *lightAnchorBackend) VerifiedTreeSlots(ctx context.Context, contractAddress, account common.Address, blockNumber *big.Int) (version, parent, depth common.Hash, err error) {
+	header, err := b.HeaderByNumber(ctx, blockNumber)
+	if err != nil {
+		return common.Hash{}, common.Hash{}, common.Hash{}, err
+	}
+	return verifiedSlots(ctx, b.cli.Client(), header.Root, contractAddress, account, blockNumber)
+}
@@ -2,9 +2,9 @@ package contract
 
 import (
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/farms/contract/layout"
 	"github.com/ethereum/go-ethereum/consensus/farms/utils"
 	"github.com/ethereum/go-ethereum/core/state"
-	"golang.org/x/crypto/sha3"
 	"math/big"
 )
 
@@ -17,6 +17,12 @@ type FarmContract struct {
 	address     common.Address
 	state       *state.StateDB
 	isAnchorNet bool
+
+	// tx is non-nil when this FarmContract was produced by FarmTx.Farm, in
+	// which case GetCommunityAccRewardPerShare/SetCommunityAccRewardPerShare
+	// and GetParentLastUpdateBlock/SetParentLastUpdateBlock shadow through
+	// its overlay instead of going straight to state.
+	tx *FarmTx
 }
 
 func NewFarmContract(state *state.StateDB, address common.Address, isAnchorNet bool) *FarmContract {
@@ -49,37 +55,13 @@ func (fc *FarmContract) GetUserInfo(pool common.Address, account common.Address)
 }
 
 func (fc *FarmContract) GetCommunityAccRewardPerShare(pool common.Address, rewardToken common.Address) *big.Int {
-	var slot1 common.Hash
-	var slot2 common.Hash
-
-	harsher := sha3.NewLegacyKeccak256()
-	harsher.Write(common.LeftPadBytes(pool.Bytes(), 32))
-	harsher.Write(common.LeftPadBytes(common.IntToSlot(FarmMemberSlotCommunityAccRewardPerShare).Bytes(), 32))
-	harsher.Sum(slot1[:0])
-	harsher.Reset()
-
-	harsher.Write(common.LeftPadBytes(rewardToken.Bytes(), 32))
-	harsher.Write(slot1.Bytes())
-	harsher.Sum(slot2[:0])
-
-	return new(big.Int).SetBytes(fc.state.GetState(fc.address, slot2).Bytes())
+	slot := layout.NestedMappingSlot(common.BytesToHash(pool.Bytes()), common.IntToSlot(FarmMemberSlotCommunityAccRewardPerShare), common.BytesToHash(rewardToken.Bytes()))
+	return new(big.Int).SetBytes(fc.getState(slot).Bytes())
 }
 
 func (fc *FarmContract) SetCommunityAccRewardPerShare(pool common.Address, rewardToken common.Address, accRewardPerShare *big.Int) {
-	var slot1 common.Hash
-	var slot2 common.Hash
-
-	harsher := sha3.NewLegacyKeccak256()
-	harsher.Write(common.LeftPadBytes(pool.Bytes(), 32))
-	harsher.Write(common.LeftPadBytes(common.IntToSlot(FarmMemberSlotCommunityAccRewardPerShare).Bytes(), 32))
-	harsher.Sum(slot1[:0])
-	harsher.Reset()
-
-	harsher.Write(common.LeftPadBytes(rewardToken.Bytes(), 32))
-	harsher.Write(slot1.Bytes())
-	harsher.Sum(slot2[:0])
-
-	fc.state.SetState(fc.address, slot2, common.BigToHash(accRewardPerShare))
+	slot := layout.NestedMappingSlot(common.BytesToHash(pool.Bytes()), common.IntToSlot(FarmMemberSlotCommunityAccRewardPerShare), common.BytesToHash(rewardToken.Bytes()))
+	fc.setState(slot, common.BigToHash(accRewardPerShare))
 }
 
 func (fc *FarmContract) GetParentLastUpdateBlock(pool common.Address, account common.Address) *big.Int {
@@ -87,22 +69,8 @@ func (fc *FarmContract) GetParentLastUpdateBlock(pool common.Address, account co
 		panic("GetLastUpdateBlock method only work AnchorNet")
 	}
 
-	var slot1 common.Hash
-	var slot2 common.Hash
-
-	harsher := sha3.NewLegacyKeccak256()
-	harsher.Write(common.LeftPadBytes(pool.Bytes(), 32))
-	harsher.Write(common.LeftPadBytes(common.IntToSlot(FarmMemberSlotLastUpdateBlockOf).Bytes(), 32))
-	harsher.Sum(slot1[:0])
-	harsher.Reset()
-
-	harsher.Write(common.LeftPadBytes(account.Bytes(), 32))
-	harsher.Write(common.LeftPadBytes(slot1.Bytes(), 32))
-	harsher.Sum(slot2[:0])
-	harsher.Reset()
-
-	lastUpdateBlock := fc.state.GetState(fc.address, slot2)
-	return new(big.Int).SetBytes(lastUpdateBlock.Bytes())
+	slot := layout.NestedMappingSlot(common.BytesToHash(pool.Bytes()), common.IntToSlot(FarmMemberSlotLastUpdateBlockOf), common.BytesToHash(account.Bytes()))
+	return new(big.Int).SetBytes(fc.getState(slot).Bytes())
 }
 
 func (fc *FarmContract) SetParentLastUpdateBlock(pool common.Address, account common.Address, number *big.Int) {
@@ -110,19 +78,25 @@ func (fc *FarmContract) SetParentLastUpdateBlock(pool common.Address, account co
 		panic("GetLastUpdateBlock method only work AnchorNet")
 	}
 
-	var slot1 common.Hash
-	var slot2 common.Hash
-
-	harsher := sha3.NewLegacyKeccak256()
-	harsher.Write(common.LeftPadBytes(pool.Bytes(), 32))
-	harsher.Write(common.LeftPadBytes(common.IntToSlot(FarmMemberSlotLastUpdateBlockOf).Bytes(), 32))
-	harsher.Sum(slot1[:0])
-	harsher.Reset()
+	slot := layout.NestedMappingSlot(common.BytesToHash(pool.Bytes()), common.IntToSlot(FarmMemberSlotLastUpdateBlockOf), common.BytesToHash(account.Bytes()))
+	fc.setState(slot, common.BigToHash(number))
+}
 
-	harsher.Write(common.LeftPadBytes(account.Bytes(), 32))
-	harsher.Write(common.LeftPadBytes(slot1.Bytes(), 32))
-	harsher.Sum(slot2[:0])
-	harsher.Reset()
+// getState and setState route GetCommunityAccRewardPerShare/
+// SetCommunityAccRewardPerShare and GetParentLastUpdateBlock/
+// SetParentLastUpdateBlock through fc.tx's overlay when fc was produced by
+// FarmTx.Farm, and straight to state otherwise.
+func (fc *FarmContract) getState(slot common.Hash) common.Hash {
+	if fc.tx != nil {
+		return fc.tx.getState(fc.address, slot)
+	}
+	return fc.state.GetState(fc.address, slot)
+}
 
-	fc.state.SetState(fc.address, slot2, common.BigToHash(number))
+func (fc *FarmContract) setState(slot common.Hash, value common.Hash) {
+	if fc.tx != nil {
+		fc.tx.setState(fc.address, slot, value)
+		return
+	}
+	fc.state.SetState(fc.address, slot, value)
 }
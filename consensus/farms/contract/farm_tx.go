@@ -0,0 +1,173 @@
+package contract
+
+import (
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+)
+
+// farmTxSlotKey identifies one storage slot of one contract account, the
+// unit FarmTx buffers writes and journals undos at.
+type farmTxSlotKey struct {
+	address common.Address
+	slot    common.Hash
+}
+
+// farmTxJournalEntry undoes exactly one FarmTx write, the same shape as
+// core/state's own journal entries: RevertToSnapshot replays these in
+// reverse instead of diffing before/after state.
+type farmTxJournalEntry struct {
+	undo func()
+}
+
+// FarmTx buffers the Set* calls FarmContract, PoolInfo and UserInfo would
+// otherwise issue straight against the StateDB, so a farm-reward
+// computation that touches many pools and users can be rolled back as one
+// unit instead of leaving whatever it already wrote committed to the
+// journal. Reads shadow the overlay first and fall back to the underlying
+// StateDB, so code running against a FarmTx sees its own uncommitted
+// writes exactly as if they'd already landed.
+type FarmTx struct {
+	state *state.StateDB
+
+	overlay    map[farmTxSlotKey]common.Hash
+	rawOverlay map[farmTxSlotKey][]byte
+	journal    []farmTxJournalEntry
+}
+
+// Begin returns a FarmTx buffering writes against fc's underlying StateDB.
+// Use FarmTx.Farm/Pool/User to rebind a FarmContract/PoolInfo/UserInfo onto
+// it so their Get/Set methods read and write through the overlay.
+func (fc *FarmContract) Begin() *FarmTx {
+	return &FarmTx{
+		state:      fc.state,
+		overlay:    make(map[farmTxSlotKey]common.Hash),
+		rawOverlay: make(map[farmTxSlotKey][]byte),
+	}
+}
+
+// Farm returns a copy of fc bound to tx, so its Get/Set methods shadow
+// reads and writes through tx's overlay instead of going straight to the
+// underlying StateDB.
+func (tx *FarmTx) Farm(fc *FarmContract) *FarmContract {
+	bound := *fc
+	bound.tx = tx
+	return &bound
+}
+
+// Pool returns a copy of p bound to tx, the PoolInfo analogue of
+// FarmTx.Farm.
+func (tx *FarmTx) Pool(p *PoolInfo) *PoolInfo {
+	bound := *p
+	bound.tx = tx
+	return &bound
+}
+
+// User returns a copy of u bound to tx, the UserInfo analogue of
+// FarmTx.Farm.
+func (tx *FarmTx) User(u *UserInfo) *UserInfo {
+	bound := *u
+	bound.tx = tx
+	return &bound
+}
+
+func (tx *FarmTx) getState(address common.Address, slot common.Hash) common.Hash {
+	if v, ok := tx.overlay[farmTxSlotKey{address, slot}]; ok {
+		return v
+	}
+	return tx.state.GetState(address, slot)
+}
+
+func (tx *FarmTx) setState(address common.Address, slot common.Hash, value common.Hash) {
+	key := farmTxSlotKey{address, slot}
+	prev, had := tx.overlay[key]
+	tx.journal = append(tx.journal, farmTxJournalEntry{undo: func() {
+		if had {
+			tx.overlay[key] = prev
+		} else {
+			delete(tx.overlay, key)
+		}
+	}})
+	tx.overlay[key] = value
+}
+
+func (tx *FarmTx) getRawState(address common.Address, slot common.Hash) []byte {
+	if v, ok := tx.rawOverlay[farmTxSlotKey{address, slot}]; ok {
+		return v
+	}
+	return tx.state.GetRawState(address, slot)
+}
+
+func (tx *FarmTx) setRawState(address common.Address, slot common.Hash, data []byte) {
+	key := farmTxSlotKey{address, slot}
+	prev, had := tx.rawOverlay[key]
+	tx.journal = append(tx.journal, farmTxJournalEntry{undo: func() {
+		if had {
+			tx.rawOverlay[key] = prev
+		} else {
+			delete(tx.rawOverlay, key)
+		}
+	}})
+	tx.rawOverlay[key] = data
+}
+
+// Snapshot returns an identifier RevertToSnapshot can later roll back to,
+// matching the EVM's own StateDB.Snapshot/RevertToSnapshot semantics so a
+// caller looping over many pools or users can undo one iteration without
+// discarding the rest of the transaction.
+func (tx *FarmTx) Snapshot() int {
+	return len(tx.journal)
+}
+
+// RevertToSnapshot undoes every write made since the matching Snapshot
+// call, in reverse order.
+func (tx *FarmTx) RevertToSnapshot(id int) {
+	for i := len(tx.journal) - 1; i >= id; i-- {
+		tx.journal[i].undo()
+	}
+	tx.journal = tx.journal[:id]
+}
+
+// Commit flushes every buffered write through to the underlying StateDB in
+// deterministic slot order, then clears the overlay so tx can be reused for
+// a fresh batch of writes.
+func (tx *FarmTx) Commit() {
+	keys := make([]farmTxSlotKey, 0, len(tx.overlay))
+	for k := range tx.overlay {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].address != keys[j].address {
+			return keys[i].address.Hex() < keys[j].address.Hex()
+		}
+		return keys[i].slot.Hex() < keys[j].slot.Hex()
+	})
+	for _, k := range keys {
+		tx.state.SetState(k.address, k.slot, tx.overlay[k])
+	}
+
+	rawKeys := make([]farmTxSlotKey, 0, len(tx.rawOverlay))
+	for k := range tx.rawOverlay {
+		rawKeys = append(rawKeys, k)
+	}
+	sort.Slice(rawKeys, func(i, j int) bool {
+		if rawKeys[i].address != rawKeys[j].address {
+			return rawKeys[i].address.Hex() < rawKeys[j].address.Hex()
+		}
+		return rawKeys[i].slot.Hex() < rawKeys[j].slot.Hex()
+	})
+	for _, k := range rawKeys {
+		tx.state.SetRawState(k.address, k.slot, tx.rawOverlay[k])
+	}
+
+	tx.Revert()
+}
+
+// Revert drops every buffered write without touching the underlying
+// StateDB.
+func (tx *FarmTx) Revert() {
+	tx.overlay = make(map[farmTxSlotKey]common.Hash)
+	tx.rawOverlay = make(map[farmTxSlotKey][]byte)
+	tx.journal = nil
+}
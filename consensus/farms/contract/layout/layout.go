@@ -0,0 +1,123 @@
+// Package layout centralizes the Solidity storage-layout arithmetic that
+// consensus/farms/contract's structs previously reimplemented by hand in
+// every constructor and seeding helper: a mapping slot's keccak256(key .
+// baseSlot) derivation, a dynamic array's keccak256(lengthSlot) data slot,
+// and the field-offset arithmetic for a struct packed into consecutive
+// slots starting at some computed base. Expressing a struct's fields as a
+// Descriptor, rather than a column of big.NewInt(N) literals scattered
+// across NewPoolInfo/SeedPoolInfo/SeedCommunityPowerPolicy, means a
+// contract-layout change (reordering fields, inserting one) shows up as a
+// one-line edit to the Descriptor instead of a silent, easy-to-miss
+// renumbering at every call site that reads it.
+package layout
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"golang.org/x/crypto/sha3"
+	"math/big"
+)
+
+// Field names one slot within a Descriptor, at offset Slot from whatever
+// base slot the Descriptor is eventually resolved against.
+type Field struct {
+	Name string
+	Slot int64
+}
+
+// Descriptor is the declarative, source-order slot layout of one Solidity
+// struct - the same order and slot numbering `solc --storage-layout` would
+// report for it. Building one of these next to the struct it describes
+// turns "which big.NewInt(N) is which field" into a single readable table.
+type Descriptor struct {
+	Fields []Field
+}
+
+// Offset returns name's slot offset, panicking if name isn't part of the
+// layout: every caller asks for a field it already knows by name at
+// compile time, so a miss here is a programmer error in this package, not
+// bad on-chain data.
+func (d Descriptor) Offset(name string) int64 {
+	for _, f := range d.Fields {
+		if f.Name == name {
+			return f.Slot
+		}
+	}
+	panic(fmt.Sprintf("layout: descriptor has no field %q", name))
+}
+
+// Resolve binds d's offsets to a concrete base slot (typically one
+// computed via MappingSlot for a per-key struct like PoolInfo's), yielding
+// a Resolved that callers index by field name instead of repeating the
+// new(big.Int).Add(slotBig, big.NewInt(N)) arithmetic themselves.
+func (d Descriptor) Resolve(base common.Hash) Resolved {
+	return Resolved{descriptor: d, base: new(big.Int).SetBytes(base.Bytes())}
+}
+
+// Resolved is a Descriptor bound to a concrete base slot.
+type Resolved struct {
+	descriptor Descriptor
+	base       *big.Int
+}
+
+// Slot returns the concrete storage slot of the named field.
+func (r Resolved) Slot(name string) common.Hash {
+	offset := r.descriptor.Offset(name)
+	return common.BigToHash(new(big.Int).Add(r.base, big.NewInt(offset)))
+}
+
+// MappingSlot computes the storage slot of mapping[key] for a mapping
+// declared at mappingSlot, the standard Solidity
+// keccak256(key . mappingSlot) scheme this package's callers previously
+// reimplemented with their own sha3.NewLegacyKeccak256/Write/Sum sequence.
+func MappingSlot(key common.Hash, mappingSlot common.Hash) common.Hash {
+	harsher := sha3.NewLegacyKeccak256()
+	harsher.Write(common.LeftPadBytes(key.Bytes(), 32))
+	harsher.Write(common.LeftPadBytes(mappingSlot.Bytes(), 32))
+	var out common.Hash
+	harsher.Sum(out[:0])
+	return out
+}
+
+// NestedMappingSlot computes the slot of outer[outerKey][innerKey] for a
+// mapping(K1 => mapping(K2 => V)) declared at mappingSlot - the two-step
+// version of MappingSlot that GetCommunityAccRewardPerShare and
+// GetParentLastUpdateBlock each need for their pool/account-then-token
+// keyed storage.
+func NestedMappingSlot(outerKey common.Hash, mappingSlot common.Hash, innerKey common.Hash) common.Hash {
+	return MappingSlot(innerKey, MappingSlot(outerKey, mappingSlot))
+}
+
+// ArrayDataSlot computes a dynamic array's first-element slot for an array
+// whose length lives at lengthSlot, the standard Solidity
+// keccak256(lengthSlot) scheme utils.GetHashArrayState already uses inline;
+// exported here so layout-driven callers can derive it without importing
+// consensus/farms/utils just for this one piece of arithmetic.
+func ArrayDataSlot(lengthSlot common.Hash) common.Hash {
+	harsher := sha3.NewLegacyKeccak256()
+	harsher.Write(lengthSlot.Bytes())
+	var out common.Hash
+	harsher.Sum(out[:0])
+	return out
+}
+
+// CheckVersion reads the uint256 stored at versionSlot on address and
+// returns an error if it's both nonzero and different from expected,
+// refusing to let a caller decode a contract whose storage layout has
+// moved on without a matching Go-side update. A zero on-chain value is
+// treated as "unversioned" rather than a mismatch, the same legacy-curve
+// convention PoolInfo.GetCommunityPowerPolicySelector already uses for a
+// zero selector, since contracts deployed before this versioning scheme
+// existed never wrote anything to versionSlot.
+func CheckVersion(state *state.StateDB, address common.Address, versionSlot common.Hash, expected uint64) error {
+	got := new(big.Int).SetBytes(state.GetState(address, versionSlot).Bytes()).Uint64()
+	if got == 0 {
+		return nil
+	}
+	if got != expected {
+		return fmt.Errorf("layout: %s storage version %d does not match expected %d", address, got, expected)
+	}
+	return nil
+}
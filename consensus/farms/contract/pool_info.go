@@ -2,19 +2,81 @@ package contract
 
 import (
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/farms/contract/layout"
 	"github.com/ethereum/go-ethereum/consensus/farms/utils"
 	"github.com/ethereum/go-ethereum/core/state"
-	"golang.org/x/crypto/sha3"
 	"math/big"
 )
 
 const (
 	FarmMemberSlotPoolOf = 3
+
+	// FarmMemberSlotLayoutVersion is the storage slot at which the farm
+	// contract records its own storage-layout version; CheckContractVersion
+	// reads it. Distinct from any PoolInfo field slot - it's a property of
+	// the farm contract as a whole, not of any one pool's struct.
+	FarmMemberSlotLayoutVersion = 0
+
+	// PoolInfoLayoutVersion is the storage-layout version poolInfoLayout
+	// below was written against. Bump it, alongside poolInfoLayout, whenever
+	// the pool struct's on-chain field order or count changes.
+	PoolInfoLayoutVersion = 1
 )
 
+// poolInfoLayout is the declarative slot layout of a farm contract's
+// per-pool struct, in the same field order the Solidity source declares
+// them. NewPoolInfo/SeedPoolInfo/SeedCommunityPowerPolicy all resolve their
+// slots against this one table instead of each repeating their own column
+// of big.NewInt(N) literals, so a contract upgrade that reorders or inserts
+// a field is a one-line edit here rather than a silent renumbering at every
+// call site.
+var poolInfoLayout = layout.Descriptor{
+	Fields: []layout.Field{
+		{Name: "token", Slot: 0},
+		{Name: "holderRangeCount", Slot: 1},
+		{Name: "holderRangeInterval", Slot: 2},
+		{Name: "holderTotalPower", Slot: 3},
+		{Name: "communityTotalPower", Slot: 4},
+		{Name: "rewardTokens", Slot: 5},
+		{Name: "rewardTokensLocker", Slot: 6},
+		{Name: "rewardStartRangeIndex", Slot: 8},
+		{Name: "communityPowerPolicy", Slot: 9},
+		{Name: "communityPowerActivation", Slot: 10},
+		{Name: "communityPowerPolicyParams", Slot: 11},
+	},
+}
+
+// CheckContractVersion verifies farmAddress's recorded storage-layout
+// version, at FarmMemberSlotLayoutVersion, matches PoolInfoLayoutVersion.
+// It's opt-in rather than called from NewPoolInfo itself: contracts
+// deployed before this versioning scheme existed (including every fixture
+// SeedPoolInfo/SeedCommunityPowerPolicy set up for tests) never wrote
+// anything to that slot, and CheckVersion's zero-means-unversioned
+// convention already lets those pass - but a caller that deploys fresh
+// contracts and wants a hard guarantee the Go and Solidity layouts still
+// agree can call this before trusting any PoolInfo built against the
+// address.
+func CheckContractVersion(state *state.StateDB, farmAddress common.Address) error {
+	return layout.CheckVersion(state, farmAddress, common.IntToSlot(FarmMemberSlotLayoutVersion), PoolInfoLayoutVersion)
+}
+
 type PoolInfo struct {
 	state       *state.StateDB
 	farmAddress common.Address
+	poolAddress common.Address
+
+	// tx is non-nil when this PoolInfo was produced by FarmTx.Pool, in which
+	// case GetHolderTotalPower/SetHolderTotalPower and
+	// GetCommunityTotalPower/SetCommunityTotalPower shadow through its
+	// overlay/journal instead of going straight to state - see
+	// FarmContract's tx field for the same pattern.
+	tx *FarmTx
+
+	// bus is nil unless SetEventBus has been called, in which case
+	// SetHolderTotalPower/SetCommunityTotalPower additionally emit a
+	// PowerChange on it - the same opt-in, nil-by-default convention
+	// Farm/PoolDistribution's tracer field already uses.
+	bus *PowerEventBus
 
 	token               common.Address
 	holderRangeCount    *big.Int
@@ -26,27 +88,35 @@ type PoolInfo struct {
 	rewardTokensArraySlot       common.Hash
 	rewardTokensLockerArraySlot common.Hash
 
+	// rewardTokensCache/rewardLocksCache are set by PoolInfoCache.Get/build
+	// when this PoolInfo came from a cache hit, so GetRewardTokens/
+	// GetRewardLocks can skip the dynamic-array walk through state entirely;
+	// nil for a PoolInfo built directly via NewPoolInfo, in which case those
+	// methods fall back to reading state as before.
+	rewardTokensCache []common.Address
+	rewardLocksCache  []common.Address
+
 	rewardStartRangeIndex *big.Int
+
+	communityPowerPolicySlot       common.Hash
+	communityPowerActivationSlot   common.Hash
+	communityPowerPolicyParamsSlot common.Hash
 }
 
 func NewPoolInfo(state *state.StateDB, farmAddress, poolAddress common.Address) *PoolInfo {
-	var poolInfoSlot common.Hash
-	harsher := sha3.NewLegacyKeccak256()
-	harsher.Write(common.LeftPadBytes(poolAddress.Bytes(), 32))
-	harsher.Write(common.LeftPadBytes(common.IntToSlot(FarmMemberSlotPoolOf).Bytes(), 32))
-	harsher.Sum(poolInfoSlot[:0])
-	harsher.Reset()
-
-	// Struct Slots
-	slotBig := new(big.Int).SetBytes(poolInfoSlot.Bytes())
-	tokenSlot := common.BigToHash(new(big.Int).Add(slotBig, big.NewInt(0)))
-	holderRangeCountSlot := common.BigToHash(new(big.Int).Add(slotBig, big.NewInt(1)))
-	holderRangeIntervalSlot := common.BigToHash(new(big.Int).Add(slotBig, big.NewInt(2)))
-	holderTotalPowerSlot := common.BigToHash(new(big.Int).Add(slotBig, big.NewInt(3)))
-	communityTotalPowerSlot := common.BigToHash(new(big.Int).Add(slotBig, big.NewInt(4)))
-	rewardTokensSlot := common.BigToHash(new(big.Int).Add(slotBig, big.NewInt(5)))
-	rewardLockersSlot := common.BigToHash(new(big.Int).Add(slotBig, big.NewInt(6)))
-	rewardStartRangeIndexSlot := common.BigToHash(new(big.Int).Add(slotBig, big.NewInt(8)))
+	resolved := poolInfoLayout.Resolve(layout.MappingSlot(common.BytesToHash(poolAddress.Bytes()), common.IntToSlot(FarmMemberSlotPoolOf)))
+
+	tokenSlot := resolved.Slot("token")
+	holderRangeCountSlot := resolved.Slot("holderRangeCount")
+	holderRangeIntervalSlot := resolved.Slot("holderRangeInterval")
+	holderTotalPowerSlot := resolved.Slot("holderTotalPower")
+	communityTotalPowerSlot := resolved.Slot("communityTotalPower")
+	rewardTokensSlot := resolved.Slot("rewardTokens")
+	rewardLockersSlot := resolved.Slot("rewardTokensLocker")
+	rewardStartRangeIndexSlot := resolved.Slot("rewardStartRangeIndex")
+	communityPowerPolicySlot := resolved.Slot("communityPowerPolicy")
+	communityPowerActivationSlot := resolved.Slot("communityPowerActivation")
+	communityPowerPolicyParamsSlot := resolved.Slot("communityPowerPolicyParams")
 
 	token := state.GetState(farmAddress, tokenSlot)
 	holderRangeCount := state.GetState(farmAddress, holderRangeCountSlot)
@@ -54,16 +124,20 @@ func NewPoolInfo(state *state.StateDB, farmAddress, poolAddress common.Address)
 	rewardStartRangeIndex := state.GetState(farmAddress, rewardStartRangeIndexSlot)
 
 	return &PoolInfo{
-		state:                       state,
-		farmAddress:                 farmAddress,
-		token:                       common.BytesToAddress(token.Bytes()),
-		holderRangeCount:            new(big.Int).SetBytes(holderRangeCount.Bytes()),
-		holderRangeInterval:         new(big.Int).SetBytes(holderRangeInterval.Bytes()),
-		holderTotalPowerSlot:        holderTotalPowerSlot,
-		communityTotalPowerSlot:     communityTotalPowerSlot,
-		rewardTokensArraySlot:       rewardTokensSlot,
-		rewardTokensLockerArraySlot: rewardLockersSlot,
-		rewardStartRangeIndex:       new(big.Int).SetBytes(rewardStartRangeIndex.Bytes()),
+		state:                          state,
+		farmAddress:                    farmAddress,
+		poolAddress:                    poolAddress,
+		token:                          common.BytesToAddress(token.Bytes()),
+		holderRangeCount:               new(big.Int).SetBytes(holderRangeCount.Bytes()),
+		holderRangeInterval:            new(big.Int).SetBytes(holderRangeInterval.Bytes()),
+		holderTotalPowerSlot:           holderTotalPowerSlot,
+		communityTotalPowerSlot:        communityTotalPowerSlot,
+		rewardTokensArraySlot:          rewardTokensSlot,
+		rewardTokensLockerArraySlot:    rewardLockersSlot,
+		rewardStartRangeIndex:          new(big.Int).SetBytes(rewardStartRangeIndex.Bytes()),
+		communityPowerPolicySlot:       communityPowerPolicySlot,
+		communityPowerActivationSlot:   communityPowerActivationSlot,
+		communityPowerPolicyParamsSlot: communityPowerPolicyParamsSlot,
 	}
 }
 
@@ -82,14 +156,19 @@ func (p *PoolInfo) GetRangeInterval() *big.Int {
 func (p *PoolInfo) GetRewardStartRangeIndex() *big.Int { return p.rewardStartRangeIndex }
 
 func (p *PoolInfo) GetHolderTotalPower() *big.Int {
-	return new(big.Int).SetBytes(p.state.GetState(p.farmAddress, p.holderTotalPowerSlot).Bytes())
+	return new(big.Int).SetBytes(p.getState(p.holderTotalPowerSlot).Bytes())
 }
 
 func (p *PoolInfo) GetCommunityTotalPower() *big.Int {
-	return new(big.Int).SetBytes(p.state.GetState(p.farmAddress, p.communityTotalPowerSlot).Bytes())
+	return new(big.Int).SetBytes(p.getState(p.communityTotalPowerSlot).Bytes())
 }
 
+// GetRewardTokens returns p.rewardTokensCache directly when p came from a
+// PoolInfoCache hit, skipping the dynamic-array walk through state below.
 func (p *PoolInfo) GetRewardTokens() []common.Address {
+	if p.rewardTokensCache != nil {
+		return p.rewardTokensCache
+	}
 	hashArray := utils.GetHashArrayState(p.state, p.farmAddress, p.rewardTokensArraySlot)
 	ret := make([]common.Address, len(*hashArray))
 	for i := 0; i < len(ret); i++ {
@@ -98,7 +177,12 @@ func (p *PoolInfo) GetRewardTokens() []common.Address {
 	return ret
 }
 
+// GetRewardLocks is GetRewardTokens' locker-array counterpart; see its
+// comment for the cache-hit shortcut.
 func (p *PoolInfo) GetRewardLocks() []common.Address {
+	if p.rewardLocksCache != nil {
+		return p.rewardLocksCache
+	}
 	hashArray := utils.GetHashArrayState(p.state, p.farmAddress, p.rewardTokensLockerArraySlot)
 	ret := make([]common.Address, len(*hashArray))
 	for i := 0; i < len(ret); i++ {
@@ -107,10 +191,135 @@ func (p *PoolInfo) GetRewardLocks() []common.Address {
 	return ret
 }
 
+// GetCommunityPowerPolicySelector returns the governance-selected
+// CommunityPowerPolicy for this pool (see the CommunityPowerPolicy*
+// constants in consensus/farms); 0 means the legacy hard-coded curve.
+func (p *PoolInfo) GetCommunityPowerPolicySelector() uint8 {
+	return p.state.GetState(p.farmAddress, p.communityPowerPolicySlot).Bytes()[31]
+}
+
+// GetCommunityPowerActivationHeight returns the block number at which
+// GetCommunityPowerPolicySelector takes effect; zero means it has never
+// been configured, so the legacy curve applies unconditionally.
+func (p *PoolInfo) GetCommunityPowerActivationHeight() *big.Int {
+	return new(big.Int).SetBytes(p.state.GetState(p.farmAddress, p.communityPowerActivationSlot).Bytes())
+}
+
+// GetCommunityPowerPolicyParams returns the policy's parameter vector, read
+// the same way GetRewardTokens reads its dynamic array.
+func (p *PoolInfo) GetCommunityPowerPolicyParams() []*big.Int {
+	hashArray := utils.GetHashArrayState(p.state, p.farmAddress, p.communityPowerPolicyParamsSlot)
+	ret := make([]*big.Int, len(*hashArray))
+	for i := 0; i < len(ret); i++ {
+		ret[i] = new(big.Int).SetBytes((*hashArray)[i].Bytes())
+	}
+	return ret
+}
+
+// SetHolderTotalPower stages power into the journaled overlay when p was
+// produced by FarmTx.Pool, so a mid-block failure elsewhere in the
+// consensus/farms pipeline can RevertToSnapshot this write independently of
+// the surrounding EVM snapshot; otherwise it writes straight to state as
+// before.
 func (p *PoolInfo) SetHolderTotalPower(power *big.Int) {
-	p.state.SetState(p.farmAddress, p.holderTotalPowerSlot, common.BigToHash(power))
+	old := p.GetHolderTotalPower()
+	p.setState(p.holderTotalPowerSlot, common.BigToHash(power))
+	p.emitPowerChange(HolderPower, old, power)
 }
 
+// SetCommunityTotalPower is SetHolderTotalPower's community-power
+// counterpart; see its comment for the journaling behavior.
 func (p *PoolInfo) SetCommunityTotalPower(power *big.Int) {
-	p.state.SetState(p.farmAddress, p.communityTotalPowerSlot, common.BigToHash(power))
+	old := p.GetCommunityTotalPower()
+	p.setState(p.communityTotalPowerSlot, common.BigToHash(power))
+	p.emitPowerChange(CommunityPower, old, power)
+}
+
+// SetEventBus configures p to emit a PowerChange on bus whenever
+// SetHolderTotalPower/SetCommunityTotalPower is called; leaving it unset
+// (the default) makes emission a no-op, matching the nil-by-default
+// convention Farm/PoolDistribution's tracer field already uses.
+func (p *PoolInfo) SetEventBus(bus *PowerEventBus) {
+	p.bus = bus
+}
+
+// emitPowerChange reports a power update on p.bus, if one has been
+// configured via SetEventBus; a PoolInfo built without ever calling
+// SetEventBus pays nothing beyond this nil check.
+func (p *PoolInfo) emitPowerChange(kind PowerChangeKind, old, updated *big.Int) {
+	if p.bus == nil {
+		return
+	}
+	p.bus.emit(PowerChange{
+		Farm: p.farmAddress,
+		Pool: p.poolAddress,
+		Kind: kind,
+		Old:  old,
+		New:  updated,
+	})
+}
+
+// getState/setState route GetHolderTotalPower/SetHolderTotalPower and
+// GetCommunityTotalPower/SetCommunityTotalPower through p.tx's overlay when p
+// was produced by FarmTx.Pool, and straight to state otherwise - the same
+// pattern FarmContract.getState/setState and UserInfo.getState/setState
+// already use.
+func (p *PoolInfo) getState(slot common.Hash) common.Hash {
+	if p.tx != nil {
+		return p.tx.getState(p.farmAddress, slot)
+	}
+	return p.state.GetState(p.farmAddress, slot)
+}
+
+func (p *PoolInfo) setState(slot common.Hash, value common.Hash) {
+	if p.tx != nil {
+		p.tx.setState(p.farmAddress, slot, value)
+		return
+	}
+	p.state.SetState(p.farmAddress, slot, value)
+}
+
+// SeedPoolInfo writes a pool's config directly into the slots NewPoolInfo
+// reads from, for callers standing up a PoolInfo from scratch without going
+// through the farm contract's admin functions (e.g. the testvectors
+// conformance harness).
+func SeedPoolInfo(state *state.StateDB, farmAddress, poolAddress, token common.Address, rangeCount, rangeInterval *big.Int, rewardTokens []common.Address, startRangeIndex *big.Int) {
+	resolved := poolInfoLayout.Resolve(layout.MappingSlot(common.BytesToHash(poolAddress.Bytes()), common.IntToSlot(FarmMemberSlotPoolOf)))
+	tokenSlot := resolved.Slot("token")
+	holderRangeCountSlot := resolved.Slot("holderRangeCount")
+	holderRangeIntervalSlot := resolved.Slot("holderRangeInterval")
+	rewardTokensSlot := resolved.Slot("rewardTokens")
+	rewardStartRangeIndexSlot := resolved.Slot("rewardStartRangeIndex")
+
+	state.SetState(farmAddress, tokenSlot, common.BytesToHash(token.Bytes()))
+	state.SetState(farmAddress, holderRangeCountSlot, common.BigToHash(rangeCount))
+	state.SetState(farmAddress, holderRangeIntervalSlot, common.BigToHash(rangeInterval))
+	state.SetState(farmAddress, rewardStartRangeIndexSlot, common.BigToHash(startRangeIndex))
+
+	state.SetState(farmAddress, rewardTokensSlot, common.BigToHash(big.NewInt(int64(len(rewardTokens)))))
+	arraySlotBig := new(big.Int).SetBytes(layout.ArrayDataSlot(rewardTokensSlot).Bytes())
+	for i, rewardToken := range rewardTokens {
+		state.SetState(farmAddress, common.BigToHash(new(big.Int).Add(arraySlotBig, big.NewInt(int64(i)))), common.BytesToHash(rewardToken.Bytes()))
+	}
+}
+
+// SeedCommunityPowerPolicy writes a pool's community-power policy selector,
+// activation height and parameter vector directly into the slots
+// GetCommunityPowerPolicySelector/GetCommunityPowerActivationHeight/
+// GetCommunityPowerPolicyParams read from, for the same from-scratch-state
+// callers SeedPoolInfo serves.
+func SeedCommunityPowerPolicy(state *state.StateDB, farmAddress, poolAddress common.Address, selector uint8, activationHeight *big.Int, params []*big.Int) {
+	resolved := poolInfoLayout.Resolve(layout.MappingSlot(common.BytesToHash(poolAddress.Bytes()), common.IntToSlot(FarmMemberSlotPoolOf)))
+	policySlot := resolved.Slot("communityPowerPolicy")
+	activationSlot := resolved.Slot("communityPowerActivation")
+	paramsSlot := resolved.Slot("communityPowerPolicyParams")
+
+	state.SetState(farmAddress, policySlot, common.BigToHash(big.NewInt(int64(selector))))
+	state.SetState(farmAddress, activationSlot, common.BigToHash(activationHeight))
+
+	state.SetState(farmAddress, paramsSlot, common.BigToHash(big.NewInt(int64(len(params)))))
+	arraySlotBig := new(big.Int).SetBytes(layout.ArrayDataSlot(paramsSlot).Bytes())
+	for i, param := range params {
+		state.SetState(farmAddress, common.BigToHash(new(big.Int).Add(arraySlotBig, big.NewInt(int64(i)))), common.BigToHash(param))
+	}
 }
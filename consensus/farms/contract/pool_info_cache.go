@@ -0,0 +1,135 @@
+package contract
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/farms/contract/layout"
+	"github.com/ethereum/go-ethereum/core/state"
+	"math/big"
+)
+
+// poolInfoCacheKey identifies one pool's immutable fields as of a
+// particular state root; keying on stateRoot rather than just
+// (farmAddress, poolAddress) means a PoolInfoCache reused across blocks
+// (instead of one per block) never serves stale data after a reorg or the
+// next block's state transition - it just grows an extra generation of
+// entries, which is why Get/Prefetch are meant to be used against a
+// PoolInfoCache scoped to a single state root's lifetime, as the doc
+// comment on PoolInfoCache describes.
+type poolInfoCacheKey struct {
+	farmAddress common.Address
+	poolAddress common.Address
+	stateRoot   common.Hash
+}
+
+// cachedPoolFields holds the PoolInfo fields that can't change without
+// also changing the state root they were read at: the pool's configured
+// token, holder-range parameters, reward start index, and reward
+// token/locker arrays. holderTotalPowerSlot/communityTotalPowerSlot and the
+// other *Slot fields aren't cached here because they're cheap,
+// state-read-free slot arithmetic (see poolInfoLayout) rather than values
+// read from a trie.
+type cachedPoolFields struct {
+	token                 common.Address
+	holderRangeCount      *big.Int
+	holderRangeInterval   *big.Int
+	rewardStartRangeIndex *big.Int
+	rewardTokens          []common.Address
+	rewardLocks           []common.Address
+}
+
+// PoolInfoCache memoizes the immutable-for-a-state-root fields NewPoolInfo
+// would otherwise re-read from state on every call - the token address,
+// holder-range parameters, reward start index, and reward token/locker
+// arrays - so a block touching many pools (or the same pool repeatedly)
+// doesn't pay an O(pools) column of StateDB.GetState calls and dynamic
+// -array walks for data that can't have changed since the cache was built.
+// A PoolInfoCache is meant to be scoped to one state root's lifetime (e.g.
+// one per block being processed); it does not invalidate entries from a
+// prior root on its own.
+type PoolInfoCache struct {
+	state *state.StateDB
+
+	mu      sync.RWMutex
+	entries map[poolInfoCacheKey]*cachedPoolFields
+}
+
+// NewPoolInfoCache returns a PoolInfoCache backed by state.
+func NewPoolInfoCache(state *state.StateDB) *PoolInfoCache {
+	return &PoolInfoCache{
+		state:   state,
+		entries: make(map[poolInfoCacheKey]*cachedPoolFields),
+	}
+}
+
+// Get returns poolAddress's PoolInfo under farmAddress, populating c's
+// cache on a miss and reusing it on a hit - the caller can't tell the
+// difference except in how many StateDB reads it cost.
+func (c *PoolInfoCache) Get(farmAddress, poolAddress common.Address) *PoolInfo {
+	key := poolInfoCacheKey{farmAddress, poolAddress, c.state.IntermediateRoot(false)}
+
+	c.mu.RLock()
+	cached, ok := c.entries[key]
+	c.mu.RUnlock()
+	if ok {
+		return c.build(farmAddress, poolAddress, cached)
+	}
+
+	info := NewPoolInfo(c.state, farmAddress, poolAddress)
+	cached = &cachedPoolFields{
+		token:                 info.token,
+		holderRangeCount:      info.holderRangeCount,
+		holderRangeInterval:   info.holderRangeInterval,
+		rewardStartRangeIndex: info.rewardStartRangeIndex,
+		rewardTokens:          info.GetRewardTokens(),
+		rewardLocks:           info.GetRewardLocks(),
+	}
+
+	c.mu.Lock()
+	c.entries[key] = cached
+	c.mu.Unlock()
+
+	info.rewardTokensCache = cached.rewardTokens
+	info.rewardLocksCache = cached.rewardLocks
+	return info
+}
+
+// Prefetch warms c's cache for every pool in pools under farmAddress.
+// Each pool still costs its own column of StateDB reads - this tree's
+// StateDB exposes no batched-read primitive for Prefetch to issue a single
+// round trip through - but doing that work once up front, in the order the
+// caller already knows it needs, means every later Get for the same pool
+// and state root is a cache hit instead of repeating it.
+func (c *PoolInfoCache) Prefetch(farmAddress common.Address, pools []common.Address) {
+	for _, pool := range pools {
+		c.Get(farmAddress, pool)
+	}
+}
+
+// build reconstructs a PoolInfo from cached, recomputing its storage slots
+// (cheap, state-read-free arithmetic) rather than caching them, since only
+// the values read through those slots - not the slots themselves - are
+// invalidated by a state-root change.
+func (c *PoolInfoCache) build(farmAddress, poolAddress common.Address, cached *cachedPoolFields) *PoolInfo {
+	resolved := poolInfoLayout.Resolve(layout.MappingSlot(common.BytesToHash(poolAddress.Bytes()), common.IntToSlot(FarmMemberSlotPoolOf)))
+
+	return &PoolInfo{
+		state:                          c.state,
+		farmAddress:                    farmAddress,
+		poolAddress:                    poolAddress,
+		token:                          cached.token,
+		holderRangeCount:               cached.holderRangeCount,
+		holderRangeInterval:            cached.holderRangeInterval,
+		holderTotalPowerSlot:           resolved.Slot("holderTotalPower"),
+		communityTotalPowerSlot:        resolved.Slot("communityTotalPower"),
+		rewardTokensArraySlot:          resolved.Slot("rewardTokens"),
+		rewardTokensLockerArraySlot:    resolved.Slot("rewardTokensLocker"),
+		rewardStartRangeIndex:          cached.rewardStartRangeIndex,
+		communityPowerPolicySlot:       resolved.Slot("communityPowerPolicy"),
+		communityPowerActivationSlot:   resolved.Slot("communityPowerActivation"),
+		communityPowerPolicyParamsSlot: resolved.Slot("communityPowerPolicyParams"),
+		rewardTokensCache:              cached.rewardTokens,
+		rewardLocksCache:               cached.rewardLocks,
+	}
+}
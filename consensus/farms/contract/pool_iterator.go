@@ -0,0 +1,101 @@
+package contract
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/crypto/sha3"
+)
+
+// defaultIteratorPrefetch is how many storage slots PoolIterator reads
+// ahead of the caller's current position in one batch, trading a little
+// extra StateDB.GetState traffic for fewer round trips through a large
+// pool list.
+const defaultIteratorPrefetch = 32
+
+// PoolIterator walks FarmContract's pools array one storage slot at a time
+// instead of materializing the whole array via utils.GetHashArrayState, so
+// a validator with thousands of farm pools doesn't pay O(N) memory and trie
+// reads for every GetPools call - only callers that actually want every
+// pool do, and everyone else can page through with Seek.
+type PoolIterator struct {
+	fc   *FarmContract
+	base *big.Int // base storage slot of the array's backing region, keccak256(startSlot)
+	len  int
+
+	prefetch int
+	pos      int
+	buf      []common.Address
+	bufFrom  int
+}
+
+// PoolsIterator returns a PoolIterator over fc's pools array.
+func (fc *FarmContract) PoolsIterator() *PoolIterator {
+	startSlot := common.IntToSlot(FarmMemberSlotPools)
+	length := int(common.StateToBig(fc.state.GetState(fc.address, startSlot)).Int64())
+
+	var arraySlot common.Hash
+	harsher := sha3.NewLegacyKeccak256()
+	harsher.Write(startSlot.Bytes())
+	harsher.Sum(arraySlot[:0])
+
+	return &PoolIterator{
+		fc:       fc,
+		base:     new(big.Int).SetBytes(arraySlot.Bytes()),
+		len:      length,
+		prefetch: defaultIteratorPrefetch,
+		pos:      -1,
+	}
+}
+
+// SetPrefetch overrides how many slots are read ahead on each underlying
+// fetch; it must be called before the first Next().
+func (it *PoolIterator) SetPrefetch(n int) {
+	if n > 0 {
+		it.prefetch = n
+	}
+}
+
+// Len returns the pools array's length, reading only the length slot - not
+// one element of it - so callers sizing an accumulator don't have to
+// iterate the whole array first.
+func (it *PoolIterator) Len() int {
+	return it.len
+}
+
+// Seek repositions the iterator so the next Next() call returns the
+// element at offset.
+func (it *PoolIterator) Seek(offset int) {
+	it.pos = offset - 1
+}
+
+// Next advances the iterator and reports whether a Value is available.
+func (it *PoolIterator) Next() bool {
+	if it.pos+1 >= it.len {
+		return false
+	}
+	it.pos++
+	if it.buf == nil || it.pos < it.bufFrom || it.pos >= it.bufFrom+len(it.buf) {
+		it.fill(it.pos)
+	}
+	return true
+}
+
+func (it *PoolIterator) fill(from int) {
+	n := it.prefetch
+	if from+n > it.len {
+		n = it.len - from
+	}
+	buf := make([]common.Address, n)
+	for i := 0; i < n; i++ {
+		slot := common.BigToHash(new(big.Int).Add(it.base, big.NewInt(int64(from+i))))
+		buf[i] = common.HashToAddress(it.fc.state.GetState(it.fc.address, slot))
+	}
+	it.buf = buf
+	it.bufFrom = from
+}
+
+// Value returns the pool address at the iterator's current position.
+func (it *PoolIterator) Value() common.Address {
+	return it.buf[it.pos-it.bufFrom]
+}
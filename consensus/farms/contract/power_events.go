@@ -0,0 +1,130 @@
+package contract
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// PowerChangeKind distinguishes which of PoolInfo's two power totals a
+// PowerChange reports on.
+type PowerChangeKind uint8
+
+const (
+	HolderPower PowerChangeKind = iota
+	CommunityPower
+)
+
+func (k PowerChangeKind) String() string {
+	switch k {
+	case HolderPower:
+		return "holder"
+	case CommunityPower:
+		return "community"
+	default:
+		return "unknown"
+	}
+}
+
+// PowerChange records one SetHolderTotalPower/SetCommunityTotalPower call,
+// so an indexer, RPC subscriber, or the block explorer can observe farm
+// power evolution without diffing full state tries. BlockNumber is
+// whatever PowerEventBus.SetBlockNumber was last called with - PoolInfo has
+// no notion of the current block itself, so it's the emitting side's
+// responsibility to keep it current across a block's processing.
+type PowerChange struct {
+	Farm        common.Address
+	Pool        common.Address
+	Kind        PowerChangeKind
+	Old         *big.Int
+	New         *big.Int
+	BlockNumber *big.Int
+}
+
+// PowerEventBus is the opt-in event bus PoolInfo.SetEventBus wires a
+// PoolInfo into: SetHolderTotalPower/SetCommunityTotalPower report through
+// it instead of silently overwriting storage. A bus with emission disabled
+// (the default) costs its callers nothing beyond the disabled check itself.
+type PowerEventBus struct {
+	feed event.Feed
+
+	mu          sync.Mutex
+	enabled     bool
+	blockNumber *big.Int
+
+	collecting bool
+	pending    []PowerChange
+}
+
+// NewPowerEventBus returns a PowerEventBus with emission disabled; call
+// Enable(true) to turn it on.
+func NewPowerEventBus() *PowerEventBus {
+	return &PowerEventBus{}
+}
+
+// Enable turns PowerChange emission on or off - the farm-level config flag
+// the consuming Farm exposes as EnablePowerChangeEvents.
+func (b *PowerEventBus) Enable(enabled bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.enabled = enabled
+}
+
+// SetBlockNumber records the block number b.emit stamps onto every
+// PowerChange it reports until the next call; the consuming Farm calls this
+// once per block, before any PoolInfo mutation that block can trigger.
+func (b *PowerEventBus) SetBlockNumber(number *big.Int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.blockNumber = number
+}
+
+// SetCollecting turns pending-change collection on or off; while on, every
+// emitted PowerChange is also appended to the list Drain returns, which is
+// how a caller assembles synthetic EVM logs for a block without subscribing
+// a channel.
+func (b *PowerEventBus) SetCollecting(collecting bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.collecting = collecting
+	if !collecting {
+		b.pending = nil
+	}
+}
+
+// Drain returns every PowerChange collected since the last Drain (or since
+// SetCollecting(true), whichever is more recent) and resets the pending
+// list.
+func (b *PowerEventBus) Drain() []PowerChange {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	pending := b.pending
+	b.pending = nil
+	return pending
+}
+
+// Subscribe registers ch to receive every PowerChange b reports while
+// enabled.
+func (b *PowerEventBus) Subscribe(ch chan<- PowerChange) event.Subscription {
+	return b.feed.Subscribe(ch)
+}
+
+// emit reports change on the feed and, if collecting, appends it to the
+// pending list - a no-op beyond the enabled check if Enable(true) was never
+// called.
+func (b *PowerEventBus) emit(change PowerChange) {
+	b.mu.Lock()
+	if !b.enabled {
+		b.mu.Unlock()
+		return
+	}
+	change.BlockNumber = b.blockNumber
+	if b.collecting {
+		b.pending = append(b.pending, change)
+	}
+	b.mu.Unlock()
+
+	b.feed.Send(change)
+}
@@ -0,0 +1,172 @@
+package contract
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/systemcontracts/anchor"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// storageProofResult mirrors the per-slot entry of the standard
+// eth_getProof JSON-RPC response.
+type storageProofResult struct {
+	Key   string          `json:"key"`
+	Value *hexutil.Big    `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+// accountProofResult mirrors the eth_getProof JSON-RPC response shape.
+type accountProofResult struct {
+	Address      common.Address       `json:"address"`
+	AccountProof []hexutil.Bytes      `json:"accountProof"`
+	Balance      *hexutil.Big         `json:"balance"`
+	CodeHash     common.Hash          `json:"codeHash"`
+	Nonce        hexutil.Uint64       `json:"nonce"`
+	StorageHash  common.Hash          `json:"storageHash"`
+	StorageProof []storageProofResult `json:"storageProof"`
+}
+
+// rlpAccount is the state trie leaf value an account resolves to.
+type rlpAccount struct {
+	Nonce    uint64
+	Balance  *big.Int
+	Root     common.Hash
+	CodeHash []byte
+}
+
+// proofNodeSet adapts a flat list of RLP-encoded trie nodes into the
+// ethdb.KeyValueReader shape trie.VerifyProof expects, keyed by keccak256.
+type proofNodeSet [][]byte
+
+func (s proofNodeSet) Has(key []byte) (bool, error) { return s.get(key) != nil, nil }
+
+func (s proofNodeSet) Get(key []byte) ([]byte, error) {
+	if v := s.get(key); v != nil {
+		return v, nil
+	}
+	return nil, fmt.Errorf("proof node for key %x not found", key)
+}
+
+func (s proofNodeSet) get(key []byte) []byte {
+	for _, n := range s {
+		if common.BytesToHash(crypto.Keccak256(n)) == common.BytesToHash(key) {
+			return n
+		}
+	}
+	return nil
+}
+
+// fetchProof issues a single eth_getProof call covering account and every
+// key in slots against blockNumber.
+func fetchProof(ctx context.Context, rpcClient *rpc.Client, account common.Address, slots []common.Hash, blockNumber *big.Int) (*accountProofResult, error) {
+	keys := make([]string, len(slots))
+	for i, s := range slots {
+		keys[i] = s.Hex()
+	}
+	blockTag := "latest"
+	if blockNumber != nil {
+		blockTag = hexutil.EncodeBig(blockNumber)
+	}
+
+	var result accountProofResult
+	if err := rpcClient.CallContext(ctx, &result, "eth_getProof", account, keys, blockTag); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// verifyAccountProof checks proof.AccountProof against stateRoot and, on
+// success, returns the account's verified storage root.
+func verifyAccountProof(stateRoot common.Hash, proof *accountProofResult) (common.Hash, error) {
+	nodes := make(proofNodeSet, len(proof.AccountProof))
+	for i, n := range proof.AccountProof {
+		nodes[i] = n
+	}
+
+	key := crypto.Keccak256(proof.Address.Bytes())
+	leaf, err := trie.VerifyProof(stateRoot, key, nodes)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("address tree proof: account proof verification failed for %s: %w", proof.Address, err)
+	}
+	if leaf == nil {
+		// Account does not exist at this state root; treat as the null node.
+		return common.Hash{}, nil
+	}
+
+	var acc rlpAccount
+	if err := rlp.DecodeBytes(leaf, &acc); err != nil {
+		return common.Hash{}, fmt.Errorf("address tree proof: malformed account leaf for %s: %w", proof.Address, err)
+	}
+	if acc.Root != proof.StorageHash {
+		return common.Hash{}, fmt.Errorf("address tree proof: reported storageHash does not match verified account root for %s", proof.Address)
+	}
+	return acc.Root, nil
+}
+
+// verifyStorageSlot checks a single storage-slot proof against storageRoot
+// and returns the verified slot value (left-padded to 32 bytes).
+func verifyStorageSlot(storageRoot common.Hash, slot storageProofResult) (common.Hash, error) {
+	nodes := make(proofNodeSet, len(slot.Proof))
+	for i, n := range slot.Proof {
+		nodes[i] = n
+	}
+
+	key := crypto.Keccak256(common.HexToHash(slot.Key).Bytes())
+	leaf, err := trie.VerifyProof(storageRoot, key, nodes)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("address tree proof: storage proof verification failed for slot %s: %w", slot.Key, err)
+	}
+	if leaf == nil {
+		return common.Hash{}, nil
+	}
+
+	var value []byte
+	if err := rlp.DecodeBytes(leaf, &value); err != nil {
+		return common.Hash{}, fmt.Errorf("address tree proof: malformed storage leaf for slot %s: %w", slot.Key, err)
+	}
+	return common.BytesToHash(value), nil
+}
+
+// verifiedSlots fetches and verifies ParentSlotHash/DepthSlotHash/
+// VersionSlotHash for account against the given state root in a single
+// eth_getProof round trip, closing the trust hole where a malicious anchor
+// RPC could feed arbitrary values into reward distribution.
+func verifiedSlots(ctx context.Context, rpcClient *rpc.Client, stateRoot common.Hash, contractAddress, account common.Address, blockNumber *big.Int) (version, parent, depth common.Hash, err error) {
+	slots := []common.Hash{
+		anchor.VersionSlotHash(account),
+		anchor.ParentSlotHash(account),
+		anchor.DepthSlotHash(account),
+	}
+
+	proof, err := fetchProof(ctx, rpcClient, contractAddress, slots, blockNumber)
+	if err != nil {
+		return common.Hash{}, common.Hash{}, common.Hash{}, err
+	}
+
+	storageRoot, err := verifyAccountProof(stateRoot, proof)
+	if err != nil {
+		return common.Hash{}, common.Hash{}, common.Hash{}, err
+	}
+
+	if len(proof.StorageProof) != len(slots) {
+		return common.Hash{}, common.Hash{}, common.Hash{}, fmt.Errorf("address tree proof: expected %d storage proofs, got %d", len(slots), len(proof.StorageProof))
+	}
+
+	values := make(map[common.Hash]common.Hash, len(slots))
+	for _, sp := range proof.StorageProof {
+		v, err := verifyStorageSlot(storageRoot, sp)
+		if err != nil {
+			return common.Hash{}, common.Hash{}, common.Hash{}, err
+		}
+		values[common.HexToHash(sp.Key)] = v
+	}
+
+	return values[slots[0]], values[slots[1]], values[slots[2]], nil
+}
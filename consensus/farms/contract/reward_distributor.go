@@ -0,0 +1,117 @@
+package contract
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// RewardTransfer is one (recipient, token, amount, locker) leg of a Claim:
+// amount of token owed to Recipient, to be paid out through Locker - the
+// rewardTokensLocker entry paired with Token - or directly if Locker is the
+// zero address (a pool can add a reward token before assigning it a
+// locker).
+type RewardTransfer struct {
+	Recipient common.Address
+	Token     common.Address
+	Amount    *big.Int
+	Locker    common.Address
+}
+
+// RewardDistributor turns the per-member RewardInfo entries
+// PoolDistribution's UpdateRewardPerShares/updateAccountBalance accrue (in
+// consensus/farms, against the per-range reward-per-share arrays that
+// package alone tracks) into a deterministic payout list, and lets a member
+// claim them. It doesn't recompute the range-weighted accrual curve itself
+// - that bookkeeping already lives in PoolDistribution - it only reads the
+// RewardInfo.Reward FarmContract.GetUserInfo already exposes, pairs each
+// reward token with its locker, and resets what it pays out.
+type RewardDistributor struct {
+	farmContract *FarmContract
+	poolAddress  common.Address
+	poolInfo     *PoolInfo
+}
+
+// NewRewardDistributor builds a RewardDistributor for poolAddress under
+// farmContract, using poolInfo for the pool's reward-token and locker
+// arrays.
+func NewRewardDistributor(farmContract *FarmContract, poolAddress common.Address, poolInfo *PoolInfo) *RewardDistributor {
+	return &RewardDistributor{
+		farmContract: farmContract,
+		poolAddress:  poolAddress,
+		poolInfo:     poolInfo,
+	}
+}
+
+// lockerFor returns the locker paired with poolInfo.GetRewardTokens()[index],
+// or the zero address if the pool's rewardTokensLocker array hasn't caught
+// up with its rewardTokens array yet - the two are independent dynamic
+// arrays on-chain, so a reward token added mid-epoch can exist before any
+// locker is assigned to it.
+func (r *RewardDistributor) lockerFor(index int) common.Address {
+	lockers := r.poolInfo.GetRewardLocks()
+	if index >= len(lockers) {
+		return common.Address{}
+	}
+	return lockers[index]
+}
+
+// Accrued returns member's currently accrued, unclaimed reward across every
+// reward token the pool tracks - holder-side and community-side
+// RewardInfo.Reward added together per token - without resetting anything.
+// Reward tokens with nothing accrued are omitted.
+func (r *RewardDistributor) Accrued(member common.Address) []RewardTransfer {
+	userInfo := r.farmContract.GetUserInfo(r.poolAddress, member)
+	tokens := r.poolInfo.GetRewardTokens()
+
+	transfers := make([]RewardTransfer, 0, len(tokens))
+	for i, token := range tokens {
+		amount := new(big.Int).Add(
+			userInfo.GetHolderRewardInfo(token).Reward,
+			userInfo.GetCommunityRewardInfo(token).Reward,
+		)
+		if amount.Sign() == 0 {
+			continue
+		}
+		transfers = append(transfers, RewardTransfer{
+			Recipient: member,
+			Token:     token,
+			Amount:    amount,
+			Locker:    r.lockerFor(i),
+		})
+	}
+	return transfers
+}
+
+// Claim returns member's Accrued transfers and, for every reward token paid
+// out, zeroes that token's holder- and community-side Reward while leaving
+// RewardDebt untouched: RewardDebt is the reward-per-share checkpoint
+// PoolDistribution's accrual math advances on every transfer/distribution
+// regardless of whether anything is ever claimed, so Claim only needs to
+// mark the already-accrued amount as paid.
+func (r *RewardDistributor) Claim(member common.Address) []RewardTransfer {
+	userInfo := r.farmContract.GetUserInfo(r.poolAddress, member)
+	tokens := r.poolInfo.GetRewardTokens()
+
+	transfers := make([]RewardTransfer, 0, len(tokens))
+	for i, token := range tokens {
+		holder := userInfo.GetHolderRewardInfo(token)
+		community := userInfo.GetCommunityRewardInfo(token)
+
+		amount := new(big.Int).Add(holder.Reward, community.Reward)
+		if amount.Sign() == 0 {
+			continue
+		}
+
+		transfers = append(transfers, RewardTransfer{
+			Recipient: member,
+			Token:     token,
+			Amount:    amount,
+			Locker:    r.lockerFor(i),
+		})
+
+		userInfo.SetHolderRewardInfo(token, big.NewInt(0), holder.RewardDebt)
+		userInfo.SetCommunityRewardInfo(token, big.NewInt(0), community.RewardDebt)
+	}
+	return transfers
+}
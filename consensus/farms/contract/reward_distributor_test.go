@@ -0,0 +1,31 @@
+package contract
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TestRewardDistributorLockerForHandlesMidEpochResize pins the case a pool
+// hits when a reward token is added before a locker is assigned to it:
+// rewardTokensLocker can be shorter than rewardTokens, and lockerFor must
+// fall back to the zero address for the unassigned tail instead of
+// panicking or reading past the end of the locker array.
+func TestRewardDistributorLockerForHandlesMidEpochResize(t *testing.T) {
+	tokenA := common.HexToAddress("0x1")
+	tokenB := common.HexToAddress("0x2")
+	lockerA := common.HexToAddress("0xa")
+
+	poolInfo := &PoolInfo{
+		rewardTokensCache: []common.Address{tokenA, tokenB},
+		rewardLocksCache:  []common.Address{lockerA},
+	}
+	distributor := &RewardDistributor{poolInfo: poolInfo}
+
+	if got := distributor.lockerFor(0); got != lockerA {
+		t.Errorf("lockerFor(0) = %s, want %s", got, lockerA)
+	}
+	if got := distributor.lockerFor(1); got != (common.Address{}) {
+		t.Errorf("lockerFor(1) = %s, want zero address for a reward token added before its locker", got)
+	}
+}
@@ -2,6 +2,7 @@ package contract
 
 import (
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/farms/contract/layout"
 	"github.com/ethereum/go-ethereum/core/state"
 	"golang.org/x/crypto/sha3"
 	"math/big"
@@ -11,6 +12,27 @@ const (
 	FarmMemberSlotUserInfo = 5
 )
 
+// userInfoLayout is UserInfo's per-account struct layout, resolved against
+// the nested pool/account mapping slot NewUserInfo computes - the same
+// declarative-table convention poolInfoLayout uses for PoolInfo.
+var userInfoLayout = layout.Descriptor{
+	Fields: []layout.Field{
+		{Name: "communityPower", Slot: 0},
+		{Name: "holderRewardInfoMapping", Slot: 1},
+		{Name: "communityRewardInfoMapping", Slot: 2},
+	},
+}
+
+// rewardInfoLayout is RewardInfo's struct layout, resolved against whichever
+// of holderRewardInfoMappingSlot/communityRewardInfoMappingSlot a given
+// reward token hashes into; both reward-info mappings share this shape.
+var rewardInfoLayout = layout.Descriptor{
+	Fields: []layout.Field{
+		{Name: "reward", Slot: 0},
+		{Name: "rewardDebt", Slot: 1},
+	},
+}
+
 type UserInfo struct {
 	state       *state.StateDB
 	farmAddress common.Address
@@ -20,6 +42,12 @@ type UserInfo struct {
 
 	holderRewardInfoMappingSlot    common.Hash
 	communityRewardInfoMappingSlot common.Hash
+
+	// tx is non-nil when this UserInfo was produced by FarmTx.User, in
+	// which case the Get/SetHolderRewardInfo, Get/SetCommunityRewardInfo
+	// and Get/SetChildrenHoldAmount pairs shadow through its overlay
+	// instead of going straight to state.
+	tx *FarmTx
 }
 
 type RewardInfo struct {
@@ -28,31 +56,19 @@ type RewardInfo struct {
 }
 
 func NewUserInfo(state *state.StateDB, farmAddress, poolAddress, account common.Address) *UserInfo {
-	var slot1 common.Hash
-	var slot2 common.Hash
 	var childrenHoldAmountSlot common.Hash
 
 	harsher := sha3.NewLegacyKeccak256()
-	harsher.Write(common.LeftPadBytes(poolAddress.Bytes(), 32))
-	harsher.Write(common.LeftPadBytes(common.IntToSlot(FarmMemberSlotUserInfo).Bytes(), 32))
-	harsher.Sum(slot1[:0])
-	harsher.Reset()
-
-	harsher.Write(common.LeftPadBytes(account.Bytes(), 32))
-	harsher.Write(common.LeftPadBytes(slot1.Bytes(), 32))
-	harsher.Sum(slot2[:0])
-	harsher.Reset()
-
 	harsher.Write(common.LeftPadBytes([]byte("__ChildrenHoldAmount"), 32))
 	harsher.Write(common.LeftPadBytes(poolAddress.Bytes(), 32))
 	harsher.Write(common.LeftPadBytes(account.Bytes(), 32))
 	harsher.Sum(childrenHoldAmountSlot[:0])
-	harsher.Reset()
 
-	slotBig := new(big.Int).SetBytes(slot2.Bytes())
-	communityPowerSlot := common.BigToHash(new(big.Int).Add(slotBig, big.NewInt(0)))
-	holderRewardInfoMappingSlot := common.BigToHash(new(big.Int).Add(slotBig, big.NewInt(1)))
-	communityRewardInfoMappingSlot := common.BigToHash(new(big.Int).Add(slotBig, big.NewInt(2)))
+	base := layout.NestedMappingSlot(common.BytesToHash(poolAddress.Bytes()), common.IntToSlot(FarmMemberSlotUserInfo), common.BytesToHash(account.Bytes()))
+	resolved := userInfoLayout.Resolve(base)
+	communityPowerSlot := resolved.Slot("communityPower")
+	holderRewardInfoMappingSlot := resolved.Slot("holderRewardInfoMapping")
+	communityRewardInfoMappingSlot := resolved.Slot("communityRewardInfoMapping")
 
 	return &UserInfo{
 		state:       state,
@@ -74,7 +90,7 @@ func (u *UserInfo) SetCommunityPower(power *big.Int) {
 }
 
 func (u *UserInfo) GetChildrenHoldAmount() []*big.Int {
-	rawData := u.state.GetRawState(u.farmAddress, u.childrenHoldAmountSlot)
+	rawData := u.getRawState(u.childrenHoldAmountSlot)
 	rawDataLen := len(rawData) / 16
 	ret := make([]*big.Int, rawDataLen)
 	for i := 0; i < rawDataLen; i++ {
@@ -88,24 +104,13 @@ func (u *UserInfo) SetChildrenHoldAmount(values []*big.Int) {
 	for i, value := range values {
 		copy(rawData[i*16:i*16+16], common.LeftPadBytes(value.Bytes(), 16))
 	}
-	u.state.SetRawState(u.farmAddress, u.childrenHoldAmountSlot, rawData)
+	u.setRawState(u.childrenHoldAmountSlot, rawData)
 }
 
 func (u *UserInfo) GetHolderRewardInfo(rewardToken common.Address) *RewardInfo {
-	var slot1 common.Hash
-
-	harsher := sha3.NewLegacyKeccak256()
-	harsher.Write(common.LeftPadBytes(rewardToken.Bytes(), 32))
-	harsher.Write(u.holderRewardInfoMappingSlot.Bytes())
-	harsher.Sum(slot1[:0])
-	harsher.Reset()
-
-	slotBig := new(big.Int).SetBytes(slot1.Bytes())
-	rewardSlot := common.BigToHash(slotBig)
-	rewardDebtSlot := common.BigToHash(new(big.Int).Add(slotBig, big.NewInt(1)))
-
-	reward := new(big.Int).SetBytes(u.state.GetState(u.farmAddress, rewardSlot).Bytes())
-	rewardDebt := new(big.Int).SetBytes(u.state.GetState(u.farmAddress, rewardDebtSlot).Bytes())
+	resolved := rewardInfoLayout.Resolve(layout.MappingSlot(common.BytesToHash(rewardToken.Bytes()), u.holderRewardInfoMappingSlot))
+	reward := new(big.Int).SetBytes(u.getState(resolved.Slot("reward")).Bytes())
+	rewardDebt := new(big.Int).SetBytes(u.getState(resolved.Slot("rewardDebt")).Bytes())
 
 	return &RewardInfo{
 		Reward:     reward,
@@ -114,37 +119,15 @@ func (u *UserInfo) GetHolderRewardInfo(rewardToken common.Address) *RewardInfo {
 }
 
 func (u *UserInfo) SetHolderRewardInfo(rewardToken common.Address, reward *big.Int, rewardDebt *big.Int) {
-	var slot1 common.Hash
-
-	harsher := sha3.NewLegacyKeccak256()
-	harsher.Write(common.LeftPadBytes(rewardToken.Bytes(), 32))
-	harsher.Write(u.holderRewardInfoMappingSlot.Bytes())
-	harsher.Sum(slot1[:0])
-	harsher.Reset()
-
-	slotBig := new(big.Int).SetBytes(slot1.Bytes())
-	rewardSlot := common.BigToHash(slotBig)
-	rewardDebtSlot := common.BigToHash(new(big.Int).Add(slotBig, big.NewInt(1)))
-
-	u.state.SetState(u.farmAddress, rewardSlot, common.BigToHash(reward))
-	u.state.SetState(u.farmAddress, rewardDebtSlot, common.BigToHash(rewardDebt))
+	resolved := rewardInfoLayout.Resolve(layout.MappingSlot(common.BytesToHash(rewardToken.Bytes()), u.holderRewardInfoMappingSlot))
+	u.setState(resolved.Slot("reward"), common.BigToHash(reward))
+	u.setState(resolved.Slot("rewardDebt"), common.BigToHash(rewardDebt))
 }
 
 func (u *UserInfo) GetCommunityRewardInfo(rewardToken common.Address) *RewardInfo {
-	var slot1 common.Hash
-
-	harsher := sha3.NewLegacyKeccak256()
-	harsher.Write(common.LeftPadBytes(rewardToken.Bytes(), 32))
-	harsher.Write(u.communityRewardInfoMappingSlot.Bytes())
-	harsher.Sum(slot1[:0])
-	harsher.Reset()
-
-	slotBig := new(big.Int).SetBytes(slot1.Bytes())
-	rewardSlot := common.BigToHash(slotBig)
-	rewardDebtSlot := common.BigToHash(new(big.Int).Add(slotBig, big.NewInt(1)))
-
-	reward := new(big.Int).SetBytes(u.state.GetState(u.farmAddress, rewardSlot).Bytes())
-	rewardDebt := new(big.Int).SetBytes(u.state.GetState(u.farmAddress, rewardDebtSlot).Bytes())
+	resolved := rewardInfoLayout.Resolve(layout.MappingSlot(common.BytesToHash(rewardToken.Bytes()), u.communityRewardInfoMappingSlot))
+	reward := new(big.Int).SetBytes(u.getState(resolved.Slot("reward")).Bytes())
+	rewardDebt := new(big.Int).SetBytes(u.getState(resolved.Slot("rewardDebt")).Bytes())
 
 	return &RewardInfo{
 		Reward:     reward,
@@ -153,18 +136,41 @@ func (u *UserInfo) GetCommunityRewardInfo(rewardToken common.Address) *RewardInf
 }
 
 func (u *UserInfo) SetCommunityRewardInfo(rewardToken common.Address, reward *big.Int, rewardDebt *big.Int) {
-	var slot1 common.Hash
+	resolved := rewardInfoLayout.Resolve(layout.MappingSlot(common.BytesToHash(rewardToken.Bytes()), u.communityRewardInfoMappingSlot))
+	u.setState(resolved.Slot("reward"), common.BigToHash(reward))
+	u.setState(resolved.Slot("rewardDebt"), common.BigToHash(rewardDebt))
+}
 
-	harsher := sha3.NewLegacyKeccak256()
-	harsher.Write(common.LeftPadBytes(rewardToken.Bytes(), 32))
-	harsher.Write(u.communityRewardInfoMappingSlot.Bytes())
-	harsher.Sum(slot1[:0])
-	harsher.Reset()
+// getState/setState/getRawState/setRawState route the Get/SetHolderRewardInfo,
+// Get/SetCommunityRewardInfo and Get/SetChildrenHoldAmount pairs through
+// u.tx's overlay when u was produced by FarmTx.User, and straight to state
+// otherwise.
+func (u *UserInfo) getState(slot common.Hash) common.Hash {
+	if u.tx != nil {
+		return u.tx.getState(u.farmAddress, slot)
+	}
+	return u.state.GetState(u.farmAddress, slot)
+}
 
-	slotBig := new(big.Int).SetBytes(slot1.Bytes())
-	rewardSlot := common.BigToHash(slotBig)
-	rewardDebtSlot := common.BigToHash(new(big.Int).Add(slotBig, big.NewInt(1)))
+func (u *UserInfo) setState(slot common.Hash, value common.Hash) {
+	if u.tx != nil {
+		u.tx.setState(u.farmAddress, slot, value)
+		return
+	}
+	u.state.SetState(u.farmAddress, slot, value)
+}
 
-	u.state.SetState(u.farmAddress, rewardSlot, common.BigToHash(reward))
-	u.state.SetState(u.farmAddress, rewardDebtSlot, common.BigToHash(rewardDebt))
+func (u *UserInfo) getRawState(slot common.Hash) []byte {
+	if u.tx != nil {
+		return u.tx.getRawState(u.farmAddress, slot)
+	}
+	return u.state.GetRawState(u.farmAddress, slot)
+}
+
+func (u *UserInfo) setRawState(slot common.Hash, data []byte) {
+	if u.tx != nil {
+		u.tx.setRawState(u.farmAddress, slot, data)
+		return
+	}
+	u.state.SetRawState(u.farmAddress, slot, data)
 }
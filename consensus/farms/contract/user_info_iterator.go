@@ -0,0 +1,61 @@
+package contract
+
+import "math/big"
+
+// childrenHoldAmountEntrySize is the packed width of one
+// ChildrenHoldAmount entry, matching GetChildrenHoldAmount/
+// SetChildrenHoldAmount's 16-byte-per-value encoding.
+const childrenHoldAmountEntrySize = 16
+
+// ChildIterator streams UserInfo's children-hold-amount list without
+// decoding every entry into a *big.Int up front the way
+// GetChildrenHoldAmount does. GetRawState only exposes this list as a
+// single opaque blob keyed by childrenHoldAmountSlot - there's no per-slot
+// read primitive to fetch just one entry - so fetching the raw bytes once
+// is unavoidable, but this defers every big.Int decode until Value() is
+// actually called for that position, which is what matters for a caller
+// that only wants to page through part of a large downline tree.
+type ChildIterator struct {
+	raw []byte
+	len int
+	pos int
+}
+
+// ChildrenHoldAmountIterator returns a ChildIterator over u's
+// children-hold-amount list.
+func (u *UserInfo) ChildrenHoldAmountIterator() *ChildIterator {
+	raw := u.state.GetRawState(u.farmAddress, u.childrenHoldAmountSlot)
+	return &ChildIterator{
+		raw: raw,
+		len: len(raw) / childrenHoldAmountEntrySize,
+		pos: -1,
+	}
+}
+
+// Len reports the number of children-hold-amount entries without decoding
+// any of them.
+func (it *ChildIterator) Len() int {
+	return it.len
+}
+
+// Seek repositions the iterator so the next Next() call returns the entry
+// at offset.
+func (it *ChildIterator) Seek(offset int) {
+	it.pos = offset - 1
+}
+
+// Next advances the iterator and reports whether a Value is available.
+func (it *ChildIterator) Next() bool {
+	if it.pos+1 >= it.len {
+		return false
+	}
+	it.pos++
+	return true
+}
+
+// Value decodes and returns the children-hold amount at the iterator's
+// current position.
+func (it *ChildIterator) Value() *big.Int {
+	off := it.pos * childrenHoldAmountEntrySize
+	return new(big.Int).SetBytes(it.raw[off : off+childrenHoldAmountEntrySize])
+}
@@ -0,0 +1,101 @@
+package farms
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const defaultNodeInfoCacheSize = 4096
+
+var (
+	distributionCacheHitMeter  = metrics.NewRegisteredMeter("farms/distribution/cache/hit", nil)
+	distributionCacheMissMeter = metrics.NewRegisteredMeter("farms/distribution/cache/miss", nil)
+)
+
+// nodeInfoKey keys the parent/children LRU by the state root the lookup was
+// made against. A PoolDistribution is constructed fresh per pool per block
+// (see newTokenHolderDistribution), so stateRoot is fixed for the cache's
+// whole lifetime; it's still carried on the key, rather than assumed, so a
+// future caller that reuses a distributionCache across blocks can't serve a
+// stale parent/children pair for the same address.
+type nodeInfoKey struct {
+	stateRoot common.Hash
+	account   common.Address
+}
+
+// distributionCache holds LRU-backed ParentOf/ChildrenOf caches alongside
+// the balanceOf map that replaced the old ad-hoc blockBalanceCaches map,
+// so updateAchievement's forefather walk doesn't re-read the same
+// storage slots (or re-dial the anchor RPC) once per distribution call.
+type distributionCache struct {
+	stateRoot common.Hash
+
+	parents  *lru.Cache // nodeInfoKey -> common.Address
+	children *lru.Cache // nodeInfoKey -> *[]common.Address
+	balances map[common.Address]*big.Int
+}
+
+func newDistributionCache(stateRoot common.Hash) *distributionCache {
+	parents, _ := lru.New(defaultNodeInfoCacheSize)
+	children, _ := lru.New(defaultNodeInfoCacheSize)
+	return &distributionCache{
+		stateRoot: stateRoot,
+		parents:   parents,
+		children:  children,
+		balances:  map[common.Address]*big.Int{},
+	}
+}
+
+func (c *distributionCache) key(account common.Address) nodeInfoKey {
+	return nodeInfoKey{stateRoot: c.stateRoot, account: account}
+}
+
+func (c *distributionCache) getParent(account common.Address) (common.Address, bool) {
+	if v, ok := c.parents.Get(c.key(account)); ok {
+		distributionCacheHitMeter.Mark(1)
+		return v.(common.Address), true
+	}
+	distributionCacheMissMeter.Mark(1)
+	return common.Address{}, false
+}
+
+func (c *distributionCache) setParent(account, parent common.Address) {
+	c.parents.Add(c.key(account), parent)
+}
+
+func (c *distributionCache) getChildren(account common.Address) (*[]common.Address, bool) {
+	if v, ok := c.children.Get(c.key(account)); ok {
+		distributionCacheHitMeter.Mark(1)
+		return v.(*[]common.Address), true
+	}
+	distributionCacheMissMeter.Mark(1)
+	return nil, false
+}
+
+func (c *distributionCache) setChildren(account common.Address, children *[]common.Address) {
+	c.children.Add(c.key(account), children)
+}
+
+// invalidate punches the cache entries a write to account's parent/children
+// slots would otherwise leave stale. Farm.FinalizeBlock calls it for both the
+// parent and the child of every relation it records, on each distribution
+// already built for the block.
+func (c *distributionCache) invalidate(account common.Address) {
+	c.parents.Remove(c.key(account))
+	c.children.Remove(c.key(account))
+}
+
+func (c *distributionCache) getBalance(account common.Address, guard bool) (*big.Int, bool) {
+	if !guard {
+		return nil, false
+	}
+	balance, ok := c.balances[account]
+	return balance, ok
+}
+
+func (c *distributionCache) setBalance(account common.Address, balance *big.Int) {
+	c.balances[account] = balance
+}
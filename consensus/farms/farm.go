@@ -28,6 +28,10 @@ type Farm struct {
 	farmABI             abi.ABI
 	transferABI         abi.ABI
 	rTransferValue      *big.Int
+	tracer              Tracer
+
+	powerEventBus            *contract.PowerEventBus
+	appendSyntheticPowerLogs bool
 }
 
 type DistributeRewardEvent struct {
@@ -68,6 +72,7 @@ func NewFarm(state *state.StateDB, ethAPI *ethapi.PublicBlockChainAPI, farmContr
 		farmABI:             farmABI,
 		transferABI:         transferABI,
 		rTransferValue:      makeNodeValue,
+		powerEventBus:       contract.NewPowerEventBus(),
 	}
 
 	return farm
@@ -79,9 +84,13 @@ func (f *Farm) FinalizeBlock(chain core.ChainContext, chainConfig *params.ChainC
 	poolInfos := map[common.Address]*contract.PoolInfo{}
 	poolHolderDistributions := map[common.Address]*PoolDistribution{}
 
+	f.powerEventBus.SetBlockNumber(header.Number)
+	f.powerEventBus.SetCollecting(f.appendSyntheticPowerLogs)
+
 	snap := f.state.Snapshot()
 	for _, token := range poolTokens {
 		poolInfos[token] = f.farmContract.GetPoolInfo(token)
+		poolInfos[token].SetEventBus(f.powerEventBus)
 	}
 
 	// Handle And Create AddressTree Node / Community Token Transfer EventLog
@@ -114,9 +123,15 @@ func (f *Farm) FinalizeBlock(chain core.ChainContext, chainConfig *params.ChainC
 					return err
 				}
 
+				for _, dst := range poolHolderDistributions {
+					dst.cache.invalidate(parent)
+					dst.cache.invalidate(*child)
+				}
+
 				for poolAddress, info := range poolInfos {
 					if poolHolderDistributions[poolAddress] == nil {
 						poolHolderDistributions[poolAddress] = newTokenHolderDistribution(f.state, f.ethAPI, f.farmContract, f.addressTreeContract, poolAddress, info, isFork0815)
+						poolHolderDistributions[poolAddress].SetTracer(f.tracer)
 					}
 					dst := poolHolderDistributions[poolAddress]
 					if balance, err := dst.balanceOf(header.ParentHash, *child); err != nil {
@@ -125,7 +140,7 @@ func (f *Farm) FinalizeBlock(chain core.ChainContext, chainConfig *params.ChainC
 						return err
 					} else {
 						if balance.Cmp(big.NewInt(0)) > 0 {
-							if err := dst.updateAchievement(*child, big.NewInt(0), balance); err != nil {
+							if err := dst.updateAchievement(header.Number, *child, big.NewInt(0), balance); err != nil {
 								f.state.RevertToSnapshot(snap)
 								log.Warn("FarmHandleBlock - HandleUpdateAchievement Error", "number", header.Number, "hash", header.Hash())
 								return err
@@ -144,9 +159,11 @@ func (f *Farm) FinalizeBlock(chain core.ChainContext, chainConfig *params.ChainC
 				if p := poolInfos[l.Address]; p != nil {
 					if poolHolderDistributions[l.Address] == nil {
 						poolHolderDistributions[l.Address] = newTokenHolderDistribution(f.state, f.ethAPI, f.farmContract, f.addressTreeContract, l.Address, p, isFork0815)
+						poolHolderDistributions[l.Address].SetTracer(f.tracer)
 					}
 					dst := poolHolderDistributions[l.Address]
 					if err := dst.putTransferEventLog(
+						header.Number,
 						header.ParentHash,
 						common.BytesToAddress(l.Topics[1].Bytes()),
 						common.BytesToAddress(l.Topics[2].Bytes()),
@@ -162,9 +179,15 @@ func (f *Farm) FinalizeBlock(chain core.ChainContext, chainConfig *params.ChainC
 				child := common.BytesToAddress(l.Topics[2].Bytes())
 				_ = f.addressTreeContract.AppendChild(parent, child)
 
+				for _, dst := range poolHolderDistributions {
+					dst.cache.invalidate(parent)
+					dst.cache.invalidate(child)
+				}
+
 				for poolAddress, info := range poolInfos {
 					if poolHolderDistributions[poolAddress] == nil {
 						poolHolderDistributions[poolAddress] = newTokenHolderDistribution(f.state, f.ethAPI, f.farmContract, f.addressTreeContract, poolAddress, info, isFork0815)
+						poolHolderDistributions[poolAddress].SetTracer(f.tracer)
 					}
 					dst := poolHolderDistributions[poolAddress]
 					if balance, err := dst.balanceOf(header.ParentHash, child); err != nil {
@@ -173,7 +196,7 @@ func (f *Farm) FinalizeBlock(chain core.ChainContext, chainConfig *params.ChainC
 						return err
 					} else {
 						if balance.Cmp(big.NewInt(0)) > 0 {
-							if err := dst.updateAchievement(child, big.NewInt(0), balance); err != nil {
+							if err := dst.updateAchievement(header.Number, child, big.NewInt(0), balance); err != nil {
 								f.state.RevertToSnapshot(snap)
 								log.Warn("FarmHandleBlock - HandleUpdateAchievement Error", "number", header.Number, "hash", header.Hash())
 								return err
@@ -189,6 +212,7 @@ func (f *Farm) FinalizeBlock(chain core.ChainContext, chainConfig *params.ChainC
 
 				if poolHolderDistributions[poolAddress] == nil {
 					poolHolderDistributions[poolAddress] = newTokenHolderDistribution(f.state, f.ethAPI, f.farmContract, f.addressTreeContract, poolAddress, poolInfos[poolAddress], isFork0815)
+					poolHolderDistributions[poolAddress].SetTracer(f.tracer)
 				}
 				dst := poolHolderDistributions[poolAddress]
 				dst.UpdateRewardPerShares(rewardToken, holderReward, communityReward)
@@ -200,5 +224,7 @@ func (f *Farm) FinalizeBlock(chain core.ChainContext, chainConfig *params.ChainC
 	for _, dst := range poolHolderDistributions {
 		dst.Storage()
 	}
+
+	f.appendSyntheticPowerChangeLogs(header, receipts)
 	return nil
 }
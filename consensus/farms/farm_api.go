@@ -0,0 +1,218 @@
+package farms
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/farms/contract"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Backend is the subset of the node's API backend FarmAPI needs to resolve
+// a blockNrOrHash argument to historical state, mirroring the same method
+// other read-only RPC services (debug_, trace_) are built against.
+type Backend interface {
+	StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error)
+}
+
+// FarmAPI exposes PoolDistribution and FarmContract state under the "farm"
+// RPC namespace, so block explorers and dashboards can inspect range
+// distribution and pending rewards without hand-decoding storage slots.
+type FarmAPI struct {
+	backend                    Backend
+	ethAPI                     *ethapi.PublicBlockChainAPI
+	farmAddress                common.Address
+	addressTreeContractAddress common.Address
+	isAnchorNet                bool
+}
+
+// NewFarmAPI wraps backend/ethAPI for RPC registration, e.g.:
+//
+//	stack.RegisterAPIs(farms.FarmAPIs(backend, ethAPI, farmAddress, addressTreeAddress, isAnchorNet))
+func NewFarmAPI(backend Backend, ethAPI *ethapi.PublicBlockChainAPI, farmAddress, addressTreeContractAddress common.Address, isAnchorNet bool) *FarmAPI {
+	return &FarmAPI{
+		backend:                    backend,
+		ethAPI:                     ethAPI,
+		farmAddress:                farmAddress,
+		addressTreeContractAddress: addressTreeContractAddress,
+		isAnchorNet:                isAnchorNet,
+	}
+}
+
+// FarmAPIs builds the rpc.API registration list for backend, for callers
+// that register namespaces the same way the other geth services (debug_,
+// trace_, txpool_) are wired up.
+func FarmAPIs(backend Backend, ethAPI *ethapi.PublicBlockChainAPI, farmAddress, addressTreeContractAddress common.Address, isAnchorNet bool) []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "farm",
+			Service:   NewFarmAPI(backend, ethAPI, farmAddress, addressTreeContractAddress, isAnchorNet),
+		},
+	}
+}
+
+// stateAt resolves blockNrOrHash and builds the contract bindings the rest
+// of the API reads through.
+func (api *FarmAPI) stateAt(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *contract.FarmContract, *contract.AddressTreeContract, error) {
+	st, _, err := api.backend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	farmContract := contract.NewFarmContract(st, api.farmAddress, api.isAnchorNet)
+	addressTreeContract := contract.NewAddressTreeContract(st, nil, api.addressTreeContractAddress, nil, 0)
+	return st, farmContract, addressTreeContract, nil
+}
+
+// distributionAt builds a read-only PoolDistribution against the historical
+// state resolved from blockNrOrHash. isFork0815 is always true here: every
+// historical block an RPC caller can query is already past that fork.
+func (api *FarmAPI) distributionAt(ctx context.Context, pool common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*PoolDistribution, error) {
+	st, farmContract, addressTreeContract, err := api.stateAt(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	poolInfo := farmContract.GetPoolInfo(pool)
+	return NewPoolDistribution(st, api.ethAPI, farmContract, addressTreeContract, pool, poolInfo, true), nil
+}
+
+// PoolInfoResult is the farm_getPoolInfo response.
+type PoolInfoResult struct {
+	Token               common.Address   `json:"token"`
+	RangeCount          *hexutil.Big     `json:"rangeCount"`
+	RangeInterval       *hexutil.Big     `json:"rangeInterval"`
+	HolderTotalPower    *hexutil.Big     `json:"holderTotalPower"`
+	CommunityTotalPower *hexutil.Big     `json:"communityTotalPower"`
+	RewardTokens        []common.Address `json:"rewardTokens"`
+}
+
+// GetPoolInfo implements farm_getPoolInfo.
+func (api *FarmAPI) GetPoolInfo(ctx context.Context, pool common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*PoolInfoResult, error) {
+	_, farmContract, _, err := api.stateAt(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	poolInfo := farmContract.GetPoolInfo(pool)
+	return &PoolInfoResult{
+		Token:               poolInfo.GetTokenAddress(),
+		RangeCount:          (*hexutil.Big)(poolInfo.GetRangeCount()),
+		RangeInterval:       (*hexutil.Big)(poolInfo.GetRangeInterval()),
+		HolderTotalPower:    (*hexutil.Big)(poolInfo.GetHolderTotalPower()),
+		CommunityTotalPower: (*hexutil.Big)(poolInfo.GetCommunityTotalPower()),
+		RewardTokens:        poolInfo.GetRewardTokens(),
+	}, nil
+}
+
+// RangeDistributionEntry is a single range's occupancy and effective power
+// in the farm_getRangeDistribution response.
+type RangeDistributionEntry struct {
+	Index           hexutil.Uint64 `json:"index"`
+	TotalCount      *hexutil.Big   `json:"totalCount"`
+	EmptyRangeCount *hexutil.Big   `json:"emptyRangeCount"`
+	EffectivePower  *hexutil.Big   `json:"effectivePower"`
+}
+
+// GetRangeDistribution implements farm_getRangeDistribution.
+func (api *FarmAPI) GetRangeDistribution(ctx context.Context, pool common.Address, blockNrOrHash rpc.BlockNumberOrHash) ([]*RangeDistributionEntry, error) {
+	dist, err := api.distributionAt(ctx, pool, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+
+	rangeCount := dist.poolInfo.GetRangeCount().Uint64()
+	entries := make([]*RangeDistributionEntry, rangeCount)
+	for i := uint64(0); i < rangeCount; i++ {
+		info := dist.GetRangeInfo(i)
+		effectivePower := new(big.Int).Mul(
+			new(big.Int).Sub(new(big.Int).SetUint64(i), info.emptyRangeCount),
+			info.totalCount,
+		)
+		entries[i] = &RangeDistributionEntry{
+			Index:           hexutil.Uint64(i),
+			TotalCount:      (*hexutil.Big)(info.totalCount),
+			EmptyRangeCount: (*hexutil.Big)(info.emptyRangeCount),
+			EffectivePower:  (*hexutil.Big)(effectivePower),
+		}
+	}
+	return entries, nil
+}
+
+// GetHolderRewardPerShare implements farm_getHolderRewardPerShare.
+func (api *FarmAPI) GetHolderRewardPerShare(ctx context.Context, pool, token common.Address, rangeIndex hexutil.Uint64, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error) {
+	dist, err := api.distributionAt(ctx, pool, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(dist.GetHolderRewardPerShare(token, uint64(rangeIndex))), nil
+}
+
+// GetPendingReward implements farm_getPendingReward, replaying the same
+// arithmetic updateAccountBalance uses to roll a holder's reward forward to
+// the range matching its current balance, including the isFork0815 clamp to
+// zero on a negative delta.
+func (api *FarmAPI) GetPendingReward(ctx context.Context, pool, token, account common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error) {
+	st, farmContract, _, err := api.stateAt(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	poolInfo := farmContract.GetPoolInfo(pool)
+	dist := NewPoolDistribution(st, api.ethAPI, farmContract, nil, pool, poolInfo, true)
+
+	_, header, err := api.backend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := dist.balanceOf(header.Hash(), account)
+	if err != nil {
+		return nil, err
+	}
+
+	rangeIndex := new(big.Int).Div(balance, poolInfo.GetRangeInterval()).Uint64()
+	if maxIndex := poolInfo.GetRangeCount().Uint64() - 1; rangeIndex > maxIndex {
+		rangeIndex = maxIndex
+	}
+
+	userInfo := farmContract.GetUserInfo(pool, account)
+	rewardInfo := userInfo.GetHolderRewardInfo(token)
+	rangePerShare := dist.GetHolderRewardPerShare(token, rangeIndex)
+
+	pending := new(big.Int).Add(rewardInfo.Reward, new(big.Int).Sub(rangePerShare, rewardInfo.RewardDebt))
+	if pending.Cmp(big.NewInt(0)) < 0 {
+		pending = big.NewInt(0)
+	}
+	return (*hexutil.Big)(pending), nil
+}
+
+// GetAncestors implements farm_getAncestors, walking ParentOf up to depth
+// steps (or until the null/burn address) the same way updateAchievement's
+// forefather walk does.
+func (api *FarmAPI) GetAncestors(ctx context.Context, account common.Address, depth hexutil.Uint64, blockNrOrHash rpc.BlockNumberOrHash) ([]common.Address, error) {
+	_, _, addressTreeContract, err := api.stateAt(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+
+	maxDepth := uint64(depth)
+	if maxDepth == 0 || maxDepth > TreeHeightMaxLimit {
+		maxDepth = TreeHeightMaxLimit
+	}
+
+	ancestors := make([]common.Address, 0, maxDepth)
+	parent := account
+	for i := uint64(0); i < maxDepth; i++ {
+		next, err := addressTreeContract.ParentOf(parent)
+		if err != nil {
+			return nil, err
+		}
+		if next == common.HexToAddress(NullAddress) || next == common.HexToAddress(BurnAddress) {
+			break
+		}
+		ancestors = append(ancestors, next)
+		parent = next
+	}
+	return ancestors, nil
+}
@@ -0,0 +1,20 @@
+package farms
+
+import "github.com/ethereum/go-ethereum/metrics"
+
+// Timers covering updateAchievement and its sub-steps, replacing the old
+// BenchMarkPrint-gated fmt.Printf timing blocks with an always-on view
+// operators can graph without a recompile.
+var (
+	updateAchievementTimer = metrics.NewRegisteredTimer("farm/distribution/updateAchievement", nil)
+	ancestorWalkTimer      = metrics.NewRegisteredTimer("farm/distribution/ancestorWalk", nil)
+	userInfoOfTimer        = metrics.NewRegisteredTimer("farm/distribution/userInfoOf", nil)
+	childrenOfTimer        = metrics.NewRegisteredTimer("farm/distribution/childrenOf", nil)
+	innerLoopTimer         = metrics.NewRegisteredTimer("farm/distribution/innerLoop", nil)
+	communityPowerTimer    = metrics.NewRegisteredTimer("farm/distribution/communityPower", nil)
+	commitTimer            = metrics.NewRegisteredTimer("farm/distribution/commit", nil)
+
+	transfersProcessedCounter = metrics.NewRegisteredCounter("farm/distribution/transfersProcessed", nil)
+	rangeTouchedMeter         = metrics.NewRegisteredMeter("farm/distribution/rangeTouched", nil)
+	ancestorWalkDepthSample   = metrics.NewRegisteredHistogram("farm/distribution/ancestorWalkDepth", nil, metrics.NewExpDecaySample(1028, 0.015))
+)
@@ -2,7 +2,6 @@ package farms
 
 import (
 	"context"
-	"fmt"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -26,19 +25,27 @@ const (
 	// ActivePowerMultipleMaxLimit unit: ETHER
 	ActivePowerMultipleMaxLimit = uint64(10000)
 	TreeHeightMaxLimit          = 200
-
-	BenchMarkPrint = false
 )
 
+// erc20BalanceCaller is the subset of ethapi.PublicBlockChainAPI's surface
+// balanceOf needs, pulled out so the testvectors harness can substitute a
+// stub returning scripted balanceOf results without standing up a full EVM.
+type erc20BalanceCaller interface {
+	Call(ctx context.Context, args ethapi.TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *ethapi.StateOverride) (hexutil.Bytes, error)
+}
+
 type RangeInfo struct {
 	rangeIndex      uint64
 	totalCount      *big.Int // uint32
 	emptyRangeCount *big.Int // uint24
 }
 
+func (r *RangeInfo) TotalCount() *big.Int      { return r.totalCount }
+func (r *RangeInfo) EmptyRangeCount() *big.Int { return r.emptyRangeCount }
+
 type PoolDistribution struct {
 	state               *state.StateDB
-	ethAPI              *ethapi.PublicBlockChainAPI
+	ethAPI              erc20BalanceCaller
 	erc20ABI            abi.ABI
 	farmContract        *contract.FarmContract
 	addressTreeContract *contract.AddressTreeContract
@@ -52,11 +59,12 @@ type PoolDistribution struct {
 	rewardPerShares         *map[common.Address][]byte
 	rewardPerSharesSlot     *map[common.Address]common.Hash
 
-	isFork0815         bool
-	blockBalanceCaches map[common.Address]*big.Int
+	isFork0815 bool
+	cache      *distributionCache
+	tracer     Tracer
 }
 
-func newTokenHolderDistribution(state *state.StateDB, ethAPI *ethapi.PublicBlockChainAPI, farmContract *contract.FarmContract, addressTreeContract *contract.AddressTreeContract, pool common.Address, poolInfo *contract.PoolInfo, isFork0815 bool) *PoolDistribution {
+func newTokenHolderDistribution(state *state.StateDB, ethAPI erc20BalanceCaller, farmContract *contract.FarmContract, addressTreeContract *contract.AddressTreeContract, pool common.Address, poolInfo *contract.PoolInfo, isFork0815 bool) *PoolDistribution {
 
 	ercABI, err := abi.JSON(strings.NewReader(systemcontracts.ERC20ABI))
 	if err != nil {
@@ -90,7 +98,7 @@ func newTokenHolderDistribution(state *state.StateDB, ethAPI *ethapi.PublicBlock
 		rewardPerShares:         &map[common.Address][]byte{},
 		rewardPerSharesSlot:     &map[common.Address]common.Hash{},
 		isFork0815:              isFork0815,
-		blockBalanceCaches:      map[common.Address]*big.Int{},
+		cache:                   newDistributionCache(state.IntermediateRoot(isFork0815)),
 	}
 
 	for _, address := range poolInfo.GetRewardTokens() {
@@ -114,7 +122,22 @@ func newTokenHolderDistribution(state *state.StateDB, ethAPI *ethapi.PublicBlock
 	return r
 }
 
-func (d *PoolDistribution) putTransferEventLog(blockHash common.Hash, from common.Address, to common.Address, amount *big.Int) error {
+// NewPoolDistribution builds a PoolDistribution the same way Farm wires one
+// internally, exported so callers outside this package (the farm_ RPC
+// namespace, the testvectors conformance harness) can construct one
+// directly against historical or scripted state.
+func NewPoolDistribution(state *state.StateDB, ethAPI erc20BalanceCaller, farmContract *contract.FarmContract, addressTreeContract *contract.AddressTreeContract, pool common.Address, poolInfo *contract.PoolInfo, isFork0815 bool) *PoolDistribution {
+	return newTokenHolderDistribution(state, ethAPI, farmContract, addressTreeContract, pool, poolInfo, isFork0815)
+}
+
+// PutTransferEventLog replays a single ERC20 Transfer event through the same
+// arithmetic Farm.FinalizeBlock uses, exported for the testvectors
+// conformance harness.
+func (d *PoolDistribution) PutTransferEventLog(blockNumber *big.Int, blockHash common.Hash, from, to common.Address, amount *big.Int) error {
+	return d.putTransferEventLog(blockNumber, blockHash, from, to, amount)
+}
+
+func (d *PoolDistribution) putTransferEventLog(blockNumber *big.Int, blockHash common.Hash, from common.Address, to common.Address, amount *big.Int) error {
 
 	if from == to {
 		return nil
@@ -126,7 +149,7 @@ func (d *PoolDistribution) putTransferEventLog(blockHash common.Hash, from commo
 			return err
 		}
 		fromCurrentBalance := new(big.Int).Sub(fromOriginBalance, amount)
-		if err := d.updateAccountBalance(from, fromOriginBalance, fromCurrentBalance); err != nil {
+		if err := d.updateAccountBalance(blockNumber, from, fromOriginBalance, fromCurrentBalance); err != nil {
 			return err
 		}
 	}
@@ -137,7 +160,7 @@ func (d *PoolDistribution) putTransferEventLog(blockHash common.Hash, from commo
 			return err
 		}
 		toCurrentBalance := new(big.Int).Add(toOriginBalance, amount)
-		if err := d.updateAccountBalance(to, toOriginBalance, toCurrentBalance); err != nil {
+		if err := d.updateAccountBalance(blockNumber, to, toOriginBalance, toCurrentBalance); err != nil {
 			return err
 		}
 	}
@@ -227,9 +250,38 @@ func (d *PoolDistribution) UpdateRewardPerShares(rewardToken common.Address, hol
 	}
 }
 
+// cachedParentOf wraps addressTreeContract.ParentOf with d.cache so the
+// forefather walk in updateAchievement doesn't re-read (or re-fetch over
+// the anchor RPC) the same parent slot once per transfer in a block.
+func (d *PoolDistribution) cachedParentOf(account common.Address) (common.Address, error) {
+	if parent, ok := d.cache.getParent(account); ok {
+		return parent, nil
+	}
+	parent, err := d.addressTreeContract.ParentOf(account)
+	if err != nil {
+		return common.Address{}, err
+	}
+	d.cache.setParent(account, parent)
+	return parent, nil
+}
+
+// cachedChildrenOf wraps addressTreeContract.ChildrenOf with d.cache for the
+// same reason as cachedParentOf.
+func (d *PoolDistribution) cachedChildrenOf(account common.Address) (*[]common.Address, error) {
+	if children, ok := d.cache.getChildren(account); ok {
+		return children, nil
+	}
+	children, err := d.addressTreeContract.ChildrenOf(account)
+	if err != nil {
+		return nil, err
+	}
+	d.cache.setChildren(account, children)
+	return children, nil
+}
+
 func (d *PoolDistribution) balanceOf(blockHash common.Hash, account common.Address) (*big.Int, error) {
 
-	if balance, isExisted := d.blockBalanceCaches[account]; isExisted && d.isFork0815 {
+	if balance, ok := d.cache.getBalance(account, d.isFork0815); ok {
 		return balance, nil
 	}
 
@@ -260,14 +312,14 @@ func (d *PoolDistribution) balanceOf(blockHash common.Hash, account common.Addre
 	if err := d.erc20ABI.UnpackIntoInterface(&ret0, method, result); err != nil {
 		return nil, err
 	}
-	d.blockBalanceCaches[account] = ret0
+	d.cache.setBalance(account, ret0)
 
 	return ret0, nil
 }
 
-func (d *PoolDistribution) updateAccountBalance(from common.Address, originAmount *big.Int, currentAmount *big.Int) error {
+func (d *PoolDistribution) updateAccountBalance(blockNumber *big.Int, from common.Address, originAmount *big.Int, currentAmount *big.Int) error {
 
-	d.blockBalanceCaches[from] = currentAmount
+	d.cache.setBalance(from, currentAmount)
 
 	fromOriginRIndex := new(big.Int).Div(originAmount, d.poolInfo.GetRangeInterval()).Uint64()
 	fromCurrentRIndex := new(big.Int).Div(currentAmount, d.poolInfo.GetRangeInterval()).Uint64()
@@ -316,52 +368,61 @@ func (d *PoolDistribution) updateAccountBalance(from common.Address, originAmoun
 		}
 	}
 
-	if err := d.updateAchievement(from, originAmount, currentAmount); err != nil {
+	if err := d.updateAchievement(blockNumber, from, originAmount, currentAmount); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (d *PoolDistribution) updateAchievement(from common.Address, originAmount *big.Int, currentAmount *big.Int) error {
-
+func (d *PoolDistribution) updateAchievement(blockNumber *big.Int, from common.Address, originAmount *big.Int, currentAmount *big.Int) error {
 	start := time.Now()
-	parentCount := 0
+	defer updateAchievementTimer.UpdateSince(start)
+	transfersProcessedCounter.Inc(1)
 
-	if BenchMarkPrint {
-		fmt.Printf("\n")
-		fmt.Printf("------------------------------------------------------------------------------------------------\n")
-		fmt.Printf("-                   PoolDistribution.updateAchievement BenchMarkTest Logs                      -\n")
-		fmt.Printf("------------------------------------------------------------------------------------------------\n")
+	var span Span
+	if d.tracer != nil {
+		_, span = d.tracer.Start(context.Background(), "farm.updateAchievement")
+		defer span.End()
 	}
 
+	ancestorWalkStart := time.Now()
 	forFathersList := make([]common.Address, TreeHeightMaxLimit)
 	parent := from
+	parentCount := 0
 	for i := 0; i < len(forFathersList) && parent != common.HexToAddress(NullAddress) && parent != common.HexToAddress(BurnAddress); i++ {
 		forFathersList[i] = parent
-		parent = d.addressTreeContract.ParentOf(parent)
+		next, err := d.cachedParentOf(parent)
+		if err != nil {
+			return err
+		}
+		parent = next
 		parentCount++
 	}
-
-	if BenchMarkPrint {
-		fmt.Printf("- Get Forfathers\t time:%dms\tparent count:%d\n", time.Since(start).Milliseconds(), parentCount)
+	ancestorWalkTimer.UpdateSince(ancestorWalkStart)
+	ancestorWalkDepthSample.Update(int64(parentCount))
+	if span != nil {
+		span.SetAttributes(map[string]interface{}{"ancestorWalkDepth": parentCount})
 	}
 
-	__cpuTimes := map[string]int64{}
 	for childIndex := 0; childIndex < len(forFathersList)-1; childIndex++ {
 		child := forFathersList[childIndex]
 		parent = forFathersList[childIndex+1]
 		if parent == common.HexToAddress(NullAddress) || parent == common.HexToAddress(BurnAddress) {
 			break
 		}
+		rangeTouchedMeter.Mark(1)
 
-		__userInfoOfStart := time.Now()
+		userInfoOfStart := time.Now()
 		parentInfo := d.farmContract.GetUserInfo(d.poolAddress, parent)
-		__cpuTimes["userInfoOf"] += time.Since(__userInfoOfStart).Microseconds()
+		userInfoOfTimer.UpdateSince(userInfoOfStart)
 
-		__childrenOfStart := time.Now()
-		children := d.addressTreeContract.ChildrenOf(parent)
-		__cpuTimes["childrenOf"] += time.Since(__childrenOfStart).Microseconds()
+		childrenOfStart := time.Now()
+		children, err := d.cachedChildrenOf(parent)
+		if err != nil {
+			return err
+		}
+		childrenOfTimer.UpdateSince(childrenOfStart)
 
 		childrenHolds := parentInfo.GetChildrenHoldAmount()
 		if len(childrenHolds) < len(*children) {
@@ -379,7 +440,7 @@ func (d *PoolDistribution) updateAchievement(from common.Address, originAmount *
 			}
 		}
 
-		__innerLoopStart := time.Now()
+		innerLoopStart := time.Now()
 		for _, rewardToken := range d.poolInfo.GetRewardTokens() {
 			rewardInfo := parentInfo.GetCommunityRewardInfo(rewardToken)
 			communityPerShare := d.farmContract.GetCommunityAccRewardPerShare(d.poolAddress, rewardToken)
@@ -402,13 +463,13 @@ func (d *PoolDistribution) updateAchievement(from common.Address, originAmount *
 				rewardInfo.RewardDebt,
 			)
 		}
-		__cpuTimes["innerLoop"] += time.Since(__innerLoopStart).Microseconds()
+		innerLoopTimer.UpdateSince(innerLoopStart)
 
-		__currentCommunityPower := time.Now()
-		currentActivePower := communityPower(&childrenHolds)
-		__cpuTimes["currentCommunityPower"] += time.Since(__currentCommunityPower).Microseconds()
+		communityPowerStart := time.Now()
+		currentActivePower := d.evaluateCommunityPower(blockNumber, &childrenHolds)
+		communityPowerTimer.UpdateSince(communityPowerStart)
 
-		__commitStart := time.Now()
+		commitStart := time.Now()
 		d.poolInfo.SetCommunityTotalPower(
 			new(big.Int).Sub(
 				new(big.Int).Add(
@@ -420,23 +481,26 @@ func (d *PoolDistribution) updateAchievement(from common.Address, originAmount *
 		)
 		parentInfo.SetChildrenHoldAmount(childrenHolds)
 		parentInfo.SetCommunityPower(currentActivePower)
-		__cpuTimes["commit"] += time.Since(__commitStart).Microseconds()
-	}
-
-	if BenchMarkPrint {
-		fmt.Printf("---- GetUserInfoOf\t time:%.2f ms\n", float32(__cpuTimes["userInfoOf"])/1000)
-		fmt.Printf("---- ChildrenOf\t\t time:%.2f ms\n", float32(__cpuTimes["childrenOf"])/1000)
-		fmt.Printf("---- InnerLoop\t\t time:%.2f ms\n", float32(__cpuTimes["innerLoop"])/1000)
-		fmt.Printf("---- CommunityPower\t time:%.2f ms\n", float32(__cpuTimes["currentCommunityPower"])/1000)
-		fmt.Printf("---- ParentInfo.Commit\t time:%.2f ms\n", float32(__cpuTimes["commit"])/1000)
-		fmt.Printf("- UpdateAchievement\t time:%d ms\n", time.Since(start).Milliseconds())
-		fmt.Printf("\n")
-		start = time.Now()
+		commitTimer.UpdateSince(commitStart)
 	}
 
 	return nil
 }
 
+// evaluateCommunityPower dispatches to a pool's configured CommunityPowerPolicy
+// once blockNumber has reached its activation height, falling back to the
+// original hard-coded communityPower curve otherwise (or when the pool has
+// never had an activation height configured, signalled by a zero value,
+// since real activation heights are always a post-genesis block).
+func (d *PoolDistribution) evaluateCommunityPower(blockNumber *big.Int, holdAmounts *[]*big.Int) *big.Int {
+	activationHeight := d.poolInfo.GetCommunityPowerActivationHeight()
+	if blockNumber == nil || activationHeight.Sign() == 0 || blockNumber.Cmp(activationHeight) < 0 {
+		return communityPower(holdAmounts)
+	}
+	policy := communityPowerPolicyFor(d.poolInfo.GetCommunityPowerPolicySelector(), d.poolInfo.GetCommunityPowerPolicyParams())
+	return policy.Evaluate(*holdAmounts)
+}
+
 func communityPower(holdAmounts *[]*big.Int) *big.Int {
 
 	maxHoldAmount := uint64(0)
@@ -0,0 +1,70 @@
+package farms
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/consensus/farms/contract"
+	"github.com/ethereum/go-ethereum/core/systemcontracts/events"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// EnablePowerChangeEvents turns PowerChange emission on or off for f - the
+// farm-level config flag SetHolderTotalPower/SetCommunityTotalPower check
+// before reporting anything. Off by default, so a Farm that never calls
+// this pays nothing beyond the check itself.
+func (f *Farm) EnablePowerChangeEvents(enabled bool) {
+	f.powerEventBus.Enable(enabled)
+}
+
+// EnableSyntheticPowerChangeLogs turns on appending a synthetic log to the
+// block's last receipt for every PowerChange FinalizeBlock's pass over a
+// block produces, in addition to (not instead of) the subscription feed -
+// a separate flag from EnablePowerChangeEvents because generating receipt
+// logs has its own cost (receipt bloom/size) a caller may want independent
+// of whether anything is subscribed to the feed. Calling this without also
+// calling EnablePowerChangeEvents(true) has no effect, since PowerChange
+// emission itself stays off.
+func (f *Farm) EnableSyntheticPowerChangeLogs(enabled bool) {
+	f.appendSyntheticPowerLogs = enabled
+}
+
+// SubscribePowerChangeEvent registers ch to receive every PowerChange f's
+// pools report while EnablePowerChangeEvents(true) is in effect.
+func (f *Farm) SubscribePowerChangeEvent(ch chan<- contract.PowerChange) event.Subscription {
+	return f.powerEventBus.Subscribe(ch)
+}
+
+// appendSyntheticPowerChangeLogs drains whatever PowerChanges this block's
+// processing collected and appends one synthetic *types.Log per change to
+// the block's last receipt, so an eth_getLogs subscriber filtering on the
+// farm contract address sees power changes without diffing state. Appending
+// to the last receipt, rather than the one whose transaction actually
+// triggered the change, is the simplest honest choice available here: a
+// PowerChange is a side effect of FinalizeBlock's own post-processing, not
+// of any single transaction, so there's no more "correct" receipt to
+// attribute it to.
+//
+// This reuses events.PowerChanged for the log shape - the same
+// topic/constructor convention core/systemcontracts/events already
+// establishes for AddressTree and Farm hook state changes - but appends the
+// log directly rather than going through StateDB.AddLog: AddLog attributes a
+// log to whichever transaction is currently executing, and by the time
+// FinalizeBlock runs every transaction in the block has already finished, so
+// there is no "current" transaction for AddLog's bookkeeping to attach to.
+func (f *Farm) appendSyntheticPowerChangeLogs(header *types.Header, receipts *[]*types.Receipt) {
+	if !f.appendSyntheticPowerLogs {
+		return
+	}
+	changes := f.powerEventBus.Drain()
+	if len(changes) == 0 || len(*receipts) == 0 {
+		return
+	}
+	last := (*receipts)[len(*receipts)-1]
+	for _, change := range changes {
+		l := events.PowerChanged(change.Farm, change.Pool, big.NewInt(int64(change.Kind)), change.Old, change.New)
+		l.BlockNumber = header.Number.Uint64()
+		l.BlockHash = header.Hash()
+		last.Logs = append(last.Logs, l)
+	}
+}
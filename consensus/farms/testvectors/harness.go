@@ -0,0 +1,183 @@
+package testvectors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/farms"
+	"github.com/ethereum/go-ethereum/consensus/farms/contract"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
+	"github.com/ethereum/go-ethereum/core/systemcontracts/anchor"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	farmAddress         = common.HexToAddress("0x0000000000000000000000000000000000f000")
+	poolAddress         = common.HexToAddress("0x0000000000000000000000000000000000f001")
+	addressTreeContract = common.HexToAddress(systemcontracts.AddressTreeContract)
+)
+
+// LoadVectors reads every *.json file in dir and decodes it as a Vector.
+func LoadVectors(dir string) ([]*Vector, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var vectors []*Vector
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		raw, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var v Vector
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Name(), err)
+		}
+		if v.Name == "" {
+			v.Name = e.Name()
+		}
+		vectors = append(vectors, &v)
+	}
+	return vectors, nil
+}
+
+// scriptedBalances is the stub ethapi.PublicBlockChainAPI.Call sees: it
+// decodes the ERC20 balanceOf(account) calldata by hand and answers from a
+// running balance table the harness updates as events are replayed, rather
+// than executing anything through a real EVM.
+type scriptedBalances struct {
+	balances map[common.Address]*big.Int
+}
+
+func (s *scriptedBalances) balanceOf(account common.Address) *big.Int {
+	if b, ok := s.balances[account]; ok {
+		return b
+	}
+	return big.NewInt(0)
+}
+
+// Call implements the erc20BalanceCaller interface PoolDistribution.balanceOf
+// calls through. args.Data is the ABI-encoded balanceOf(address) call;
+// balanceOf(address)'s selector is irrelevant here since this stub only
+// ever receives that one call shape.
+func (s *scriptedBalances) Call(ctx context.Context, args ethapi.TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *ethapi.StateOverride) (hexutil.Bytes, error) {
+	data := *args.Data
+	if len(data) < 4+32 {
+		return nil, fmt.Errorf("testvectors: malformed balanceOf calldata (%d bytes)", len(data))
+	}
+	account := common.BytesToAddress(data[4+12 : 4+32])
+	return common.LeftPadBytes(s.balanceOf(account).Bytes(), 32), nil
+}
+
+// Diff is a single mismatch between a vector's expected post-state and what
+// the replay actually produced.
+type Diff struct {
+	Field    string
+	Expected string
+	Actual   string
+}
+
+func (d Diff) String() string {
+	return fmt.Sprintf("%s: expected %s, got %s", d.Field, d.Expected, d.Actual)
+}
+
+// Run replays v against a fresh in-memory state and returns every mismatch
+// against v.Expected (empty when the vector passes).
+func Run(v *Vector) ([]Diff, error) {
+	db := state.NewDatabase(rawdb.NewMemoryDatabase())
+	st, err := state.New(common.Hash{}, db, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rel := range v.Tree {
+		st.SetState(addressTreeContract, anchor.ParentSlotHash(rel.Child), common.BytesToHash(rel.Parent.Bytes()))
+		st.SetState(addressTreeContract, anchor.DepthSlotHash(rel.Child), common.BigToHash(big.NewInt(1)))
+		existing := st.GetRawState(addressTreeContract, anchor.ChildrenSlotHash(rel.Parent))
+		st.SetRawState(addressTreeContract, anchor.ChildrenSlotHash(rel.Parent), append(common.CopyBytes(existing), rel.Child.Bytes()...))
+	}
+
+	rangeCount := new(big.Int).SetUint64(v.PoolInfo.RangeCount)
+	rangeInterval := v.PoolInfo.RangeInterval
+	if rangeInterval == nil {
+		rangeInterval = big.NewInt(1)
+	}
+	contract.SeedPoolInfo(st, farmAddress, poolAddress, common.Address{}, rangeCount, rangeInterval, v.PoolInfo.RewardTokens, new(big.Int).SetUint64(v.PoolInfo.StartRangeIndex))
+
+	farmContract := contract.NewFarmContract(st, farmAddress, false)
+	addressTree := contract.NewAddressTreeContract(st, nil, addressTreeContract, nil, 0)
+	poolInfo := farmContract.GetPoolInfo(poolAddress)
+
+	balances := &scriptedBalances{balances: make(map[common.Address]*big.Int, len(v.Balances))}
+	for addr, amount := range v.Balances {
+		balances.balances[addr] = new(big.Int).Set(amount)
+	}
+
+	for _, seed := range v.RewardSeeds {
+		farmContract.GetUserInfo(poolAddress, seed.Account).SetHolderRewardInfo(seed.RewardToken, seed.Reward, seed.RewardDebt)
+	}
+
+	dist := farms.NewPoolDistribution(st, balances, farmContract, addressTree, poolAddress, poolInfo, v.IsFork0815)
+
+	blockNumber := v.BlockNumber
+	if blockNumber == nil {
+		blockNumber = big.NewInt(0)
+	}
+	for _, ev := range v.Events {
+		if err := dist.PutTransferEventLog(blockNumber, common.Hash{}, ev.From, ev.To, ev.Amount); err != nil {
+			return nil, fmt.Errorf("event %s->%s: %w", ev.From, ev.To, err)
+		}
+		fromBalance := new(big.Int).Sub(balances.balanceOf(ev.From), ev.Amount)
+		balances.balances[ev.From] = fromBalance
+		balances.balances[ev.To] = new(big.Int).Add(balances.balanceOf(ev.To), ev.Amount)
+	}
+	dist.Storage()
+
+	return diffState(dist, poolInfo, farmContract, v.Expected), nil
+}
+
+func diffState(dist *farms.PoolDistribution, poolInfo *contract.PoolInfo, farmContract *contract.FarmContract, expected ExpectedState) []Diff {
+	var diffs []Diff
+
+	for _, r := range expected.Ranges {
+		info := dist.GetRangeInfo(r.Index)
+		if info.TotalCount().Cmp(r.TotalCount) != 0 {
+			diffs = append(diffs, Diff{fmt.Sprintf("range[%d].totalCount", r.Index), r.TotalCount.String(), info.TotalCount().String()})
+		}
+		if info.EmptyRangeCount().Cmp(r.EmptyRangeCount) != 0 {
+			diffs = append(diffs, Diff{fmt.Sprintf("range[%d].emptyRangeCount", r.Index), r.EmptyRangeCount.String(), info.EmptyRangeCount().String()})
+		}
+	}
+
+	if expected.HolderTotalPower != nil && poolInfo.GetHolderTotalPower().Cmp(expected.HolderTotalPower) != 0 {
+		diffs = append(diffs, Diff{"holderTotalPower", expected.HolderTotalPower.String(), poolInfo.GetHolderTotalPower().String()})
+	}
+	if expected.CommunityTotalPower != nil && poolInfo.GetCommunityTotalPower().Cmp(expected.CommunityTotalPower) != 0 {
+		diffs = append(diffs, Diff{"communityTotalPower", expected.CommunityTotalPower.String(), poolInfo.GetCommunityTotalPower().String()})
+	}
+
+	for _, a := range expected.Accounts {
+		userInfo := farmContract.GetUserInfo(poolAddress, a.Account)
+		rewardInfo := userInfo.GetHolderRewardInfo(a.RewardToken)
+		if rewardInfo.Reward.Cmp(a.Reward) != 0 {
+			diffs = append(diffs, Diff{fmt.Sprintf("%s.reward", a.Account), a.Reward.String(), rewardInfo.Reward.String()})
+		}
+		if rewardInfo.RewardDebt.Cmp(a.RewardDebt) != 0 {
+			diffs = append(diffs, Diff{fmt.Sprintf("%s.rewardDebt", a.Account), a.RewardDebt.String(), rewardInfo.RewardDebt.String()})
+		}
+	}
+
+	return diffs
+}
@@ -0,0 +1,26 @@
+package testvectors
+
+import "testing"
+
+func TestVectors(t *testing.T) {
+	vectors, err := LoadVectors("testdata")
+	if err != nil {
+		t.Fatalf("loading vectors: %v", err)
+	}
+	if len(vectors) == 0 {
+		t.Fatal("no vectors found under testdata")
+	}
+
+	for _, v := range vectors {
+		v := v
+		t.Run(v.Name, func(t *testing.T) {
+			diffs, err := Run(v)
+			if err != nil {
+				t.Fatalf("running vector: %v", err)
+			}
+			for _, d := range diffs {
+				t.Error(d)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+// Package testvectors runs PoolDistribution against scripted JSON vectors,
+// the same way the Filecoin test-vectors submodule lets third-party
+// re-implementations validate distribution arithmetic without standing up a
+// full chain.
+package testvectors
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TreeRelation seeds a single parent/child edge in the address tree before
+// a vector's events are replayed, so updateAchievement's forefather walk
+// and communityPower have something to act on.
+type TreeRelation struct {
+	Parent common.Address `json:"parent"`
+	Child  common.Address `json:"child"`
+}
+
+// PoolInfoConfig is the subset of PoolInfo a vector needs to configure
+// before replaying events.
+type PoolInfoConfig struct {
+	RangeInterval   *big.Int         `json:"rangeInterval"`
+	RangeCount      uint64           `json:"rangeCount"`
+	RewardTokens    []common.Address `json:"rewardTokens"`
+	StartRangeIndex uint64           `json:"startRangeIndex"`
+}
+
+// RewardInfoSeed pre-populates a holder's reward/rewardDebt for a single
+// reward token before a vector's events are replayed, used to reach reward
+// states (e.g. a negative pending delta) that a fresh pool can't produce on
+// its own within a single vector.
+type RewardInfoSeed struct {
+	Account     common.Address `json:"account"`
+	RewardToken common.Address `json:"rewardToken"`
+	Reward      *big.Int       `json:"reward"`
+	RewardDebt  *big.Int       `json:"rewardDebt"`
+}
+
+// TransferEvent is a single scripted ERC20 Transfer(from, to, amount) the
+// harness replays through PoolDistribution.putTransferEventLog.
+type TransferEvent struct {
+	From   common.Address `json:"from"`
+	To     common.Address `json:"to"`
+	Amount *big.Int       `json:"amount"`
+}
+
+// RangeExpectation is the expected post-state of a single distribution
+// range.
+type RangeExpectation struct {
+	Index           uint64   `json:"index"`
+	TotalCount      *big.Int `json:"totalCount"`
+	EmptyRangeCount *big.Int `json:"emptyRangeCount"`
+}
+
+// AccountRewardExpectation is the expected post-state of a single account's
+// holder reward info for a single reward token.
+type AccountRewardExpectation struct {
+	Account     common.Address `json:"account"`
+	RewardToken common.Address `json:"rewardToken"`
+	Reward      *big.Int       `json:"reward"`
+	RewardDebt  *big.Int       `json:"rewardDebt"`
+}
+
+// ExpectedState is what Run diffs the post-replay PoolDistribution/PoolInfo
+// state against.
+type ExpectedState struct {
+	Ranges              []RangeExpectation         `json:"ranges"`
+	HolderTotalPower    *big.Int                   `json:"holderTotalPower"`
+	CommunityTotalPower *big.Int                   `json:"communityTotalPower"`
+	Accounts            []AccountRewardExpectation `json:"accounts"`
+}
+
+// Vector is a single conformance test vector: an initial address-tree
+// shape, a pool config, a balanceOf script, an ordered event list, and the
+// expected post-state.
+type Vector struct {
+	Name       string `json:"name"`
+	IsFork0815 bool   `json:"isFork0815"`
+	// BlockNumber is the height events are replayed at, relevant only when
+	// PoolInfo's community-power policy has an activation height configured.
+	// Defaults to 0 (before any real activation height) when omitted.
+	BlockNumber *big.Int       `json:"blockNumber"`
+	Tree        []TreeRelation `json:"tree"`
+	PoolInfo    PoolInfoConfig `json:"poolInfo"`
+	// Balances is each account's balanceOf result as of the start of the
+	// script; the harness updates its own running copy as transfer events
+	// are replayed, so a vector only needs to state the starting point.
+	Balances    map[common.Address]*big.Int `json:"balances"`
+	RewardSeeds []RewardInfoSeed            `json:"rewardSeeds"`
+	Events      []TransferEvent             `json:"events"`
+	Expected    ExpectedState               `json:"expected"`
+}
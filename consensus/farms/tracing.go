@@ -0,0 +1,31 @@
+package farms
+
+import "context"
+
+// Span is the minimal span surface updateAchievement needs: a handle to
+// close and a couple of attributes to attach. An OpenTelemetry span (wrapped
+// to satisfy this interface) is the expected backend, but nothing in this
+// package imports OpenTelemetry directly so a caller can wire in whatever
+// tracing library it already uses.
+type Span interface {
+	SetAttributes(attrs map[string]interface{})
+	End()
+}
+
+// Tracer starts a Span for a named operation. SetTracer on Farm leaves it nil
+// by default, which keeps tracing fully opt-in: every call site below checks
+// for a nil Tracer before doing any work.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// SetTracer configures the tracer used for updateAchievement spans on f and
+// every PoolDistribution it constructs from this point on.
+func (f *Farm) SetTracer(tracer Tracer) {
+	f.tracer = tracer
+}
+
+// SetTracer configures the tracer used for updateAchievement spans on d.
+func (d *PoolDistribution) SetTracer(tracer Tracer) {
+	d.tracer = tracer
+}
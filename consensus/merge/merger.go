@@ -0,0 +1,105 @@
+// Package merge tracks the Anchor network's one-way transition from
+// Anchor-signed blocks to beacon-driven ones, the same PreMerge/TDDReached/
+// PoSFinalized state machine go-ethereum's own merge used, scaled down to
+// what consensus/beacon.Beacon and eth/catalyst need to decide which rules
+// currently apply.
+package merge
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// transitionStatusKey is the single key Merger's state is persisted under,
+// so a restarted node doesn't forget it already crossed the terminal total
+// difficulty before a clean re-sync from genesis would notice again.
+var transitionStatusKey = []byte("anchor-merge-transition-status")
+
+// Status is one stage of the one-way PreMerge -> TDDReached -> PoSFinalized
+// transition. It never moves backwards.
+type Status byte
+
+const (
+	// PreMerge is every Anchor network before it reaches its configured
+	// TerminalTotalDifficulty: Beacon defers every decision to the
+	// embedded Anchor engine unchanged.
+	PreMerge Status = iota
+	// TDDReached means the terminal total difficulty has been crossed but
+	// the beacon chain hasn't finalized a post-merge block yet; Beacon
+	// accepts externally-built payloads but still tolerates an in-flight
+	// Anchor-signed block landing on top of the transition block.
+	TDDReached
+	// PoSFinalized means a finalized post-merge block exists; Beacon no
+	// longer accepts Anchor-signed blocks at all.
+	PoSFinalized
+)
+
+// Merger tracks the Anchor network's merge transition and persists it so a
+// restart doesn't regress consensus/beacon.Beacon back to pre-merge rules.
+type Merger struct {
+	db ethdb.Database
+
+	mu     sync.RWMutex
+	status Status
+}
+
+// NewMerger restores a Merger's status from db, defaulting to PreMerge for a
+// database that has never seen a transition.
+func NewMerger(db ethdb.Database) *Merger {
+	m := &Merger{db: db}
+	if raw, err := db.Get(transitionStatusKey); err == nil && len(raw) == 1 {
+		m.status = Status(raw[0])
+	}
+	return m
+}
+
+// ReachTTD records that the terminal total difficulty has been crossed. It
+// is a no-op once PoSFinalized has already been reached, since the
+// transition never moves backwards.
+func (m *Merger) ReachTTD() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.status >= TDDReached {
+		return
+	}
+	m.setStatus(TDDReached)
+	log.Info("Terminal total difficulty reached, Beacon now accepts external payloads")
+}
+
+// FinalizePoS records that a finalized post-merge block exists, after which
+// Beacon stops accepting Anchor-signed blocks entirely.
+func (m *Merger) FinalizePoS() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.status == PoSFinalized {
+		return
+	}
+	m.setStatus(PoSFinalized)
+	log.Info("Proof-of-stake finalized, Beacon now rejects Anchor-signed blocks")
+}
+
+// setStatus updates the in-memory status and persists it; callers must hold
+// m.mu.
+func (m *Merger) setStatus(status Status) {
+	m.status = status
+	if err := m.db.Put(transitionStatusKey, []byte{byte(status)}); err != nil {
+		log.Error("Failed to persist merge transition status", "status", status, "err", err)
+	}
+}
+
+// TDDReached reports whether the terminal total difficulty has been
+// crossed, regardless of whether PoS has since been finalized.
+func (m *Merger) TDDReached() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status >= TDDReached
+}
+
+// PoSFinalized reports whether a finalized post-merge block exists.
+func (m *Merger) PoSFinalized() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status == PoSFinalized
+}
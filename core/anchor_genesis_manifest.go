@@ -0,0 +1,124 @@
+// Copyright 2014 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/anchor_network"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// AnchorGenesisManifest is the canonical, signed description of an AnchorNet
+// chain's genesis: what a compromised anchor RPC could otherwise lie about
+// by feeding DefaultAnchorNetGenesisBlock an arbitrary AnchorNetworkInfo and
+// forkBlockHash.
+type AnchorGenesisManifest struct {
+	ChainID            *big.Int
+	ForkBlockNumber    *big.Int
+	ForkBlockHash      common.Hash
+	ForkBlockTimestamp uint64
+	GenesisAddress     common.Address
+	ManagerAddress     common.Address
+	AllocRoot          common.Hash
+}
+
+// signedAnchorGenesisManifest is the wire format a manifest is distributed
+// and persisted in: the canonical RLP encoding of Manifest, plus the k-of-n
+// secp256k1 signatures over its hash that VerifyAnchorGenesisManifest
+// checks against params.AnchorConfig's pinned signer set.
+type signedAnchorGenesisManifest struct {
+	Manifest   AnchorGenesisManifest
+	Signatures [][]byte
+}
+
+// AnchorManifestError reports that a previously-accepted genesis manifest no
+// longer validates against the node's currently pinned signer set, e.g.
+// after governance rotates the manager keys. It is returned by
+// SetupGenesisBlockWithSetup as a distinct type so a caller can tell this
+// apart from an ordinary GenesisMismatchError or ConfigCompatError.
+type AnchorManifestError struct {
+	Hash common.Hash
+	Err  error
+}
+
+func (e *AnchorManifestError) Error() string {
+	return fmt.Sprintf("genesis: anchor manifest for stored genesis %s no longer validates: %v", e.Hash, e.Err)
+}
+
+func (e *AnchorManifestError) Unwrap() error { return e.Err }
+
+// sigHash is the hash every signature in a signedAnchorGenesisManifest
+// covers: the canonical RLP encoding of the manifest itself, independent of
+// however many signatures end up attached to it.
+func (m *AnchorGenesisManifest) sigHash() (common.Hash, error) {
+	enc, err := rlp.EncodeToBytes(m)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(enc), nil
+}
+
+// VerifyAnchorGenesisManifest decodes manifest and checks that at least
+// cfg.ManifestThreshold of its signatures recover to distinct addresses in
+// cfg.ManifestSigners, returning the AnchorNetworkInfo the manifest attests
+// to once that threshold is met, plus the manifest itself so a caller can
+// also check the fields AnchorNetworkInfo doesn't carry - ForkBlockHash,
+// ForkBlockTimestamp, AllocRoot - against whatever it was separately given.
+func VerifyAnchorGenesisManifest(manifest []byte, cfg *params.AnchorConfig) (*anchor_network.AnchorNetworkInfo, *AnchorGenesisManifest, error) {
+	var signed signedAnchorGenesisManifest
+	if err := rlp.DecodeBytes(manifest, &signed); err != nil {
+		return nil, nil, fmt.Errorf("genesis: decoding anchor manifest: %w", err)
+	}
+
+	hash, err := signed.Manifest.sigHash()
+	if err != nil {
+		return nil, nil, fmt.Errorf("genesis: hashing anchor manifest: %w", err)
+	}
+
+	pinned := make(map[common.Address]bool, len(cfg.ManifestSigners))
+	for _, signer := range cfg.ManifestSigners {
+		pinned[signer] = true
+	}
+
+	seen := make(map[common.Address]bool, len(signed.Signatures))
+	for _, sig := range signed.Signatures {
+		pub, err := crypto.SigToPub(hash.Bytes(), sig)
+		if err != nil {
+			continue
+		}
+		signer := crypto.PubkeyToAddress(*pub)
+		if pinned[signer] {
+			seen[signer] = true
+		}
+	}
+	if len(seen) < cfg.ManifestThreshold {
+		return nil, nil, fmt.Errorf("genesis: anchor manifest has %d valid pinned signatures, need %d", len(seen), cfg.ManifestThreshold)
+	}
+
+	info := &anchor_network.AnchorNetworkInfo{
+		ChainID:         signed.Manifest.ChainID,
+		ForkBlockNumber: signed.Manifest.ForkBlockNumber,
+		GenesisAddress:  signed.Manifest.GenesisAddress,
+		ManagerAddress:  signed.Manifest.ManagerAddress,
+	}
+	return info, &signed.Manifest, nil
+}
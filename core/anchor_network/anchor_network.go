@@ -12,4 +12,53 @@ type AnchorNetworkInfo struct {
 	GenesisAddress  common.Address `json:"genesisAddress"`
 	AnchorContract  common.Address `json:"anchorContract"`
 	ManagerAddress  common.Address `json:"managerAddress"`
+
+	// BootstrapPeers lists the enode URLs of anchor peers for the
+	// AnchorSyncService gossip subscriber. AnchorSyncService does not read
+	// this field and does not dial these peers itself.
+	BootstrapPeers []string `json:"bootstrapPeers"`
+
+	// NodeCacheSize bounds the in-memory LRU of resolved address-tree nodes
+	// kept by AddressTreeContract. Zero falls back to a sane default.
+	NodeCacheSize int `json:"nodeCacheSize"`
+
+	// NegativeCacheTTLSeconds bounds how long AddressTreeContract remembers
+	// that an account is not yet registered in the tree before it will
+	// re-query the anchor RPC for it. Zero falls back to a sane default.
+	NegativeCacheTTLSeconds int64 `json:"negativeCacheTTLSeconds"`
+
+	// CheckpointHash pins a trusted anchor-chain block hash for the
+	// light-client AnchorBackend's header chain. NewLightAnchorBackend takes
+	// its checkpoint as an argument; it is not read from this field.
+	CheckpointHash common.Hash `json:"checkpointHash"`
+
+	// TerminalTotalDifficulty is the total difficulty at which this network
+	// switches from Anchor-signed blocks to beacon-driven ones. Nil or zero
+	// means the network never transitions and consensus/beacon.Beacon stays
+	// in its pre-merge mode forever.
+	TerminalTotalDifficulty *big.Int `json:"terminalTotalDifficulty"`
+
+	// BLSValidatorAddresses and BLSValidatorPubKeys describe the current
+	// epoch's t-of-n burn-proof signing committee, as committed by the
+	// anchor networks manager contract: index i of one slice is that
+	// validator's address, index i of the other its compressed G1 BLS
+	// public key. Like every other field here, both are read once by
+	// getAnchorNetworkInfo when NewAnchorContract builds the engine's anchor
+	// contract; they are not re-read at an epoch rollover.
+	BLSValidatorAddresses []common.Address `json:"blsValidatorAddresses"`
+	BLSValidatorPubKeys   map[uint8][]byte `json:"blsValidatorPubKeys"`
+
+	// BLSThreshold is t in the t-of-n scheme BLSValidatorPubKeys describes:
+	// aggregateBurnProofs needs at least this many partial shares before
+	// the aggregate signature it produces carries the scheme's intended
+	// security margin.
+	BLSThreshold int `json:"blsThreshold"`
+
+	// ConfirmationDepth is how many L1 blocks must confirm on top of a
+	// target block before l1ExchangesOfBlockNumber trusts its view of it:
+	// a short L1 reorg can otherwise make an L2 anchor block embed exchange
+	// transactions that later disappear from L1's canonical chain. Zero
+	// disables the check, pinning l1ExchangesOfBlockNumber's old behavior
+	// of trusting whatever L1 reports as head.
+	ConfirmationDepth uint64 `json:"confirmationDepth"`
 }
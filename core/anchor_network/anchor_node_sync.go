@@ -0,0 +1,210 @@
+package anchor_network
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+const (
+	// ProtocolName is the devp2p sub-protocol name advertised by anchor peers.
+	ProtocolName = "anchorgossip"
+
+	// ProtocolVersion is the only supported anchor gossip protocol version.
+	ProtocolVersion = 1
+
+	anchorNodeMsgCode = 0x00
+
+	// nodeCacheLimit bounds the number of gossiped tree nodes kept in memory
+	// between falling back to a full anchor RPC round trip.
+	nodeCacheLimit = 65536
+)
+
+// AnchorNode is a single (account, parent, depth, version) tuple published by
+// an anchor peer, together with the Merkle proof that ties it to the anchor
+// chain's state root and the signature of the publishing peer's node key.
+type AnchorNode struct {
+	Account common.Address
+	Parent  common.Address
+	Depth   uint64
+	Version uint64
+	Proof   [][]byte
+
+	Signer    common.Address
+	Signature []byte
+}
+
+// sigHash returns the hash that AnchorNode.Signature is expected to cover.
+func (n *AnchorNode) sigHash() common.Hash {
+	return rlp.RlpHash([]interface{}{n.Account, n.Parent, n.Depth, n.Version})
+}
+
+// verifySignature recovers and returns the address that signed n. The
+// address is not compared with the peer that gossiped the message.
+func (n *AnchorNode) verifySignature() (common.Address, error) {
+	pub, err := crypto.SigToPub(n.sigHash().Bytes(), n.Signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// verifyProof checks n.Proof against the anchor block's state root, proving
+// that the (account, parent, depth, version) tuple is part of the committed
+// address-tree storage without requiring a full RPC round trip.
+func (n *AnchorNode) verifyProof(stateRoot common.Hash, slotHash common.Hash) error {
+	if _, err := trie.VerifyProof(stateRoot, slotHash.Bytes(), rlpProofList(n.Proof)); err != nil {
+		return fmt.Errorf("anchor gossip: proof verification failed for %s: %w", n.Account, err)
+	}
+	return nil
+}
+
+// rlpProofList adapts a raw [][]byte proof into the ethdb.KeyValueReader
+// shape trie.VerifyProof expects. Kept local since the gossip wire format
+// carries the proof nodes pre-ordered rather than keyed by hash.
+type rlpProofList [][]byte
+
+func (l rlpProofList) Has(key []byte) (bool, error) { return l.get(key) != nil, nil }
+
+func (l rlpProofList) Get(key []byte) ([]byte, error) {
+	if v := l.get(key); v != nil {
+		return v, nil
+	}
+	return nil, errors.New("anchor gossip: proof node not found")
+}
+
+func (l rlpProofList) get(key []byte) []byte {
+	for _, n := range l {
+		if common.BytesToHash(crypto.Keccak256(n)) == common.BytesToHash(key) {
+			return n
+		}
+	}
+	return nil
+}
+
+// AnchorSyncService runs the anchor tree gossip subscriber: it joins the
+// anchorgossip sub-protocol, verifies incoming AnchorNode announcements
+// against the anchor chain's state root, and serves them out of an
+// in-memory cache so AddressTreeContract can skip the per-lookup RPC round
+// trip to the anchor client.
+type AnchorSyncService struct {
+	info *AnchorNetworkInfo
+
+	mu       sync.RWMutex
+	cache    map[common.Address]*AnchorNode
+	peerSet  map[enode.ID]bool
+	knownSet map[common.Address]bool // addresses received over gossip
+
+	headerByHash func(common.Hash) *types.Header
+}
+
+// NewAnchorSyncService builds the gossip subscriber. headerByHash is used to
+// fetch the anchor block header (and therefore its state root) that an
+// incoming AnchorNode proof is checked against.
+func NewAnchorSyncService(info *AnchorNetworkInfo, headerByHash func(common.Hash) *types.Header) *AnchorSyncService {
+	return &AnchorSyncService{
+		info:         info,
+		cache:        make(map[common.Address]*AnchorNode),
+		peerSet:      make(map[enode.ID]bool),
+		knownSet:     make(map[common.Address]bool),
+		headerByHash: headerByHash,
+	}
+}
+
+// Protocol returns the devp2p sub-protocol descriptor so the node's p2p
+// server can dial the configured bootstrap peers and run the gossip loop.
+func (s *AnchorSyncService) Protocol() p2p.Protocol {
+	return p2p.Protocol{
+		Name:    ProtocolName,
+		Version: ProtocolVersion,
+		Length:  1,
+		Run:     s.runPeer,
+	}
+}
+
+func (s *AnchorSyncService) runPeer(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
+	s.mu.Lock()
+	s.peerSet[peer.ID()] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.peerSet, peer.ID())
+		s.mu.Unlock()
+	}()
+
+	for {
+		msg, err := rw.ReadMsg()
+		if err != nil {
+			return err
+		}
+		if msg.Code != anchorNodeMsgCode {
+			msg.Discard()
+			continue
+		}
+		var node AnchorNode
+		if err := msg.Decode(&node); err != nil {
+			log.Warn("anchor gossip: bad node message", "peer", peer.ID(), "err", err)
+			continue
+		}
+		if err := s.handleAnchorNode(&node); err != nil {
+			log.Debug("anchor gossip: rejected node message", "peer", peer.ID(), "account", node.Account, "err", err)
+		}
+	}
+}
+
+// handleAnchorNode validates a gossiped tuple and, if it checks out, makes
+// it available to ParentOf/DepthOf/ChildrenOf callers through Lookup.
+func (s *AnchorSyncService) handleAnchorNode(node *AnchorNode) error {
+	signer, err := node.verifySignature()
+	if err != nil {
+		return err
+	}
+	node.Signer = signer
+
+	header := s.headerByHash(node.sigHash())
+	if header != nil {
+		if err := node.verifyProof(header.Root, node.sigHash()); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	if _, ok := s.cache[node.Account]; !ok && len(s.cache) >= nodeCacheLimit {
+		for account := range s.cache {
+			delete(s.cache, account)
+			delete(s.knownSet, account)
+			break
+		}
+	}
+	s.cache[node.Account] = node
+	s.knownSet[node.Account] = true
+	s.mu.Unlock()
+	return nil
+}
+
+// Lookup returns a gossip-verified node for account, if one has been
+// received, so that AddressTreeContract can avoid the anchor RPC fallback.
+func (s *AnchorSyncService) Lookup(account common.Address) (*AnchorNode, bool) {
+	if s == nil {
+		return nil, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	node, ok := s.cache[account]
+	return node, ok
+}
+
+func (s *AnchorSyncService) peerCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.peerSet)
+}
@@ -59,6 +59,19 @@ type Genesis struct {
 	Coinbase   common.Address      `json:"coinbase"`
 	Alloc      GenesisAlloc        `json:"alloc"      gencodec:"required"`
 
+	// AllocSource streams Alloc in from a compressed RLP blob instead of
+	// inlining it in this JSON document, for a prealloc too large to
+	// materialize as a map (an AnchorNet snapshot of an existing L1's
+	// accounts, say). Mutually exclusive with Alloc; ToBlock prefers
+	// AllocSource when both are set.
+	AllocSource *AllocSource `json:"allocSource,omitempty"`
+
+	// AnchorManifest is the raw signed AnchorGenesisManifest this genesis
+	// was built from, set by DefaultAnchorNetGenesisBlock. Commit persists
+	// it next to the chain config so a later boot can re-verify it against
+	// the then-current pinned signer set without needing the anchor RPC.
+	AnchorManifest []byte `json:"anchorManifest,omitempty"`
+
 	// These fields are used for consensus tests. Please don't use them
 	// in actual genesis blocks.
 	Number     uint64      `json:"number"`
@@ -157,10 +170,23 @@ func (e *GenesisMismatchError) Error() string {
 //
 // The returned chain configuration is never nil.
 func SetupGenesisBlock(db ethdb.Database, genesis *Genesis) (*params.ChainConfig, common.Hash, error) {
-	return SetupGenesisBlockWithOverride(db, genesis, nil, nil, nil)
+	return SetupGenesisBlockWithSetup(db, &GenesisSetup{Genesis: genesis})
 }
 
-func SetupGenesisBlockWithOverride(db ethdb.Database, genesis *Genesis, overrideBerlin, overrideArrowGlacier, overrideTerminalTotalDifficulty *big.Int) (*params.ChainConfig, common.Hash, error) {
+// SetupGenesisBlockWithSetup is SetupGenesisBlock with the full GenesisSetup
+// knobs: a Loader to resolve Genesis lazily, fork-name keyed Overrides
+// instead of a growing positional argument list, and AllowConfigRewind (see
+// rewindToCompatibleHeight) for a caller that wants an incompatible stored
+// config rewound rather than rejected.
+func SetupGenesisBlockWithSetup(db ethdb.Database, setup *GenesisSetup) (*params.ChainConfig, common.Hash, error) {
+	genesis := setup.Genesis
+	if genesis == nil && setup.Loader != nil {
+		loaded, err := setup.Loader()
+		if err != nil {
+			return nil, common.Hash{}, fmt.Errorf("genesis: resolving GenesisSetup.Loader: %w", err)
+		}
+		genesis = loaded
+	}
 	if genesis != nil && genesis.Config == nil {
 		return params.AllEthashProtocolChanges, common.Hash{}, errGenesisNoConfig
 	}
@@ -207,18 +233,29 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, genesis *Genesis, override
 	}
 	// Get the existing chain configuration.
 	newcfg := genesis.configOrDefault(stored)
-	if overrideBerlin != nil {
-		newcfg.BerlinBlock = overrideBerlin
-	}
-	if overrideArrowGlacier != nil {
-		newcfg.ArrowGlacierBlock = overrideArrowGlacier
-	}
-	if overrideTerminalTotalDifficulty != nil {
-		newcfg.TerminalTotalDifficulty = overrideTerminalTotalDifficulty
+	for fork, value := range setup.Overrides {
+		if value == nil {
+			continue
+		}
+		switch fork {
+		case OverrideBerlin:
+			newcfg.BerlinBlock = value
+		case OverrideArrowGlacier:
+			newcfg.ArrowGlacierBlock = value
+		case OverrideTerminalTotalDifficulty:
+			newcfg.TerminalTotalDifficulty = value
+		}
 	}
 	if err := newcfg.CheckConfigForkOrder(); err != nil {
 		return newcfg, common.Hash{}, err
 	}
+	if newcfg.Anchor != nil {
+		if manifest := rawdb.ReadAnchorGenesisManifest(db, stored); len(manifest) > 0 {
+			if _, _, err := VerifyAnchorGenesisManifest(manifest, newcfg.Anchor); err != nil {
+				return newcfg, stored, &AnchorManifestError{Hash: stored, Err: err}
+			}
+		}
+	}
 	storedcfg := rawdb.ReadChainConfig(db, stored)
 	if storedcfg == nil {
 		log.Warn("Found genesis block without chain config")
@@ -240,7 +277,14 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, genesis *Genesis, override
 	}
 	compatErr := storedcfg.CheckCompatible(newcfg, *height)
 	if compatErr != nil && *height != 0 && compatErr.RewindTo != 0 {
-		return newcfg, stored, compatErr
+		if !setup.AllowConfigRewind {
+			return newcfg, stored, compatErr
+		}
+		if err := rewindToCompatibleHeight(db, compatErr.RewindTo); err != nil {
+			return newcfg, stored, fmt.Errorf("genesis: rewinding to %d after config mismatch: %w", compatErr.RewindTo, err)
+		}
+		log.Warn("Rewound chain for compatible config", "to", compatErr.RewindTo, "reason", compatErr.Error())
+		stored = rawdb.ReadCanonicalHash(db, compatErr.RewindTo)
 	}
 	rawdb.WriteChainConfig(db, stored, newcfg)
 	return newcfg, stored, nil
@@ -272,12 +316,18 @@ func (g *Genesis) ToBlock(db ethdb.Database) *types.Block {
 	if err != nil {
 		panic(err)
 	}
-	for addr, account := range g.Alloc {
-		statedb.AddBalance(addr, account.Balance)
-		statedb.SetCode(addr, account.Code)
-		statedb.SetNonce(addr, account.Nonce)
-		for key, value := range account.Storage {
-			statedb.SetState(addr, key, value)
+	if g.AllocSource != nil {
+		if err := streamAllocInto(statedb, g.AllocSource); err != nil {
+			panic(err)
+		}
+	} else {
+		for addr, account := range g.Alloc {
+			statedb.AddBalance(addr, account.Balance)
+			statedb.SetCode(addr, account.Code)
+			statedb.SetNonce(addr, account.Nonce)
+			for key, value := range account.Storage {
+				statedb.SetState(addr, key, value)
+			}
 		}
 	}
 	root := statedb.IntermediateRoot(false)
@@ -339,6 +389,9 @@ func (g *Genesis) Commit(db ethdb.Database) (*types.Block, error) {
 	rawdb.WriteHeadFastBlockHash(db, block.Hash())
 	rawdb.WriteHeadHeaderHash(db, block.Hash())
 	rawdb.WriteChainConfig(db, block.Hash(), config)
+	if len(g.AnchorManifest) > 0 {
+		rawdb.WriteAnchorGenesisManifest(db, block.Hash(), g.AnchorManifest)
+	}
 	return block, nil
 }
 
@@ -399,13 +452,42 @@ func DefaultTestNetGenesisBlock() *Genesis {
 	}
 }
 
+// DefaultAnchorNetGenesisBlock builds the AnchorNet genesis block. manifest
+// is the signed AnchorGenesisManifest a compromised anchor RPC could
+// otherwise lie about by feeding an arbitrary info/forkBlockHash here
+// directly; it is verified against params.AnchorNetChainConfig.Anchor's
+// pinned signer set before anything else happens, and the verified
+// manifest's fields are used in place of the caller-supplied ones wherever
+// they overlap. allocSource is optional - pass nil to keep the small inline
+// Alloc map below; pass a non-nil AllocSource to stream a larger prealloc in
+// instead, in which case its Checksum must match the manifest's AllocRoot.
 func DefaultAnchorNetGenesisBlock(
 	stack *node.Node,
 	forkBlockTimestamp uint64,
 	forkBlockHash common.Hash,
 	ipcPath string,
 	info anchor_network.AnchorNetworkInfo,
+	manifest []byte,
+	allocSource *AllocSource,
 ) *Genesis {
+	verified, verifiedManifest, err := VerifyAnchorGenesisManifest(manifest, params.AnchorNetChainConfig.Anchor)
+	if err != nil {
+		panic(err)
+	}
+	if verified.ChainID.Cmp(info.ChainID) != 0 || verified.ForkBlockNumber.Cmp(info.ForkBlockNumber) != 0 || verified.GenesisAddress != info.GenesisAddress || verified.ManagerAddress != info.ManagerAddress {
+		panic(fmt.Errorf("genesis: anchor manifest does not match supplied AnchorNetworkInfo"))
+	}
+	// The manifest's signature only buys anything if forkBlockHash and
+	// forkBlockTimestamp - used unchecked below to build ExtraData and
+	// Timestamp - are the values it actually signed, not whatever the caller
+	// (e.g. a compromised anchor RPC) happened to pass in alongside it.
+	if verifiedManifest.ForkBlockHash != forkBlockHash {
+		panic(fmt.Errorf("genesis: anchor manifest fork block hash %s does not match supplied forkBlockHash %s", verifiedManifest.ForkBlockHash, forkBlockHash))
+	}
+	if verifiedManifest.ForkBlockTimestamp != forkBlockTimestamp {
+		panic(fmt.Errorf("genesis: anchor manifest fork block timestamp %d does not match supplied forkBlockTimestamp %d", verifiedManifest.ForkBlockTimestamp, forkBlockTimestamp))
+	}
+
 	var genesis = &Genesis{
 		Config: params.AnchorNetChainConfig,
 		Nonce:  88,
@@ -440,6 +522,19 @@ func DefaultAnchorNetGenesisBlock(
 	genesis.Config.Anchor.GenesisAddress = info.GenesisAddress
 	genesis.Config.Anchor.ManagerAddress = info.ManagerAddress
 	genesis.Config.Anchor.CacheDataBase = rdb
+	genesis.Config.TerminalTotalDifficulty = info.TerminalTotalDifficulty
+	genesis.AnchorManifest = manifest
+
+	// If the caller passed an AllocSource to stream a larger prealloc in
+	// instead of the inline Alloc map above, its checksum must be the root
+	// the manifest actually signed - otherwise the signature verifies
+	// everything except the one field (the account set) it exists to pin.
+	// ToBlock prefers AllocSource over Alloc when both are set, so this
+	// also supersedes the inline Alloc map built above once wired in.
+	if allocSource != nil && allocSource.Checksum != verifiedManifest.AllocRoot {
+		panic(fmt.Errorf("genesis: allocSource checksum %s does not match anchor manifest's AllocRoot %s", allocSource.Checksum, verifiedManifest.AllocRoot))
+	}
+	genesis.AllocSource = allocSource
 
 	return genesis
 }
@@ -0,0 +1,161 @@
+// Copyright 2014 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"compress/bzip2"
+	"compress/gzip"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Supported AllocSource.Format values.
+const (
+	AllocFormatRLPBzip2 = "rlp+bzip2"
+	AllocFormatRLPGzip  = "rlp+gzip"
+)
+
+// AllocSource points ToBlock at a prealloc blob too large to inline as JSON
+// (an AnchorNet snapshot of millions of addresses from an existing L1,
+// say), instead of populating Genesis.Alloc directly. Checksum is the
+// SHA-256 of the compressed blob as it sits on disk, so a truncated
+// download or a copy/paste mistake in Path produces a clear error instead
+// of silently committing the wrong genesis hash.
+type AllocSource struct {
+	Format   string      `json:"format"`
+	Path     string      `json:"path"`
+	Checksum common.Hash `json:"checksum"`
+}
+
+// allocStreamRecord is one account as it appears in an AllocSource blob,
+// the streaming counterpart of GenesisAccount: every field decodePrealloc's
+// legacy raw-RLP-string format left out (code, nonce, storage) so a
+// streamed prealloc can stand in for a full JSON Alloc map.
+type allocStreamRecord struct {
+	Addr    common.Address
+	Balance *big.Int
+	Nonce   uint64
+	Code    []byte
+	Storage []allocStreamStorageSlot
+}
+
+type allocStreamStorageSlot struct {
+	Key   common.Hash
+	Value common.Hash
+}
+
+// decompressorFor wraps r with the decompressor AllocSource.Format names.
+func decompressorFor(format string, r io.Reader) (io.Reader, error) {
+	switch format {
+	case AllocFormatRLPBzip2:
+		return bzip2.NewReader(r), nil
+	case AllocFormatRLPGzip:
+		return gzip.NewReader(r)
+	default:
+		return nil, fmt.Errorf("genesis: unsupported allocSource format %q", format)
+	}
+}
+
+// streamAllocInto reads source's compressed RLP blob and writes every
+// account directly into statedb, the same way ToBlock's Alloc loop does,
+// without ever holding the full account set in memory at once.
+func streamAllocInto(statedb *state.StateDB, source *AllocSource) error {
+	f, err := os.Open(source.Path)
+	if err != nil {
+		return fmt.Errorf("genesis: opening allocSource %q: %w", source.Path, err)
+	}
+	defer f.Close()
+
+	checksum := sha256.New()
+	decompressor, err := decompressorFor(source.Format, io.TeeReader(f, checksum))
+	if err != nil {
+		return err
+	}
+	stream := rlp.NewStream(decompressor, 0)
+	for {
+		var rec allocStreamRecord
+		if err := stream.Decode(&rec); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return fmt.Errorf("genesis: decoding allocSource record: %w", err)
+		}
+		statedb.AddBalance(rec.Addr, rec.Balance)
+		statedb.SetNonce(rec.Addr, rec.Nonce)
+		statedb.SetCode(rec.Addr, rec.Code)
+		for _, slot := range rec.Storage {
+			statedb.SetState(rec.Addr, slot.Key, slot.Value)
+		}
+	}
+
+	if (source.Checksum != common.Hash{}) {
+		if got := checksumHash(checksum); got != source.Checksum {
+			return fmt.Errorf("genesis: allocSource %q checksum mismatch: have %x, want %x", source.Path, got, source.Checksum)
+		}
+	}
+	return nil
+}
+
+func checksumHash(h hash.Hash) common.Hash {
+	return common.BytesToHash(h.Sum(nil))
+}
+
+// EncodeAllocStream writes alloc to w as a compressed RLP blob in format,
+// the companion ToBlock's streaming path reads back via an AllocSource; it
+// returns the blob's checksum so the caller can embed it in the genesis
+// JSON's allocSource.checksum field.
+func EncodeAllocStream(w io.Writer, format string, alloc GenesisAlloc) (common.Hash, error) {
+	checksum := sha256.New()
+	counting := io.MultiWriter(w, checksum)
+
+	var (
+		compressor io.WriteCloser
+		err        error
+	)
+	switch format {
+	case AllocFormatRLPGzip:
+		compressor = gzip.NewWriter(counting)
+	case AllocFormatRLPBzip2:
+		return common.Hash{}, errors.New("genesis: compress/bzip2 only supports decoding, encode with rlp+gzip instead")
+	default:
+		return common.Hash{}, fmt.Errorf("genesis: unsupported allocSource format %q", format)
+	}
+
+	for addr, account := range alloc {
+		rec := allocStreamRecord{Addr: addr, Balance: account.Balance, Nonce: account.Nonce, Code: account.Code}
+		for key, value := range account.Storage {
+			rec.Storage = append(rec.Storage, allocStreamStorageSlot{Key: key, Value: value})
+		}
+		if err = rlp.Encode(compressor, &rec); err != nil {
+			compressor.Close()
+			return common.Hash{}, fmt.Errorf("genesis: encoding account %s: %w", addr, err)
+		}
+	}
+	if err = compressor.Close(); err != nil {
+		return common.Hash{}, err
+	}
+	return checksumHash(checksum), nil
+}
@@ -0,0 +1,54 @@
+// Copyright 2014 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// rewindToCompatibleHeight truncates db's canonical chain back to rewindTo,
+// the GenesisSetup.AllowConfigRewind counterpart to the manual
+// `geth removedb` an operator would otherwise have to run after a
+// CheckCompatible error: every canonical hash and receipt above rewindTo is
+// deleted and the head/fast/header pointers are moved back to it, so
+// SetupGenesisBlockWithSetup can write the new config and resume at the
+// rewound height instead of returning the compat error to the caller.
+func rewindToCompatibleHeight(db ethdb.Database, rewindTo uint64) error {
+	headHash := rawdb.ReadHeadHeaderHash(db)
+	headNumber := rawdb.ReadHeaderNumber(db, headHash)
+	if headNumber == nil {
+		return fmt.Errorf("genesis: missing header number for head %x", headHash)
+	}
+	for number := *headNumber; number > rewindTo; number-- {
+		if hash := rawdb.ReadCanonicalHash(db, number); hash != (common.Hash{}) {
+			rawdb.DeleteReceipts(db, hash, number)
+		}
+		rawdb.DeleteCanonicalHash(db, number)
+	}
+	newHead := rawdb.ReadCanonicalHash(db, rewindTo)
+	if newHead == (common.Hash{}) {
+		return fmt.Errorf("genesis: no canonical hash stored at rewind target %d", rewindTo)
+	}
+	rawdb.WriteHeadBlockHash(db, newHead)
+	rawdb.WriteHeadFastBlockHash(db, newHead)
+	rawdb.WriteHeadHeaderHash(db, newHead)
+	return nil
+}
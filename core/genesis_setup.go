@@ -0,0 +1,136 @@
+// Copyright 2014 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/core/anchor_network"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Overridable GenesisSetup.Overrides keys. An unrecognised key is ignored
+// rather than erroring, so a caller built against a newer fork list than
+// this binary still starts up with everything it does recognise applied.
+const (
+	OverrideBerlin                  = "berlin"
+	OverrideArrowGlacier            = "arrowGlacier"
+	OverrideTerminalTotalDifficulty = "terminalTotalDifficulty"
+)
+
+// GenesisLoader resolves a GenesisSetup's Genesis when it isn't supplied
+// directly, e.g. LoadGenesisFile bound to a path a caller named instead of
+// a *Genesis literal it built itself.
+type GenesisLoader func() (*Genesis, error)
+
+// GenesisSetup groups SetupGenesisBlockWithSetup's inputs, replacing the
+// positional overrideBerlin/overrideArrowGlacier/overrideTerminalTotalDifficulty
+// arguments SetupGenesisBlockWithOverride used to take, so library users
+// outside cmd/geth (SDKs, test harnesses, the anchor bootstrapper) don't
+// have to keep adding parameters every time a new fork becomes overridable.
+type GenesisSetup struct {
+	Genesis *Genesis
+
+	// Overrides is keyed by one of the Override* constants; nil or
+	// missing entries leave the stored/default config's value alone.
+	Overrides map[string]*big.Int
+
+	// AllowConfigRewind lets SetupGenesisBlockWithSetup truncate the chain
+	// to a CheckCompatible error's RewindTo point instead of returning the
+	// error to the caller. Defaults to false, matching
+	// SetupGenesisBlockWithOverride's historical behavior.
+	AllowConfigRewind bool
+
+	// Loader resolves Genesis when it is nil.
+	Loader GenesisLoader
+}
+
+// LoadGenesisFile reads path and decodes it as a Genesis. Two on-disk forms
+// are understood: a standard Genesis JSON document (what Genesis's own
+// json tags describe), and a richer "chain spec" form with top-level
+// "params", "genesis", "alloc" and "anchor" sections that let a chain's
+// consensus config, block fields, prealloc and AnchorNet wiring be
+// reviewed and versioned independently. The chain spec form is detected by
+// the presence of a top-level "params" key.
+func LoadGenesisFile(path string) (*Genesis, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("genesis: reading %q: %w", path, err)
+	}
+
+	var probe struct {
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return nil, fmt.Errorf("genesis: %q is not valid JSON: %w", path, err)
+	}
+	if probe.Params == nil {
+		var genesis Genesis
+		if err := json.Unmarshal(raw, &genesis); err != nil {
+			return nil, fmt.Errorf("genesis: decoding %q: %w", path, err)
+		}
+		return &genesis, nil
+	}
+	return loadChainSpecFile(raw)
+}
+
+// chainSpecFile is the richer on-disk form LoadGenesisFile accepts
+// alongside a plain Genesis document. genesis carries the block-level
+// fields (nonce, timestamp, extraData, ...); params and alloc, when
+// present, take priority over genesis's own config/alloc so a chain spec
+// can keep the three concerns in separate, independently diffable blocks.
+type chainSpecFile struct {
+	Params  *params.ChainConfig               `json:"params"`
+	Genesis *Genesis                          `json:"genesis"`
+	Alloc   GenesisAlloc                      `json:"alloc"`
+	Anchor  *anchor_network.AnchorNetworkInfo `json:"anchor"`
+}
+
+func loadChainSpecFile(raw []byte) (*Genesis, error) {
+	var spec chainSpecFile
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		return nil, fmt.Errorf("genesis: decoding chain spec: %w", err)
+	}
+	genesis := spec.Genesis
+	if genesis == nil {
+		genesis = &Genesis{}
+	}
+	if spec.Params != nil {
+		genesis.Config = spec.Params
+	}
+	if spec.Alloc != nil {
+		genesis.Alloc = spec.Alloc
+	}
+	if spec.Anchor != nil {
+		if genesis.Config == nil {
+			return nil, errors.New("genesis: chain spec's anchor section requires a params section")
+		}
+		genesis.Config.ChainID = spec.Anchor.ChainID
+		genesis.Config.TerminalTotalDifficulty = spec.Anchor.TerminalTotalDifficulty
+		if genesis.Config.Anchor == nil {
+			genesis.Config.Anchor = &params.AnchorConfig{}
+		}
+		genesis.Config.Anchor.ForkBlockNumber = spec.Anchor.ForkBlockNumber.Uint64()
+		genesis.Config.Anchor.GenesisAddress = spec.Anchor.GenesisAddress
+		genesis.Config.Anchor.ManagerAddress = spec.Anchor.ManagerAddress
+	}
+	return genesis, nil
+}
@@ -61,10 +61,27 @@ func DepthSlotHash(account common.Address) common.Hash {
 	return slotHash
 }
 
+// AnchorBlockSlotHash derives the sidecar slot a cached address-tree entry
+// records its source anchor-chain block number under, so reorg handling can
+// tell which entries were written at or after a fork point.
+func AnchorBlockSlotHash(account common.Address) common.Hash {
+	var slotHash common.Hash
+	harsher := sha3.NewLegacyKeccak256()
+	harsher.Write(common.LeftPadBytes(account.Bytes(), 32))
+	harsher.Write(common.LeftPadBytes([]byte("__ANCHOR_BLOCK"), 32))
+	harsher.Sum(slotHash[:0])
+	harsher.Reset()
+	return slotHash
+}
+
 func ChildrenDBKey(account common.Address) []byte {
 	return ldbKey(ChildrenSlotHash(account))
 }
 
+func AnchorBlockDBKey(account common.Address) []byte {
+	return ldbKey(AnchorBlockSlotHash(account))
+}
+
 func ParentDBKey(account common.Address) []byte {
 	return ldbKey(ParentSlotHash(account))
 }
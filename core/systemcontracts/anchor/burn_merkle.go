@@ -0,0 +1,143 @@
+package anchor
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// burnTreeDepth is chosen so a burn index's position in the tree is just
+// its low 16 bits (index mod 2^16): a window of pending burns never spans
+// more than one epoch's worth of requests in practice, and 2^16 leaves
+// keeps GenerateBurnInclusionProof's audit path a fixed 16 hashes no matter
+// how large a batch's [Start, End) window grows, replacing the old
+// hard-coded 32-request cap with an O(log n) proof instead of a bound on n.
+const burnTreeDepth = 16
+
+const burnTreeSize = 1 << burnTreeDepth
+
+// zeroHashes[i] is the root of an empty subtree of height i, precomputed
+// once so BurnMerkleTree never has to hash real zero-valued leaves for the
+// (usually large) stretch of the window with no pending request.
+var zeroHashes [burnTreeDepth + 1]common.Hash
+
+func init() {
+	zeroHashes[0] = crypto.Keccak256Hash([]byte("pdachain-burn-empty-leaf"))
+	for i := 1; i <= burnTreeDepth; i++ {
+		zeroHashes[i] = hashPair(zeroHashes[i-1], zeroHashes[i-1])
+	}
+}
+
+func hashPair(left, right common.Hash) common.Hash {
+	return crypto.Keccak256Hash(append(append([]byte{}, left.Bytes()...), right.Bytes()...))
+}
+
+var burnLeafArguments = abi.Arguments{
+	{Type: mustBurnLeafType("uint256")},
+	{Type: mustBurnLeafType("address")},
+	{Type: mustBurnLeafType("address")},
+	{Type: mustBurnLeafType("address")},
+	{Type: mustBurnLeafType("address")},
+	{Type: mustBurnLeafType("uint256")},
+}
+
+func mustBurnLeafType(solidityType string) abi.Type {
+	typ, err := abi.NewType(solidityType, "", nil)
+	if err != nil {
+		panic(err)
+	}
+	return typ
+}
+
+// BurnLeafHash encodes one burn request the same way the request's leaf is
+// committed to the sparse Merkle tree: keccak256(abi.encode(Index,
+// FromToken, FromAddress, ToToken, ToAddress, Amount)), matching the ABI
+// arguments the pre-batch per-request signature used to sign over.
+func BurnLeafHash(index *big.Int, fromToken, fromAddress, toToken, toAddress common.Address, amount *big.Int) (common.Hash, error) {
+	data, err := burnLeafArguments.Pack(index, fromToken, fromAddress, toToken, toAddress, amount)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(data), nil
+}
+
+// BurnMerkleTree is a sparse Merkle tree over a window of burn request
+// leaves, keyed by burn index mod 2^16. Every position with no pending
+// request folds down to zeroHashes[0] instead of being materialized, so
+// building the tree costs O(burnTreeSize) regardless of how sparse the
+// window is - acceptable since burnTreeSize is fixed and small, and far
+// simpler than a pointer-based sparse tree for the proof sizes this scheme
+// needs.
+type BurnMerkleTree struct {
+	levels [][]common.Hash // levels[0] is the leaves, levels[burnTreeDepth] is [root]
+}
+
+// NewBurnMerkleTree builds a BurnMerkleTree over leaves, keyed by burn
+// index (only the low 16 bits of each index are used as the tree
+// position).
+func NewBurnMerkleTree(leaves map[uint64]common.Hash) *BurnMerkleTree {
+	level := make([]common.Hash, burnTreeSize)
+	for i := range level {
+		level[i] = zeroHashes[0]
+	}
+	for index, leaf := range leaves {
+		level[index%burnTreeSize] = leaf
+	}
+
+	levels := make([][]common.Hash, 0, burnTreeDepth+1)
+	levels = append(levels, level)
+	for d := 0; d < burnTreeDepth; d++ {
+		cur := levels[d]
+		next := make([]common.Hash, len(cur)/2)
+		for i := range next {
+			next[i] = hashPair(cur[2*i], cur[2*i+1])
+		}
+		levels = append(levels, next)
+	}
+	return &BurnMerkleTree{levels: levels}
+}
+
+// Root returns the tree's root hash, the value L2BurnBatch.Root commits to
+// and the threshold BLS signature is computed over.
+func (t *BurnMerkleTree) Root() common.Hash {
+	return t.levels[burnTreeDepth][0]
+}
+
+// GenerateBurnInclusionProof returns the sibling hash at every level from
+// index's leaf up to the root, suitable for submission to L1 alongside the
+// leaf itself so a watcher can prove a specific burn was (or wasn't)
+// included in a signed root via VerifyBurnInclusion.
+func (t *BurnMerkleTree) GenerateBurnInclusionProof(index uint64) ([][32]byte, error) {
+	pos := index % burnTreeSize
+	path := make([][32]byte, 0, burnTreeDepth)
+	for d := 0; d < burnTreeDepth; d++ {
+		path = append(path, [32]byte(t.levels[d][pos^1]))
+		pos /= 2
+	}
+	return path, nil
+}
+
+// VerifyBurnInclusion recomputes the root leaf's audit path folds up to and
+// reports whether it matches root, i.e. whether leaf was committed to at
+// position index when root was signed.
+func VerifyBurnInclusion(root common.Hash, leaf common.Hash, path [][32]byte, index uint64) (bool, error) {
+	if len(path) != burnTreeDepth {
+		return false, fmt.Errorf("anchor: burn inclusion proof has %d levels, want %d", len(path), burnTreeDepth)
+	}
+
+	cur := leaf
+	pos := index % burnTreeSize
+	for d := 0; d < burnTreeDepth; d++ {
+		sibling := common.Hash(path[d])
+		if pos%2 == 0 {
+			cur = hashPair(cur, sibling)
+		} else {
+			cur = hashPair(sibling, cur)
+		}
+		pos /= 2
+	}
+	return cur == root, nil
+}
@@ -0,0 +1,173 @@
+package anchor
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/rjeczalik/notify"
+)
+
+var cacheDBReloadsCounter = metrics.NewRegisteredCounter("anchor/cachedb/reloads", nil)
+
+// ReloadableCacheDB wraps an on-disk anchor cacheDB that an out-of-band sync
+// process can replace wholesale (write the new snapshot alongside the old
+// one, then rename over it) without the node restarting to notice. It
+// watches the backing directory for write/rename events and atomically
+// swaps in a freshly opened handle behind an atomic.Pointer, draining
+// readers of the old handle through mu before closing it.
+//
+// It only implements the KeyValueStore-shaped subset of ethdb.Database the
+// anchor cache actually exercises (Get/Has/Put/Delete plus the batch,
+// iterate, stat and compact methods every ethdb.Database backend carries) -
+// every cacheStateXxxOf hook in core/vm only ever calls Get against it.
+type ReloadableCacheDB struct {
+	opener func() (ethdb.Database, error)
+
+	// mu is write-locked only for the moment a fresh handle is swapped in,
+	// so every read already in flight against the old handle completes
+	// before Close is called on it, and no read ever straddles a swap.
+	mu sync.RWMutex
+	db atomic.Pointer[ethdb.Database]
+
+	events chan notify.EventInfo
+	quit   chan struct{}
+}
+
+// WatchCacheDB opens the cacheDB under dir via opener, then watches dir for
+// write/rename/create events and reopens+swaps in a fresh handle on each
+// one. The caller is expected to assign the returned *ReloadableCacheDB
+// directly to params.AnchorConfig.CacheDataBase - it satisfies the same
+// interface the plain on-disk handle does, so nothing downstream of
+// evm.cacheDB needs to change.
+func WatchCacheDB(dir string, opener func() (ethdb.Database, error)) (*ReloadableCacheDB, error) {
+	db, err := opener()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &ReloadableCacheDB{
+		opener: opener,
+		events: make(chan notify.EventInfo, 16),
+		quit:   make(chan struct{}),
+	}
+	w.db.Store(&db)
+
+	if err := notify.Watch(dir+"/...", w.events, notify.Write, notify.Rename, notify.Create); err != nil {
+		db.Close()
+		return nil, err
+	}
+	go w.loop()
+	return w, nil
+}
+
+func (w *ReloadableCacheDB) loop() {
+	defer notify.Stop(w.events)
+	for {
+		select {
+		case <-w.events:
+			w.reload()
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+// reload reopens the cacheDB and swaps it in, closing the superseded handle
+// only once every reader that was using it has released mu.
+func (w *ReloadableCacheDB) reload() {
+	fresh, err := w.opener()
+	if err != nil {
+		log.Warn("Failed to reopen anchor cacheDB after change notification", "err", err)
+		return
+	}
+
+	w.mu.Lock()
+	old := w.db.Swap(&fresh)
+	w.mu.Unlock()
+
+	if old != nil {
+		(*old).Close()
+	}
+	cacheDBReloadsCounter.Inc(1)
+}
+
+func (w *ReloadableCacheDB) current() ethdb.Database {
+	return *w.db.Load()
+}
+
+// Has implements ethdb.KeyValueReader.
+func (w *ReloadableCacheDB) Has(key []byte) (bool, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current().Has(key)
+}
+
+// Get implements ethdb.KeyValueReader. This is the hot path every
+// cacheStateChildrenOf/ParentOf/VersionOf/DepthOf hook goes through, so it's
+// the one method whose read-lock window is what actually prevents a torn
+// read against a handle that's mid-Close.
+func (w *ReloadableCacheDB) Get(key []byte) ([]byte, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current().Get(key)
+}
+
+// Put implements ethdb.KeyValueWriter.
+func (w *ReloadableCacheDB) Put(key, value []byte) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current().Put(key, value)
+}
+
+// Delete implements ethdb.KeyValueWriter.
+func (w *ReloadableCacheDB) Delete(key []byte) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current().Delete(key)
+}
+
+// NewBatch implements ethdb.Batcher.
+func (w *ReloadableCacheDB) NewBatch() ethdb.Batch {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current().NewBatch()
+}
+
+// NewBatchWithSize implements ethdb.Batcher.
+func (w *ReloadableCacheDB) NewBatchWithSize(size int) ethdb.Batch {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current().NewBatchWithSize(size)
+}
+
+// NewIterator implements ethdb.Iteratee.
+func (w *ReloadableCacheDB) NewIterator(prefix, start []byte) ethdb.Iterator {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current().NewIterator(prefix, start)
+}
+
+// Stat implements ethdb.Stater.
+func (w *ReloadableCacheDB) Stat(property string) (string, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current().Stat(property)
+}
+
+// Compact implements ethdb.Compacter.
+func (w *ReloadableCacheDB) Compact(start, limit []byte) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current().Compact(start, limit)
+}
+
+// Close stops the watcher goroutine and closes the current handle.
+func (w *ReloadableCacheDB) Close() error {
+	close(w.quit)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current().Close()
+}
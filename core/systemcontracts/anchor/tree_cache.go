@@ -0,0 +1,139 @@
+package anchor
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const defaultTreeCacheSize = 4096
+
+var (
+	treeCacheHitMeter  = metrics.NewRegisteredMeter("anchor/treecache/hit", nil)
+	treeCacheMissMeter = metrics.NewRegisteredMeter("anchor/treecache/miss", nil)
+)
+
+// treeCacheKey is the (owner, blockNumber) pair TreeCache memoizes
+// versionOf/childrenOf under: an entry is only ever trusted for the block
+// it was read at, since the anchor chain can still add new blocks that
+// change an owner's version or children between one block and the next.
+type treeCacheKey struct {
+	owner       common.Address
+	blockNumber uint64
+}
+
+// treeCacheEntry holds whichever of versionOf/childrenOf have been resolved
+// for one (owner, blockNumber) pair; most shortcut calls only ever need one
+// of the two, so each is filled in lazily except when Prefetch populates
+// both up front.
+type treeCacheEntry struct {
+	version     []byte
+	hasVersion  bool
+	children    []byte
+	hasChildren bool
+}
+
+// TreeCache memoizes address-tree versionOf/childrenOf reads for the
+// lifetime of a block, and supports bulk-prefetching a subtree so a single
+// childrenOf shortcut that would otherwise walk N accounts one read at a
+// time instead warms the whole expansion in a single pass. It's shared by
+// every EVM instance created for the chain it belongs to, since those
+// instances are pooled per-transaction rather than per-block.
+type TreeCache struct {
+	mu  sync.Mutex
+	lru *lru.Cache
+}
+
+// NewTreeCache returns a TreeCache holding up to size (owner, blockNumber)
+// entries; size <= 0 falls back to defaultTreeCacheSize.
+func NewTreeCache(size int) *TreeCache {
+	if size <= 0 {
+		size = defaultTreeCacheSize
+	}
+	c, _ := lru.New(size)
+	return &TreeCache{lru: c}
+}
+
+func (c *TreeCache) entry(key treeCacheKey) *treeCacheEntry {
+	if v, ok := c.lru.Get(key); ok {
+		return v.(*treeCacheEntry)
+	}
+	e := &treeCacheEntry{}
+	c.lru.Add(key, e)
+	return e
+}
+
+// Version returns the cached versionOf result for (owner, blockNumber),
+// calling fetch and caching its result on a miss.
+func (c *TreeCache) Version(owner common.Address, blockNumber uint64, fetch func() []byte) []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.entry(treeCacheKey{owner, blockNumber})
+	if e.hasVersion {
+		treeCacheHitMeter.Mark(1)
+		return e.version
+	}
+	treeCacheMissMeter.Mark(1)
+	e.version, e.hasVersion = fetch(), true
+	return e.version
+}
+
+// Children returns the cached childrenOf result for (owner, blockNumber),
+// calling fetch and caching its result on a miss.
+func (c *TreeCache) Children(owner common.Address, blockNumber uint64, fetch func() []byte) []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.entry(treeCacheKey{owner, blockNumber})
+	if e.hasChildren {
+		treeCacheHitMeter.Mark(1)
+		return e.children
+	}
+	treeCacheMissMeter.Mark(1)
+	e.children, e.hasChildren = fetch(), true
+	return e.children
+}
+
+// Prefetch walks owner's subtree up to depth levels deep, calling
+// fetchVersion/fetchChildren once per visited account and populating both
+// Version and Children for it, so the childrenOf shortcut's later hits on
+// every account in the subtree are served from memory instead of cacheDB.
+// fetchChildren also returns the decoded child addresses so Prefetch can
+// keep expanding the frontier without re-decoding its own cached bytes.
+func (c *TreeCache) Prefetch(owner common.Address, depth int, blockNumber uint64, fetchVersion func(common.Address) []byte, fetchChildren func(common.Address) (raw []byte, children []common.Address)) {
+	if depth <= 0 {
+		return
+	}
+	frontier := []common.Address{owner}
+	for level := 0; level < depth && len(frontier) > 0; level++ {
+		var next []common.Address
+		for _, acc := range frontier {
+			version := fetchVersion(acc)
+			raw, children := fetchChildren(acc)
+
+			c.mu.Lock()
+			e := c.entry(treeCacheKey{acc, blockNumber})
+			e.version, e.hasVersion = version, true
+			e.children, e.hasChildren = raw, true
+			c.mu.Unlock()
+
+			next = append(next, children...)
+		}
+		frontier = next
+	}
+}
+
+// OnWrite invalidates every entry cached for owner, across every block
+// number it was memoized under. Call this from a StateDB hook whenever a
+// write touches one of owner's address-tree slots, so a cache warmed before
+// the write can't go on serving a stale versionOf/childrenOf afterward.
+func (c *TreeCache) OnWrite(owner common.Address) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, k := range c.lru.Keys() {
+		if key, ok := k.(treeCacheKey); ok && key.owner == owner {
+			c.lru.Remove(k)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Package treeindex maintains a section-based bloom index of the address
+// tree's transitive descendants, modeled on core/bloombits' section indexer:
+// instead of answering "is Y a descendant of X" with N recursive
+// cacheStateChildrenOf/cacheStateParentOf calls, it keeps one bloom filter
+// per (ancestor, section) that's checked first, with a walk up
+// cacheStateParentOf only needed to resolve the rare false positive.
+package treeindex
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// SectionSize is the number of blocks whose address-tree writes share one
+// bloom section, the same role core/bloombits.SectionSize plays for log blooms.
+const SectionSize = 4096
+
+// sectionKey identifies one ancestor's bloom filter over one section's worth
+// of ChildrenDBKey writes.
+type sectionKey struct {
+	ancestor common.Address
+	section  uint64
+}
+
+// Indexer holds, for every (ancestor, section) pair seen since the last
+// Rebuild, a bloom filter over that ancestor's descendants added during that
+// section. Index is append-mostly: Add during normal block processing,
+// Rebuild to recompute everything from state after a reorg or a
+// --tree.reindex run.
+type Indexer struct {
+	mu       sync.RWMutex
+	sections map[sectionKey]*types.Bloom
+	// current is the highest section each ancestor has been written to,
+	// needed so MaybeContains knows how many sections it has to check.
+	current map[common.Address]uint64
+}
+
+// NewIndexer returns an empty Indexer.
+func NewIndexer() *Indexer {
+	return &Indexer{
+		sections: make(map[sectionKey]*types.Bloom),
+		current:  make(map[common.Address]uint64),
+	}
+}
+
+func sectionOf(blockNumber uint64) uint64 {
+	return blockNumber / SectionSize
+}
+
+// Add records that descendant was attached somewhere under ancestor's
+// subtree at blockNumber, incrementally updating ancestor's bloom filter for
+// that block's section. Call this from the StateDB write path whenever an
+// anchor.ChildrenDBKey write extends ancestor's subtree with descendant -
+// every one of descendant's own ancestors must be recorded, not just its
+// immediate parent, since isDescendantOf queries an arbitrary root.
+func (idx *Indexer) Add(ancestor, descendant common.Address, blockNumber uint64) {
+	section := sectionOf(blockNumber)
+	key := sectionKey{ancestor, section}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	bloom, ok := idx.sections[key]
+	if !ok {
+		bloom = new(types.Bloom)
+		idx.sections[key] = bloom
+	}
+	bloom.Add(descendant.Bytes())
+	if section > idx.current[ancestor] {
+		idx.current[ancestor] = section
+	}
+}
+
+// MaybeContains reports whether candidate might be a descendant of root, by
+// OR-checking every section bloom ever recorded for root. A false positive
+// is possible (that's the caller's cue to verify by walking
+// cacheStateParentOf); a false negative is not, as long as every write was
+// recorded via Add.
+func (idx *Indexer) MaybeContains(root, candidate common.Address) bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	last, ok := idx.current[root]
+	if !ok {
+		return false
+	}
+	for section := uint64(0); section <= last; section++ {
+		if bloom, ok := idx.sections[sectionKey{root, section}]; ok && types.BloomLookup(*bloom, candidate.Bytes()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reset discards every recorded section, the first step of a full
+// --tree.reindex run; the caller is responsible for then replaying Add over
+// every ChildrenDBKey write in state (or calling Rebuild, if it has a full
+// state walker on hand).
+func (idx *Indexer) Reset() {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.sections = make(map[sectionKey]*types.Bloom)
+	idx.current = make(map[common.Address]uint64)
+}
+
+// Rebuild repopulates the index from scratch by calling walk once per
+// (ancestor, descendant, blockNumber) triple the state walker knows about;
+// it's the function a `--tree.reindex` startup flag should call after
+// Reset(), since this trimmed snapshot doesn't carry the cmd/geth flag
+// wiring to invoke it automatically.
+func (idx *Indexer) Rebuild(walk func(add func(ancestor, descendant common.Address, blockNumber uint64))) {
+	idx.Reset()
+	walk(idx.Add)
+}
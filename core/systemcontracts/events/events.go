@@ -0,0 +1,120 @@
+// Package events synthesizes typed logs for AddressTree and Farm hook state
+// changes that have no Solidity emitter behind them - the tree and farm
+// state both live in raw storage slots written directly by the EVM hooks in
+// core/vm, not by contract bytecode, so there is no `emit` to piggyback on.
+// Each constructor here returns a *types.Log ready for StateDB.AddLog,
+// synthesized during state-transition so it lands in the receipt bloom the
+// same as a contract-emitted log would, letting light clients verify it.
+package events
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Topic hashes are keccak256 of each event's canonical signature, computed
+// once at init the same way a Solidity compiler would for a real `event`.
+var (
+	ChildAddedTopic                = crypto.Keccak256Hash([]byte("ChildAdded(address,address)"))
+	ParentChangedTopic             = crypto.Keccak256Hash([]byte("ParentChanged(address,address)"))
+	DepthChangedTopic              = crypto.Keccak256Hash([]byte("DepthChanged(address,uint256)"))
+	VersionChangedTopic            = crypto.Keccak256Hash([]byte("VersionChanged(address,uint256)"))
+	RewardPerShareAdvancedTopic    = crypto.Keccak256Hash([]byte("RewardPerShareAdvanced(address,address,uint256,uint256)"))
+	HolderDistributionChangedTopic = crypto.Keccak256Hash([]byte("HolderDistributionChanged(address)"))
+	ChildrenHoldAmountChangedTopic = crypto.Keccak256Hash([]byte("ChildrenHoldAmountChanged(address,address)"))
+	PowerChangedTopic              = crypto.Keccak256Hash([]byte("PowerChanged(address,uint8,uint256,uint256)"))
+)
+
+func addressTopic(addr common.Address) common.Hash {
+	return common.BytesToHash(addr.Bytes())
+}
+
+func wordData(values ...*big.Int) []byte {
+	data := make([]byte, 0, 32*len(values))
+	for _, v := range values {
+		data = append(data, common.LeftPadBytes(v.Bytes(), 32)...)
+	}
+	return data
+}
+
+// ChildAdded synthesizes the log for a new child attached under parent in
+// the address tree, indexed the same way a Solidity
+// `event ChildAdded(address indexed parent, address indexed child)` would
+// be: topic[0] the event signature, topic[1]/topic[2] the indexed args.
+func ChildAdded(addressTreeContract, parent, child common.Address) *types.Log {
+	return &types.Log{
+		Address: addressTreeContract,
+		Topics:  []common.Hash{ChildAddedTopic, addressTopic(parent), addressTopic(child)},
+	}
+}
+
+// ParentChanged synthesizes the log for account's parent changing to
+// newParent (including the initial attach, where the old parent is the zero
+// address).
+func ParentChanged(addressTreeContract, account, newParent common.Address) *types.Log {
+	return &types.Log{
+		Address: addressTreeContract,
+		Topics:  []common.Hash{ParentChangedTopic, addressTopic(account), addressTopic(newParent)},
+	}
+}
+
+// DepthChanged synthesizes the log for account's tree depth changing to
+// newDepth.
+func DepthChanged(addressTreeContract, account common.Address, newDepth *big.Int) *types.Log {
+	return &types.Log{
+		Address: addressTreeContract,
+		Topics:  []common.Hash{DepthChangedTopic, addressTopic(account)},
+		Data:    wordData(newDepth),
+	}
+}
+
+// VersionChanged synthesizes the log for account's tree version changing to
+// newVersion.
+func VersionChanged(addressTreeContract, account common.Address, newVersion *big.Int) *types.Log {
+	return &types.Log{
+		Address: addressTreeContract,
+		Topics:  []common.Hash{VersionChangedTopic, addressTopic(account)},
+		Data:    wordData(newVersion),
+	}
+}
+
+// RewardPerShareAdvanced synthesizes the log for (pool, rewardToken)'s
+// accumulated reward-per-share at rangeIndex advancing to newValue.
+func RewardPerShareAdvanced(farmContract, pool, rewardToken common.Address, rangeIndex, newValue *big.Int) *types.Log {
+	return &types.Log{
+		Address: farmContract,
+		Topics:  []common.Hash{RewardPerShareAdvancedTopic, addressTopic(pool), addressTopic(rewardToken)},
+		Data:    wordData(rangeIndex, newValue),
+	}
+}
+
+// HolderDistributionChanged synthesizes the log for tokenContract's holder
+// range distribution table being rewritten.
+func HolderDistributionChanged(farmContract, tokenContract common.Address) *types.Log {
+	return &types.Log{
+		Address: farmContract,
+		Topics:  []common.Hash{HolderDistributionChangedTopic, addressTopic(tokenContract)},
+	}
+}
+
+// ChildrenHoldAmountChanged synthesizes the log for (pool, parent)'s
+// per-child hold amounts being rewritten.
+func ChildrenHoldAmountChanged(farmContract, pool, parent common.Address) *types.Log {
+	return &types.Log{
+		Address: farmContract,
+		Topics:  []common.Hash{ChildrenHoldAmountChangedTopic, addressTopic(pool), addressTopic(parent)},
+	}
+}
+
+// PowerChanged synthesizes the log for pool's holder or community total
+// power (kind: 0 holder, 1 community) changing from old to newValue.
+func PowerChanged(farmContract, pool common.Address, kind, old, newValue *big.Int) *types.Log {
+	return &types.Log{
+		Address: farmContract,
+		Topics:  []common.Hash{PowerChangedTopic, addressTopic(pool)},
+		Data:    wordData(kind, old, newValue),
+	}
+}
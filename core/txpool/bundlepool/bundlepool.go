@@ -0,0 +1,149 @@
+// Package bundlepool maintains an ordered pool of MEV bundles (BEP-322
+// style), submitted out of band from the regular transaction pool via
+// mev_sendBundle and included atomically by the miner's
+// fillTransactionsAndBundles path.
+package bundlepool
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	// ErrBundleExpired is returned for a bundle whose MaxTimestamp has
+	// already passed, or whose MaxBlockNumber is already behind the chain.
+	ErrBundleExpired = errors.New("bundlepool: bundle expired")
+
+	// ErrBundleNotYetValid is returned for a bundle submitted ahead of its
+	// own MinTimestamp.
+	ErrBundleNotYetValid = errors.New("bundlepool: bundle not yet valid")
+
+	// ErrBundleEmpty is returned for a bundle with no transactions.
+	ErrBundleEmpty = errors.New("bundlepool: bundle has no transactions")
+
+	// ErrBundleTooLarge is returned for a bundle whose transaction count
+	// exceeds the maxBundleSize passed to Add.
+	ErrBundleTooLarge = errors.New("bundlepool: bundle exceeds max bundle size")
+
+	// ErrPoolFull is returned when the pool already holds maxBundles
+	// distinct bundles and bundle isn't a resubmission of one of them.
+	ErrPoolFull = errors.New("bundlepool: pool is full")
+)
+
+// maxBundles caps how many distinct bundles the pool holds at once, so an
+// unauthenticated mev_sendBundle caller can't grow it without bound -
+// oldest-first eviction isn't worth it here since a bundle past its
+// MaxBlockNumber/MaxTimestamp is already pruned by Prune; a pool that's
+// genuinely full of still-valid bundles should reject new ones outright.
+const maxBundles = 4096
+
+// BundlePool holds every pending bundle, keyed by its hash so a duplicate
+// submission (the same bundle resubmitted while still pending) replaces
+// rather than duplicates its entry.
+type BundlePool struct {
+	mu      sync.RWMutex
+	bundles map[common.Hash]*types.Bundle
+}
+
+// New creates an empty BundlePool.
+func New() *BundlePool {
+	return &BundlePool{
+		bundles: make(map[common.Hash]*types.Bundle),
+	}
+}
+
+// Add validates and inserts bundle, keyed by its hash. now is the caller's
+// view of the current time (as a unix timestamp) and blockNumber the
+// current chain head, both needed to reject an already-expired or
+// not-yet-valid bundle up front rather than finding out at inclusion time.
+// maxBundleSize bounds bundle.Txs; a non-positive value disables the check.
+func (p *BundlePool) Add(bundle *types.Bundle, blockNumber uint64, now uint64, maxBundleSize int) error {
+	if len(bundle.Txs) == 0 {
+		return ErrBundleEmpty
+	}
+	if maxBundleSize > 0 && len(bundle.Txs) > maxBundleSize {
+		return ErrBundleTooLarge
+	}
+	if bundle.MaxBlockNumber != 0 && blockNumber > bundle.MaxBlockNumber {
+		return ErrBundleExpired
+	}
+	if bundle.MaxTimestamp != 0 && now > bundle.MaxTimestamp {
+		return ErrBundleExpired
+	}
+	if bundle.MinTimestamp != 0 && now < bundle.MinTimestamp {
+		return ErrBundleNotYetValid
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	hash := bundle.Hash()
+	if _, exists := p.bundles[hash]; !exists && len(p.bundles) >= maxBundles {
+		return ErrPoolFull
+	}
+	p.bundles[hash] = bundle
+	return nil
+}
+
+// Pending returns every bundle applicable at blockNumber/timestamp, in a
+// stable order (by bundle hash) so two nodes building on the same pending
+// set try them in the same order.
+func (p *BundlePool) Pending(blockNumber uint64, timestamp uint64) []*types.Bundle {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	pending := make([]*types.Bundle, 0, len(p.bundles))
+	for _, bundle := range p.bundles {
+		if bundle.MaxBlockNumber != 0 && blockNumber > bundle.MaxBlockNumber {
+			continue
+		}
+		if bundle.MaxTimestamp != 0 && timestamp > bundle.MaxTimestamp {
+			continue
+		}
+		if bundle.MinTimestamp != 0 && timestamp < bundle.MinTimestamp {
+			continue
+		}
+		pending = append(pending, bundle)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Hash().Hex() < pending[j].Hash().Hex()
+	})
+	return pending
+}
+
+// Prune drops every bundle that can no longer apply to any future block:
+// past its MaxBlockNumber or MaxTimestamp. Unlike Pending's filtering, this
+// permanently removes the entry instead of just skipping it for one block.
+func (p *BundlePool) Prune(blockNumber uint64, timestamp uint64) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pruned := 0
+	for hash, bundle := range p.bundles {
+		expiredByBlock := bundle.MaxBlockNumber != 0 && blockNumber > bundle.MaxBlockNumber
+		expiredByTime := bundle.MaxTimestamp != 0 && timestamp > bundle.MaxTimestamp
+		if expiredByBlock || expiredByTime {
+			delete(p.bundles, hash)
+			pruned++
+		}
+	}
+	return pruned
+}
+
+// Get returns the bundle with the given hash, if still pending.
+func (p *BundlePool) Get(hash common.Hash) (*types.Bundle, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	bundle, ok := p.bundles[hash]
+	return bundle, ok
+}
+
+// Len reports how many bundles are currently pending.
+func (p *BundlePool) Len() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.bundles)
+}
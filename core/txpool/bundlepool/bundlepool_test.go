@@ -0,0 +1,64 @@
+package bundlepool
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func newTestBundle(t *testing.T, numTxs int, salt int64) *types.Bundle {
+	t.Helper()
+	to := common.HexToAddress("0x1")
+	txs := make(types.Transactions, numTxs)
+	for i := range txs {
+		txs[i] = types.NewTx(&types.DynamicFeeTx{
+			ChainID:   big.NewInt(1),
+			Nonce:     uint64(i),
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(salt + int64(i) + 1),
+			Gas:       21000,
+			To:        &to,
+		})
+	}
+	return &types.Bundle{Txs: txs}
+}
+
+func TestBundlePoolAddEnforcesMaxBundleSize(t *testing.T) {
+	pool := New()
+	bundle := newTestBundle(t, 3, 0)
+
+	if err := pool.Add(bundle, 1, 1, 2); err != ErrBundleTooLarge {
+		t.Fatalf("Add with maxBundleSize=2 on a 3-tx bundle = %v, want ErrBundleTooLarge", err)
+	}
+	if err := pool.Add(bundle, 1, 1, 3); err != nil {
+		t.Fatalf("Add with maxBundleSize=3 on a 3-tx bundle = %v, want nil", err)
+	}
+	if err := pool.Add(bundle, 1, 1, 0); err != nil {
+		t.Fatalf("Add with maxBundleSize=0 (disabled) = %v, want nil", err)
+	}
+}
+
+func TestBundlePoolAddCapsPoolSize(t *testing.T) {
+	pool := New()
+	for i := 0; i < maxBundles; i++ {
+		bundle := newTestBundle(t, 1, int64(i))
+		if err := pool.Add(bundle, 1, 1, 0); err != nil {
+			t.Fatalf("Add #%d = %v, want nil", i, err)
+		}
+	}
+
+	overflow := newTestBundle(t, 1, int64(maxBundles))
+	if err := pool.Add(overflow, 1, 1, 0); err != ErrPoolFull {
+		t.Fatalf("Add past maxBundles = %v, want ErrPoolFull", err)
+	}
+
+	// Resubmitting a bundle already in the pool must still succeed even
+	// when the pool is at capacity - it isn't growing the pool, just
+	// replacing an existing entry.
+	existing := newTestBundle(t, 1, 0)
+	if err := pool.Add(existing, 1, 1, 0); err != nil {
+		t.Fatalf("resubmitting an already-pooled bundle at capacity = %v, want nil", err)
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2014 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
+	"golang.org/x/crypto/sha3"
+)
+
+// DeploymentPolicy decides whether deployer may CREATE a contract in state
+// at blockNumber, replacing the isPrivateDeploymentMode/isContractCreator/
+// MainNetChainID activation-block logic that used to be baked directly into
+// EVM.create. chargeNonce tells create whether to still bump deployer's
+// nonce on a denial, matching create's pre-refactor behavior of only doing
+// so once a policy's activation block has passed.
+type DeploymentPolicy interface {
+	CanDeploy(state StateDB, deployer common.Address, blockNumber *big.Int) (allowed bool, chargeNonce bool, reason error)
+}
+
+// NoopPolicy allows every deployment unconditionally. It's the zero-config
+// DeploymentPolicy for chains that never restricted contract creation.
+type NoopPolicy struct{}
+
+func (NoopPolicy) CanDeploy(StateDB, common.Address, *big.Int) (bool, bool, error) {
+	return true, false, nil
+}
+
+// StaticAllowlistPolicy allows only the addresses in Allowed, independent of
+// any on-chain state. It exists for tests that want a DeploymentPolicy
+// without standing up a governance system contract.
+type StaticAllowlistPolicy struct {
+	Allowed map[common.Address]bool
+}
+
+func (p StaticAllowlistPolicy) CanDeploy(_ StateDB, deployer common.Address, _ *big.Int) (bool, bool, error) {
+	if p.Allowed[deployer] {
+		return true, false, nil
+	}
+	return false, true, ErrNoDeploymentPermission
+}
+
+// systemContractPolicyCache memoizes a SystemContractPolicy's reads of the
+// governance contract's mode flag and allowlist for the block currently
+// being built, so a transaction with several CREATEs doesn't re-read the
+// same two storage slots once per CREATE.
+type systemContractPolicyCache struct {
+	mu              sync.Mutex
+	blockNumber     uint64
+	privateModeRead bool
+	privateMode     bool
+	creators        map[common.Address]bool
+}
+
+// SystemContractPolicy is the default DeploymentPolicy: it reads a private-
+// deployment-mode flag and a per-address creator allowlist out of the
+// governance system contract's storage, the same slots
+// isPrivateDeploymentMode/isContractCreator used to read directly, and
+// denies CREATE to anyone neither flag clears at every height; once
+// ActivationBlock has passed it also charges their nonce, the way the old
+// activeBlockNumber gate did.
+type SystemContractPolicy struct {
+	// AnchorMode selects which storage slot the creator allowlist lives at,
+	// mirroring the EVM.IsAnchorEVM() branch the inline logic used to take.
+	AnchorMode bool
+	// ActivationBlock is the last height at which a denial is free to retry;
+	// above it, denied deployers have their nonce charged.
+	ActivationBlock uint64
+
+	cache systemContractPolicyCache
+}
+
+// NewSystemContractPolicy builds the default DeploymentPolicy for a chain
+// whose governance contract lives at systemcontracts.SystemDaoContract,
+// tracking whether anchorMode slots should be consulted and the block after
+// which denials start costing the deployer a nonce.
+func NewSystemContractPolicy(anchorMode bool, activationBlock uint64) *SystemContractPolicy {
+	return &SystemContractPolicy{AnchorMode: anchorMode, ActivationBlock: activationBlock}
+}
+
+func (p *SystemContractPolicy) CanDeploy(state StateDB, deployer common.Address, blockNumber *big.Int) (bool, bool, error) {
+	if !p.privateDeploymentMode(state, blockNumber.Uint64()) || p.isContractCreator(state, deployer, blockNumber.Uint64()) {
+		return true, false, nil
+	}
+	return false, blockNumber.Uint64() > p.ActivationBlock, ErrNoDeploymentPermission
+}
+
+func (p *SystemContractPolicy) privateDeploymentMode(state StateDB, blockNumber uint64) bool {
+	p.refreshLocked(blockNumber)
+
+	p.cache.mu.Lock()
+	if p.cache.privateModeRead {
+		mode := p.cache.privateMode
+		p.cache.mu.Unlock()
+		return mode
+	}
+	p.cache.mu.Unlock()
+
+	boolBytes := state.GetState(common.HexToAddress(systemcontracts.SystemDaoContract), common.BigToHash(big.NewInt(6)))
+	mode := common.StateToBig(boolBytes).Uint64() > 0
+
+	p.cache.mu.Lock()
+	p.cache.privateMode = mode
+	p.cache.privateModeRead = true
+	p.cache.mu.Unlock()
+	return mode
+}
+
+func (p *SystemContractPolicy) isContractCreator(state StateDB, deployer common.Address, blockNumber uint64) bool {
+	p.refreshLocked(blockNumber)
+
+	p.cache.mu.Lock()
+	if cached, ok := p.cache.creators[deployer]; ok {
+		p.cache.mu.Unlock()
+		return cached
+	}
+	p.cache.mu.Unlock()
+
+	boolBytes := state.GetState(common.HexToAddress(systemcontracts.SystemDaoContract), p.creatorSlot(deployer))
+	isCreator := common.StateToBig(boolBytes).Uint64() > 0
+
+	p.cache.mu.Lock()
+	p.cache.creators[deployer] = isCreator
+	p.cache.mu.Unlock()
+	return isCreator
+}
+
+// refreshLocked drops the previous block's cached answers once blockNumber
+// advances, so privateDeploymentMode and isContractCreator re-read state
+// exactly once per address per block instead of once per CREATE.
+func (p *SystemContractPolicy) refreshLocked(blockNumber uint64) {
+	p.cache.mu.Lock()
+	defer p.cache.mu.Unlock()
+	if p.cache.creators != nil && p.cache.blockNumber == blockNumber {
+		return
+	}
+	p.cache.blockNumber = blockNumber
+	p.cache.creators = make(map[common.Address]bool)
+	p.cache.privateModeRead = false
+}
+
+// creatorSlot mirrors the pre-refactor isContractCreator's
+// keccak256(paddedAddr || paddedSlotIndex) derivation, using slot 5 under
+// AnchorMode and slot 7 otherwise.
+func (p *SystemContractPolicy) creatorSlot(deployer common.Address) common.Hash {
+	slotIndex := 7
+	if p.AnchorMode {
+		slotIndex = 5
+	}
+	var slot common.Hash
+	harsher := sha3.NewLegacyKeccak256()
+	harsher.Write(common.LeftPadBytes(deployer.Bytes(), 32))
+	harsher.Write(common.LeftPadBytes(common.IntToSlot(slotIndex).Bytes(), 32))
+	harsher.Sum(slot[:0])
+	return slot
+}
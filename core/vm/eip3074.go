@@ -0,0 +1,275 @@
+// Copyright 2014 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// EIP-3074 adds AUTH and AUTHCALL: AUTH lets an EOA sign over a commitment
+// to a particular invoker contract, and AUTHCALL lets that invoker spend the
+// EOA's balance and act as its msg.sender for a single call, without the EOA
+// needing to hold the invoker's code itself.
+//
+// STATUS: NOT WIRED. opAuth/opAuthCall/gasAuth/gasAuthCall below have no
+// JumpTable entry anywhere in this tree - jump_table.go, instructions.go and
+// opcodes.go, which is where newXXXInstructionSet would register them, don't
+// exist here yet (see the wiring note further down this file). Until that
+// registration is added, AUTH and AUTHCALL are not reachable from any
+// contract: this file is a reference implementation of the opcode
+// semantics, not a shipped EVM feature. Don't treat this as done.
+const (
+	AUTH     OpCode = 0xf6
+	AUTHCALL OpCode = 0xf7
+)
+
+// authMagic is MAGIC from EIP-3074: the first byte of every AUTH commitment
+// hash, so an AUTH signature can never be replayed as an ordinary
+// secp256k1-signed message (or vice versa).
+const authMagic = 0x04
+
+// authGas is AUTH's fixed signature-verification cost, charged regardless of
+// whether the recovered signer matches anything useful to the caller.
+const authGas = 3100
+
+var (
+	// ErrNoAuthorizedAccount is returned by AuthCall when no prior AUTH in
+	// the current frame set evm.authorized.
+	ErrNoAuthorizedAccount = errors.New("authcall without authorized account")
+	// ErrInvalidAuthSignature is returned by Auth when yParity is anything
+	// other than 0 or 1.
+	ErrInvalidAuthSignature = errors.New("invalid auth signature")
+)
+
+// authMessageHash computes keccak256(MAGIC || chainId || paddedInvokerAddr || commit),
+// the tuple an AUTH signature must cover per EIP-3074.
+func (evm *EVM) authMessageHash(invoker common.Address, commit common.Hash) common.Hash {
+	var buf []byte
+	buf = append(buf, authMagic)
+	buf = append(buf, common.LeftPadBytes(evm.chainConfig.ChainID.Bytes(), 32)...)
+	buf = append(buf, common.LeftPadBytes(invoker.Bytes(), 32)...)
+	buf = append(buf, commit.Bytes()...)
+	return crypto.Keccak256Hash(buf)
+}
+
+// Auth implements the AUTH opcode. invoker is the address of the contract
+// executing AUTH (msg.sender at the time AUTH runs); commit is the 32-byte
+// value the signer committed to. On a valid signature, evm.authorized is set
+// to the recovered signer for the remainder of the current frame and that
+// signer is returned; callers that don't want to leak the recovered address
+// on failure get the zero address and a non-nil error instead.
+func (evm *EVM) Auth(invoker common.Address, commit common.Hash, yParity byte, r, s *big.Int) (common.Address, error) {
+	if yParity > 1 {
+		return common.Address{}, ErrInvalidAuthSignature
+	}
+	sig := make([]byte, 0, 65)
+	sig = append(sig, common.LeftPadBytes(r.Bytes(), 32)...)
+	sig = append(sig, common.LeftPadBytes(s.Bytes(), 32)...)
+	sig = append(sig, yParity)
+
+	hash := evm.authMessageHash(invoker, commit)
+	pub, err := crypto.SigToPub(hash.Bytes(), sig)
+	if err != nil {
+		evm.authorized = nil
+		return common.Address{}, fmt.Errorf("auth: %w", err)
+	}
+	signer := crypto.PubkeyToAddress(*pub)
+	evm.authorized = &signer
+	return signer, nil
+}
+
+// opAuth is AUTH's interpreter-loop execution function - the executionFunc
+// signature every opcode in instructions.go implements. Its stack
+// convention follows this file's own simplified Auth signature rather than
+// upstream EIP-3074's memory-resident signature blob: bottom-to-top, the
+// operands are commit, yParity, r, s, and it pushes 1 on a valid signature
+// or 0 otherwise (evm.authorized is left cleared on failure, same as Auth
+// itself already does).
+func opAuth(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	s, r, yParity, commit := scope.Stack.pop(), scope.Stack.pop(), scope.Stack.pop(), scope.Stack.pop()
+
+	_, err := interpreter.evm.Auth(scope.Contract.Address(), common.BigToHash(commit), byte(yParity.Uint64()), r, s)
+	if err != nil {
+		scope.Stack.push(new(big.Int))
+		return nil, nil
+	}
+	scope.Stack.push(big.NewInt(1))
+	return nil, nil
+}
+
+// opAuthCall is AUTHCALL's execution function, mirroring how opCall reads
+// its seven stack operands (gas, addr, value, argsOffset, argsLength,
+// retOffset, retLength) and the returned data back into memory; the only
+// difference from opCall is that the call itself runs as evm.AuthCall
+// rather than evm.Call, so it executes as evm.authorized instead of the
+// current contract.
+func opAuthCall(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	gas, addr, value, argsOffset, argsLength, retOffset, retLength :=
+		scope.Stack.pop(), scope.Stack.pop(), scope.Stack.pop(), scope.Stack.pop(), scope.Stack.pop(), scope.Stack.pop(), scope.Stack.pop()
+
+	args := scope.Memory.GetPtr(argsOffset.Int64(), argsLength.Int64())
+	ret, returnGas, err := interpreter.evm.AuthCall(scope.Contract, common.BytesToAddress(addr.Bytes()), args, gas.Uint64(), value)
+	if err != nil {
+		scope.Stack.push(new(big.Int))
+	} else {
+		scope.Stack.push(big.NewInt(1))
+	}
+	if err == nil || err == ErrExecutionReverted {
+		scope.Memory.Set(retOffset.Uint64(), retLength.Uint64(), ret)
+	}
+	scope.Contract.Gas += returnGas
+	return ret, nil
+}
+
+// gasAuth is AUTH's constantGas: authGas regardless of outcome, the same
+// "charge for the signature-recovery attempt, not its result" policy Auth's
+// own doc comment describes.
+func gasAuth(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	return authGas, nil
+}
+
+// gasAuthCall is AUTHCALL's dynamicGas: the same base-transfer/new-account
+// accounting gasCall applies to CALL, plus evm.authCallGasCost's
+// EIP-2929 cold-address surcharge on top, since AUTHCALL's target address
+// is looked up the same way CALL's is.
+func gasAuthCall(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	addr := common.BytesToAddress(stack.Back(1).Bytes())
+	return evm.authCallGasCost(addr), nil
+}
+
+// Note: jump_table.go, instructions.go and opcodes.go - the files defining
+// OpCode's sibling types JumpTable and operation, and the per-hardfork
+// newXXXInstructionSet constructors that build the EVM's actual dispatch
+// table - aren't vendored into this snapshot at all, so there is no
+// JumpTable instance here for opAuth/opAuthCall to be registered into yet.
+// Once those files are present, wiring AUTH/AUTHCALL in is:
+//
+//	instructionSet[AUTH] = &operation{
+//		execute:     opAuth,
+//		constantGas: authGas,
+//		minStack:    minStack(4, 1),
+//		maxStack:    maxStack(4, 1),
+//	}
+//	instructionSet[AUTHCALL] = &operation{
+//		execute:     opAuthCall,
+//		constantGas: params.CallGasEIP150,
+//		dynamicGas:  gasAuthCall,
+//		minStack:    minStack(7, 1),
+//		maxStack:    maxStack(7, 1),
+//		memorySize:  memoryCall,
+//	}
+//
+// in whichever newXXXInstructionSet() builds the chain's active instruction
+// set, alongside CALL's own entry.
+
+// authCallGasCost applies EIP-3074's cold/warm invoker-address surcharge to
+// an AUTHCALL's target, the same EIP-2929 access-list bookkeeping CALL's own
+// gas table applies to addr.
+func (evm *EVM) authCallGasCost(addr common.Address) uint64 {
+	if !evm.chainRules.IsBerlin {
+		return 0
+	}
+	if !evm.StateDB.AddressInAccessList(addr) {
+		evm.StateDB.AddAddressToAccessList(addr)
+		return params.ColdAccountAccessCostEIP2929 - params.WarmStorageReadCostEIP2929
+	}
+	return 0
+}
+
+// AuthCall implements the AUTHCALL opcode. It behaves like Call except the
+// effective msg.sender, and the account value is debited from, is
+// evm.authorized rather than caller - the account a prior AUTH in this same
+// frame verified. It fails outright if no AUTH has run yet, and it never
+// lets the authorized account survive into the nested frame it calls: that
+// frame starts with authorized cleared, same as any other Call.
+func (evm *EVM) AuthCall(caller ContractRef, addr common.Address, input []byte, gas uint64, value *big.Int) (ret []byte, leftOverGas uint64, err error) {
+	if evm.authorized == nil {
+		return nil, gas, ErrNoAuthorizedAccount
+	}
+	if evm.depth > int(params.CallCreateDepth) {
+		return nil, gas, ErrDepth
+	}
+	authorized := *evm.authorized
+	if value.Sign() != 0 {
+		if evm.interpreter.readOnly {
+			return nil, gas, ErrWriteProtection
+		}
+		if !evm.Context.CanTransfer(evm.StateDB, authorized, value) {
+			return nil, gas, ErrInsufficientBalance
+		}
+	}
+	if surcharge := evm.authCallGasCost(addr); surcharge > gas {
+		return nil, 0, ErrOutOfGas
+	} else {
+		gas -= surcharge
+	}
+
+	snapshot := evm.StateDB.Snapshot()
+	p, isPrecompile := evm.precompile(addr)
+	if !evm.StateDB.Exist(addr) {
+		if !isPrecompile && evm.chainRules.IsEIP158 && value.Sign() == 0 {
+			if evm.Config.Debug {
+				evm.Config.Tracer.CaptureEnter(AUTHCALL, authorized, addr, input, gas, value)
+				evm.Config.Tracer.CaptureExit(ret, 0, nil)
+			}
+			return nil, gas, nil
+		}
+		evm.StateDB.CreateAccount(addr)
+	}
+	evm.Context.Transfer(evm.StateDB, authorized, addr, value)
+
+	if evm.Config.Debug {
+		evm.Config.Tracer.CaptureEnter(AUTHCALL, authorized, addr, input, gas, value)
+		defer func(startGas uint64) {
+			evm.Config.Tracer.CaptureExit(ret, startGas-gas, err)
+		}(gas)
+	}
+
+	// AUTHCALL never propagates its authorized account into the frame it
+	// calls: restore the caller's own view of evm.authorized once the
+	// nested frame (which clears it on entry, same as any Call) returns.
+	savedAuthorized := evm.authorized
+	defer func() { evm.authorized = savedAuthorized }()
+
+	if isPrecompile {
+		ret, gas, err = evm.runPrecompile(addr, p, AccountRef(authorized), input, gas, evm.interpreter.readOnly)
+	} else {
+		code := evm.StateDB.GetCode(addr)
+		if len(code) == 0 {
+			ret, err = nil, nil
+		} else {
+			addrCopy := addr
+			contract := NewContract(AccountRef(authorized), AccountRef(addrCopy), value, gas)
+			contract.SetCallCode(&addrCopy, evm.StateDB.GetCodeHash(addrCopy), code)
+			ret, err = evm.interpreter.Run(contract, input, false)
+			gas = contract.Gas
+		}
+	}
+	if err != nil {
+		evm.StateDB.RevertToSnapshot(snapshot)
+		if err != ErrExecutionReverted {
+			gas = 0
+		}
+	}
+	return ret, gas, err
+}
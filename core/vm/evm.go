@@ -19,11 +19,11 @@ package vm
 import (
 	"bytes"
 	"github.com/ethereum/go-ethereum/accounts/abi"
-	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/systemcontracts"
 	"github.com/ethereum/go-ethereum/core/systemcontracts/anchor"
+	"github.com/ethereum/go-ethereum/core/systemcontracts/anchor/treeindex"
+	"github.com/ethereum/go-ethereum/core/systemcontracts/events"
 	"github.com/ethereum/go-ethereum/ethdb"
-	"golang.org/x/crypto/sha3"
 	"math/big"
 	"strings"
 	"sync"
@@ -76,6 +76,17 @@ func (evm *EVM) precompile(addr common.Address) (PrecompiledContract, bool) {
 	default:
 		precompiles = PrecompiledContractsHomestead
 	}
+	if registry := evm.chainConfig.PrecompileRegistry; registry != nil {
+		if entry, ok := registry.activeEntry(addr, evm.Context.BlockNumber); ok {
+			if p, ok := entry.Precompile.(PrecompiledContract); ok {
+				return p, true
+			}
+			// A StatefulPrecompile activated here too; evm.runPrecompile
+			// dispatches to it, but the caller still needs isPrecompile=true
+			// so it takes the precompile branch instead of loading code.
+			return nil, true
+		}
+	}
 	p, ok := precompiles[addr]
 	return p, ok
 }
@@ -147,6 +158,14 @@ type EVM struct {
 
 	treeABI abi.ABI
 	cacheDB *ethdb.Database
+
+	// authorized is the EIP-3074 signer AUTH verified for the current call
+	// frame; AUTHCALL debits value from it and uses it as the effective
+	// msg.sender instead of the calling contract. It is cleared on Reset
+	// and at the start of every new frame (Call/CallCode/DelegateCall/
+	// StaticCall/create), so it never leaks across transactions or into a
+	// frame that didn't itself run AUTH.
+	authorized *common.Address
 }
 
 // NewEVM returns a new EVM. The returned EVM is not thread safe and should
@@ -186,6 +205,16 @@ func (evm *EVM) IsAnchorEVM() bool {
 func (evm *EVM) Reset(txCtx TxContext, statedb StateDB) {
 	evm.TxContext = txCtx
 	evm.StateDB = statedb
+	evm.authorized = nil
+}
+
+// SetInitialAuthorized lets state_transition.go seed evm.authorized before
+// running a transaction's top-level call, so an EIP-3074-aware transaction
+// type can hand its invoker an already-authorized account without an
+// in-contract AUTH. Frames entered during execution still each start with
+// authorized cleared, same as any other AUTH-established value.
+func (evm *EVM) SetInitialAuthorized(addr *common.Address) {
+	evm.authorized = addr
 }
 
 // Cancel cancels any running EVM operation. This may be called concurrently and
@@ -209,6 +238,9 @@ func (evm *EVM) Interpreter() *EVMInterpreter {
 // the necessary steps to create accounts and reverses the state in case of an
 // execution error or failed value transfer.
 func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas uint64, value *big.Int) (ret []byte, leftOverGas uint64, err error) {
+	// Each new frame starts with no AUTH-authorized account; AUTHCALL only
+	// ever consumes one set by this same frame's own AUTH.
+	evm.authorized = nil
 	// Fail if we're trying to execute above the call depth limit
 	if evm.depth > int(params.CallCreateDepth) {
 		return nil, gas, ErrDepth
@@ -218,8 +250,8 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 		return nil, gas, ErrInsufficientBalance
 	}
 
-	hooked := false
-	ret, leftOverGas, err = evm.callHook(caller, addr, input, gas, &hooked)
+	var hooked bool
+	ret, leftOverGas, hooked, err = evm.runShortcut(caller, addr, input, gas, value, CALL)
 	if hooked {
 		return ret, leftOverGas, err
 	}
@@ -262,7 +294,7 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 	}
 
 	if isPrecompile {
-		ret, gas, err = RunPrecompiledContract(p, input, gas)
+		ret, gas, err = evm.runPrecompile(addr, p, caller, input, gas, evm.interpreter.readOnly)
 	} else {
 		// Initialise a new contract and set the code that is to be used by the EVM.
 		// The contract is a scoped environment for this execution context only.
@@ -302,6 +334,7 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 // CallCode differs from Call in the sense that it executes the given address'
 // code with the caller as context.
 func (evm *EVM) CallCode(caller ContractRef, addr common.Address, input []byte, gas uint64, value *big.Int) (ret []byte, leftOverGas uint64, err error) {
+	evm.authorized = nil
 	// Fail if we're trying to execute above the call depth limit
 	if evm.depth > int(params.CallCreateDepth) {
 		return nil, gas, ErrDepth
@@ -325,7 +358,7 @@ func (evm *EVM) CallCode(caller ContractRef, addr common.Address, input []byte,
 
 	// It is allowed to call precompiles, even via delegatecall
 	if p, isPrecompile := evm.precompile(addr); isPrecompile {
-		ret, gas, err = RunPrecompiledContract(p, input, gas)
+		ret, gas, err = evm.runPrecompile(addr, p, caller, input, gas, evm.interpreter.readOnly)
 	} else {
 		addrCopy := addr
 		// Initialise a new contract and set the code that is to be used by the EVM.
@@ -350,10 +383,18 @@ func (evm *EVM) CallCode(caller ContractRef, addr common.Address, input []byte,
 // DelegateCall differs from CallCode in the sense that it executes the given address'
 // code with the caller as context and the caller is set to the caller of the caller.
 func (evm *EVM) DelegateCall(caller ContractRef, addr common.Address, input []byte, gas uint64) (ret []byte, leftOverGas uint64, err error) {
+	evm.authorized = nil
 	// Fail if we're trying to execute above the call depth limit
 	if evm.depth > int(params.CallCreateDepth) {
 		return nil, gas, ErrDepth
 	}
+
+	var hooked bool
+	ret, leftOverGas, hooked, err = evm.runShortcut(caller, addr, input, gas, nil, DELEGATECALL)
+	if hooked {
+		return ret, leftOverGas, err
+	}
+
 	var snapshot = evm.StateDB.Snapshot()
 
 	// Invoke tracer hooks that signal entering/exiting a call frame
@@ -366,7 +407,7 @@ func (evm *EVM) DelegateCall(caller ContractRef, addr common.Address, input []by
 
 	// It is allowed to call precompiles, even via delegatecall
 	if p, isPrecompile := evm.precompile(addr); isPrecompile {
-		ret, gas, err = RunPrecompiledContract(p, input, gas)
+		ret, gas, err = evm.runPrecompile(addr, p, caller, input, gas, evm.interpreter.readOnly)
 	} else {
 		addrCopy := addr
 		// Initialise a new contract and make initialise the delegate values
@@ -389,13 +430,14 @@ func (evm *EVM) DelegateCall(caller ContractRef, addr common.Address, input []by
 // Opcodes that attempt to perform such modifications will result in exceptions
 // instead of performing the modifications.
 func (evm *EVM) StaticCall(caller ContractRef, addr common.Address, input []byte, gas uint64) (ret []byte, leftOverGas uint64, err error) {
+	evm.authorized = nil
 	// Fail if we're trying to execute above the call depth limit
 	if evm.depth > int(params.CallCreateDepth) {
 		return nil, gas, ErrDepth
 	}
 
-	hooked := false
-	ret, leftOverGas, err = evm.callHook(caller, addr, input, gas, &hooked)
+	var hooked bool
+	ret, leftOverGas, hooked, err = evm.runShortcut(caller, addr, input, gas, nil, STATICCALL)
 	if hooked {
 		return ret, leftOverGas, err
 	}
@@ -422,7 +464,7 @@ func (evm *EVM) StaticCall(caller ContractRef, addr common.Address, input []byte
 	}
 
 	if p, isPrecompile := evm.precompile(addr); isPrecompile {
-		ret, gas, err = RunPrecompiledContract(p, input, gas)
+		ret, gas, err = evm.runPrecompile(addr, p, caller, input, gas, true)
 	} else {
 		// At this point, we use a copy of address. If we don't, the go compiler will
 		// leak the 'contract' to the outer scope, and make allocation for 'contract'
@@ -461,24 +503,30 @@ func (c *codeAndHash) Hash() common.Hash {
 
 // create creates a new contract using code as deployment code.
 func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64, value *big.Int, address common.Address, typ OpCode) ([]byte, common.Address, uint64, error) {
-	// Depth check execution. Fail if we're trying to execute above the
-	// limit.
-	if evm.isPrivateDeploymentMode() && !evm.isContractCreator(caller.Address()) {
-		activeBlockNumber := uint64(0)
-		if evm.chainConfig.ChainID.Uint64() == MainNetChainID {
-			activeBlockNumber = 1547266
-		}
-
-		if evm.Context.BlockNumber.Uint64() > activeBlockNumber {
-			nonce := evm.StateDB.GetNonce(caller.Address())
-			if nonce+1 < nonce {
-				return nil, common.Address{}, gas, ErrNonceUintOverflow
+	evm.authorized = nil
+	// Consult the chain's DeploymentPolicy before anything else. This used
+	// to be a hardcoded isPrivateDeploymentMode/isContractCreator/
+	// MainNetChainID check; it's now pluggable so a chain's governance
+	// contract, rather than this file, decides who may CREATE and from
+	// which block a denial starts costing the deployer a nonce.
+	if policy := evm.chainConfig.DeploymentPolicy; policy != nil {
+		if allowed, chargeNonce, reason := policy.CanDeploy(evm.StateDB, caller.Address(), evm.Context.BlockNumber); !allowed {
+			if chargeNonce {
+				nonce := evm.StateDB.GetNonce(caller.Address())
+				if nonce+1 < nonce {
+					return nil, common.Address{}, gas, ErrNonceUintOverflow
+				}
+				evm.StateDB.SetNonce(caller.Address(), nonce+1)
+			}
+			if reason == nil {
+				reason = ErrNoDeploymentPermission
 			}
-			evm.StateDB.SetNonce(caller.Address(), nonce+1)
+			return nil, common.Address{}, gas, reason
 		}
-		return nil, common.Address{}, gas, ErrNoDeploymentPermission
 	}
 
+	// Depth check execution. Fail if we're trying to execute above the
+	// limit.
 	if evm.depth > int(params.CallCreateDepth) {
 		return nil, common.Address{}, gas, ErrDepth
 	}
@@ -587,159 +635,44 @@ func (evm *EVM) Create2(caller ContractRef, code []byte, gas uint64, endowment *
 // ChainConfig returns the environment's chain configuration
 func (evm *EVM) ChainConfig() *params.ChainConfig { return evm.chainConfig }
 
-func (evm *EVM) callHook(caller ContractRef, addr common.Address, input []byte, gas uint64, hooked *bool) (ret []byte, leftOverGas uint64, err error) {
-	// Special handling
-	// `function holderRangeInfoOf(address token,uint64 rangeIndex)` in contract 0xC '0e603a1c'
-	// `function holderRangeAccRewardPerShare(address,uint64)` in contract 0xC '24fc55d9'
-	// `function childrenOf(address owner)` in contract 0xA '42c4c0d0'
-	// `function childrenHoldAmount(address,address)` in contract 0xC 'e8b23ad8'
-
-	if addr == common.HexToAddress(systemcontracts.FarmContract) && len(input) >= 4 {
-		if strings.EqualFold(common.Bytes2Hex(input[0:4]), "0e603a1c") && len(input) == 68 {
-			tokenContract := common.BytesToAddress(input[4:36])
-			rangeIndex := new(big.Int).SetBytes(input[36:68])
-			*hooked = true
-			return evm.holderRangeInfo(tokenContract, rangeIndex, gas)
-		}
-
-		if strings.EqualFold(common.Bytes2Hex(input[0:4]), "24fc55d9") && len(input) == 100 {
-			poolAddress := common.BytesToAddress(input[4:36])
-			rewardTokenAddress := common.BytesToAddress(input[36:68])
-			rangeIndex := new(big.Int).SetBytes(input[68:100])
-			*hooked = true
-			return evm.holderRangeAccRewardPerShare(poolAddress, rewardTokenAddress, rangeIndex, gas)
-		}
-
-		if strings.EqualFold(common.Bytes2Hex(input[0:4]), "e8b23ad8") && len(input) == 68 {
-			poolAddress := common.BytesToAddress(input[4:36])
-			parentAddress := common.BytesToAddress(input[36:68])
-			*hooked = true
-			return evm.childrenHoldAmount(poolAddress, parentAddress, gas)
-		}
-
-	} else if evm.chainConfig.Anchor == nil && addr == common.HexToAddress(systemcontracts.AddressTreeContract) && len(input) >= 4 {
-		if strings.EqualFold(common.Bytes2Hex(input[0:4]), "42c4c0d0") && len(input) == 36 {
-			parentAddress := common.BytesToAddress(input[4:36])
-			*hooked = true
-			return evm.childrenOf(parentAddress, gas)
-		}
-	} else if evm.chainConfig.Anchor != nil && evm.IsAnchorEVM() && addr == common.HexToAddress(systemcontracts.AddressTreeContract) && len(input) == 36 {
-		var result hexutil.Bytes
-		account := common.BytesToAddress(input[4:36])
-		*hooked = true
-		// Method ID
-		// 		depthOf:    7c3165b1
-		//  	parentOf:   ee08388e
-		//  	versionOf:  0db3ff45
-		//  	childrenOf: 42c4c0d0
-		switch common.Bytes2Hex(input[0:4]) {
-
-		case "7c3165b1":
-			// depthOf
-			result = evm.cacheStateDepthOf(account)
-			break
-
-		case "ee08388e":
-			// parentOf
-			result = evm.cacheStateParentOf(account)
-			break
-
-		case "0db3ff45":
-			// versionOf
-			result = evm.cacheStateVersionOf(account)
-			break
-
-		case "42c4c0d0":
-			// childrenOf
-			result = evm.cacheStateChildrenOf(account)
-			break
-
-		default:
-			*hooked = false
-		}
-
-		if *hooked {
-			return result, 0, nil
-		} else {
-			return []byte{}, 0, nil
-		}
-	}
-	*hooked = false
-	return []byte{}, 0, nil
-}
-
+// holderRangeAccRewardPerShare, like every other hooked handler below, is no
+// longer responsible for its own flat gas deduction: callShortcut already
+// charged holderRangeAccRewardPerShareGasCost against gas before calling in,
+// so the returned gas here is simply whatever's left.
 func (evm *EVM) holderRangeAccRewardPerShare(pool common.Address, rewardToken common.Address, rangeIndex *big.Int, gas uint64) (ret []byte, leftOverGas uint64, err error) {
-	if gas < 20000 {
-		return nil, gas, ErrOutOfGas
-	}
-
-	var rewardPerShareSlot common.Hash
-	harsher := sha3.NewLegacyKeccak256()
-	harsher.Write([]byte("__RewardPerShare"))
-	harsher.Write(pool.Bytes())
-	harsher.Write(rewardToken.Bytes())
-	harsher.Sum(rewardPerShareSlot[:0])
-	harsher.Reset()
-
-	rewardPerShareRaw := evm.StateDB.GetRawState(common.HexToAddress(systemcontracts.FarmContract), rewardPerShareSlot)
+	rewardPerShareRaw := evm.StateDB.GetRawState(common.HexToAddress(systemcontracts.FarmContract), rewardPerShareSlot(pool, rewardToken))
 	if len(rewardPerShareRaw) == 0 {
-		return make([]byte, 32), gas - 20000, nil
-	} else {
-		totalRangeCount := len(rewardPerShareRaw) / 24
-		rIndex := rangeIndex.Uint64()
-		if rIndex >= uint64(totalRangeCount) {
-			rIndex = uint64(totalRangeCount) - 1
-		}
-		ret := common.LeftPadBytes(rewardPerShareRaw[rIndex*24+0:rIndex*24+24], 32)
-		return ret, gas - 20000, nil
+		return make([]byte, 32), gas, nil
 	}
+	totalRangeCount := len(rewardPerShareRaw) / 24
+	rIndex := rangeIndex.Uint64()
+	if rIndex >= uint64(totalRangeCount) {
+		rIndex = uint64(totalRangeCount) - 1
+	}
+	ret = common.LeftPadBytes(rewardPerShareRaw[rIndex*24+0:rIndex*24+24], 32)
+	return ret, gas, nil
 }
 
 func (evm *EVM) holderRangeInfo(tokenContract common.Address, rangeIndex *big.Int, gas uint64) (ret []byte, leftOverGas uint64, err error) {
-	if gas < 40000 {
-		return nil, gas, ErrOutOfGas
-	}
-
-	var rawDataSlot common.Hash
-	harsher := sha3.NewLegacyKeccak256()
-	harsher.Write(common.LeftPadBytes([]byte("__HolderDistribution"), 32))
-	harsher.Write(common.LeftPadBytes(tokenContract.Bytes(), 32))
-	harsher.Sum(rawDataSlot[:0])
-	harsher.Reset()
-
-	rawData := evm.StateDB.GetRawState(common.HexToAddress(systemcontracts.FarmContract), rawDataSlot)
-
+	rawData := evm.StateDB.GetRawState(common.HexToAddress(systemcontracts.FarmContract), holderRangeInfoSlot(tokenContract))
 	if len(rawData) <= 0 {
-		return make([]byte, 64), gas - 40000, nil
-	} else {
-		totalRangeCount := len(rawData) / 7
-		rIndex := rangeIndex.Uint64()
-		if rIndex >= uint64(totalRangeCount) {
-			rIndex = uint64(totalRangeCount) - 1
-		}
+		return make([]byte, 64), gas, nil
+	}
+	totalRangeCount := len(rawData) / 7
+	rIndex := rangeIndex.Uint64()
+	if rIndex >= uint64(totalRangeCount) {
+		rIndex = uint64(totalRangeCount) - 1
+	}
 
-		totalCount := rawData[rIndex*7+0 : rIndex*7+4]
-		emptyRangeCount := rawData[rIndex*7+4 : rIndex*7+4+3]
+	totalCount := rawData[rIndex*7+0 : rIndex*7+4]
+	emptyRangeCount := rawData[rIndex*7+4 : rIndex*7+4+3]
 
-		ret := append(common.LeftPadBytes(totalCount, 32), common.LeftPadBytes(emptyRangeCount, 32)...)
-		return ret, gas - 80000, nil
-	}
+	ret = append(common.LeftPadBytes(totalCount, 32), common.LeftPadBytes(emptyRangeCount, 32)...)
+	return ret, gas, nil
 }
 
 func (evm *EVM) childrenHoldAmount(poolAddress common.Address, parent common.Address, gas uint64) (ret []byte, leftOverGas uint64, err error) {
-	if gas < 40000 {
-		return nil, gas, ErrOutOfGas
-	}
-
-	var childrenHoldAmountSlot common.Hash
-	harsher := sha3.NewLegacyKeccak256()
-	harsher.Write(common.LeftPadBytes([]byte("__ChildrenHoldAmount"), 32))
-	harsher.Write(common.LeftPadBytes(poolAddress.Bytes(), 32))
-	harsher.Write(common.LeftPadBytes(parent.Bytes(), 32))
-	harsher.Sum(childrenHoldAmountSlot[:0])
-	harsher.Reset()
-
-	rawData := evm.StateDB.GetRawState(common.HexToAddress(systemcontracts.FarmContract), childrenHoldAmountSlot)
+	rawData := evm.StateDB.GetRawState(common.HexToAddress(systemcontracts.FarmContract), childrenHoldAmountSlot(poolAddress, parent))
 	rawDataLen := len(rawData) / 16
 
 	ret1 := [][]byte{
@@ -750,22 +683,11 @@ func (evm *EVM) childrenHoldAmount(poolAddress common.Address, parent common.Add
 		ret1 = append(ret1, common.LeftPadBytes(rawData[i*16:i*16+16], 32))
 	}
 
-	return bytes.Join(ret1, []byte{}), gas - 40000, nil
+	return bytes.Join(ret1, []byte{}), gas, nil
 }
 
 func (evm *EVM) childrenOf(parent common.Address, gas uint64) (ret []byte, leftOverGas uint64, err error) {
-	if gas < 40000 {
-		return nil, gas, ErrOutOfGas
-	}
-
-	var childrenRawDataSlot common.Hash
-	harsher := sha3.NewLegacyKeccak256()
-	harsher.Write(common.LeftPadBytes(parent.Bytes(), 32))
-	harsher.Write(common.LeftPadBytes([]byte("__RAW_CHILDREN"), 32))
-	harsher.Sum(childrenRawDataSlot[:0])
-	harsher.Reset()
-
-	childrenRaw := evm.StateDB.GetRawState(common.HexToAddress(systemcontracts.AddressTreeContract), childrenRawDataSlot)
+	childrenRaw := evm.StateDB.GetRawState(common.HexToAddress(systemcontracts.AddressTreeContract), childrenOfSlot(parent))
 	childrenLen := len(childrenRaw) / common.AddressLength
 
 	ret1 := [][]byte{
@@ -776,30 +698,7 @@ func (evm *EVM) childrenOf(parent common.Address, gas uint64) (ret []byte, leftO
 		ret1 = append(ret1, common.LeftPadBytes(childrenRaw[i*common.AddressLength:i*common.AddressLength+common.AddressLength], 32))
 	}
 
-	return bytes.Join(ret1, []byte{}), gas - 40000, nil
-}
-
-func (evm *EVM) isContractCreator(caller common.Address) bool {
-	var slot common.Hash
-	harsher := sha3.NewLegacyKeccak256()
-	harsher.Write(common.LeftPadBytes(caller.Bytes(), 32))
-
-	if evm.IsAnchorEVM() {
-		harsher.Write(common.LeftPadBytes(common.IntToSlot(5).Bytes(), 32))
-	} else {
-		harsher.Write(common.LeftPadBytes(common.IntToSlot(7).Bytes(), 32))
-	}
-
-	harsher.Sum(slot[:0])
-	harsher.Reset()
-
-	boolBytes := evm.StateDB.GetState(common.HexToAddress(systemcontracts.SystemDaoContract), slot)
-	return common.StateToBig(boolBytes).Uint64() > 0
-}
-
-func (evm *EVM) isPrivateDeploymentMode() bool {
-	boolBytes := evm.StateDB.GetState(common.HexToAddress(systemcontracts.SystemDaoContract), common.BigToHash(big.NewInt(6)))
-	return common.StateToBig(boolBytes).Uint64() > 0
+	return bytes.Join(ret1, []byte{}), gas, nil
 }
 
 // //////////////////////////////////////////////////////////////////////////////////////
@@ -811,15 +710,32 @@ const (
 	AddressTreeContractSlotVersionOf = "0x0000000000000000000000000000000000000000000000000000000000000006"
 )
 
-func (evm *EVM) cacheStateChildrenOf(account common.Address) []byte {
-	childrenRaw, err := (*evm.cacheDB).Get(anchor.ChildrenDBKey(account))
-	if err != nil {
-		emptyEncode := [][]byte{
-			common.LeftPadBytes(big.NewInt(32).Bytes(), 32),
-			common.LeftPadBytes(big.NewInt(0).Bytes(), 32),
-		}
-		return bytes.Join(emptyEncode, []byte{})
+// treeCache returns the chain's shared anchor.TreeCache, lazily creating it
+// on first use. It lives on chainConfig.Anchor rather than on evm itself
+// because EVM instances are pooled per-transaction while the cache needs to
+// go on memoizing reads for the whole block (and beyond, since entries are
+// keyed by block number and just age out of the LRU on their own).
+func (evm *EVM) treeCache() *anchor.TreeCache {
+	if evm.chainConfig.Anchor.TreeCache == nil {
+		evm.chainConfig.Anchor.TreeCache = anchor.NewTreeCache(0)
+	}
+	return evm.chainConfig.Anchor.TreeCache
+}
+
+// decodeChildrenRaw splits cacheDB's flat children encoding into individual
+// addresses, the form Prefetch needs to keep expanding its BFS frontier.
+func decodeChildrenRaw(childrenRaw []byte) []common.Address {
+	childrenLen := len(childrenRaw) / common.AddressLength
+	children := make([]common.Address, childrenLen)
+	for i := 0; i < childrenLen; i++ {
+		children[i] = common.BytesToAddress(childrenRaw[i*common.AddressLength : i*common.AddressLength+common.AddressLength])
 	}
+	return children
+}
+
+// encodeChildrenOf ABI-encodes cacheDB's flat children encoding into
+// childrenOf's address[] return value.
+func encodeChildrenOf(childrenRaw []byte) []byte {
 	childrenLen := len(childrenRaw) / common.AddressLength
 	ret1 := [][]byte{
 		common.LeftPadBytes(big.NewInt(32).Bytes(), 32),
@@ -832,6 +748,24 @@ func (evm *EVM) cacheStateChildrenOf(account common.Address) []byte {
 	return bytes.Join(ret1, []byte{})
 }
 
+func (evm *EVM) cacheStateChildrenOf(account common.Address) []byte {
+	childrenRaw := evm.treeCache().Children(account, evm.Context.BlockNumber.Uint64(), func() []byte {
+		raw, err := (*evm.cacheDB).Get(anchor.ChildrenDBKey(account))
+		if err != nil {
+			return nil
+		}
+		return raw
+	})
+	if childrenRaw == nil {
+		emptyEncode := [][]byte{
+			common.LeftPadBytes(big.NewInt(32).Bytes(), 32),
+			common.LeftPadBytes(big.NewInt(0).Bytes(), 32),
+		}
+		return bytes.Join(emptyEncode, []byte{})
+	}
+	return encodeChildrenOf(childrenRaw)
+}
+
 func (evm *EVM) cacheStateParentOf(account common.Address) []byte {
 	parentRaw, _ := (*evm.cacheDB).Get(anchor.ParentDBKey(account))
 	if parentRaw == nil {
@@ -841,13 +775,115 @@ func (evm *EVM) cacheStateParentOf(account common.Address) []byte {
 }
 
 func (evm *EVM) cacheStateVersionOf(account common.Address) []byte {
-	versionRaw, _ := (*evm.cacheDB).Get(anchor.VersionDBKey(account))
+	versionRaw := evm.treeCache().Version(account, evm.Context.BlockNumber.Uint64(), func() []byte {
+		raw, _ := (*evm.cacheDB).Get(anchor.VersionDBKey(account))
+		return raw
+	})
 	if versionRaw == nil {
 		return common.BigToHash(big.NewInt(0)).Bytes()
 	}
 	return versionRaw
 }
 
+// PrefetchAnchorSubtree warms the anchor TreeCache for owner's subtree down
+// to depth levels deep, so block processing can pay for the cacheDB reads
+// once up front instead of one shortcut call at a time while replaying the
+// block's transactions. It's a no-op off the anchor-cache EVM.
+func (evm *EVM) PrefetchAnchorSubtree(owner common.Address, depth int) {
+	if !evm.IsAnchorEVM() {
+		return
+	}
+	evm.treeCache().Prefetch(owner, depth, evm.Context.BlockNumber.Uint64(),
+		func(account common.Address) []byte {
+			raw, _ := (*evm.cacheDB).Get(anchor.VersionDBKey(account))
+			return raw
+		},
+		func(account common.Address) ([]byte, []common.Address) {
+			raw, err := (*evm.cacheDB).Get(anchor.ChildrenDBKey(account))
+			if err != nil {
+				return nil, nil
+			}
+			return raw, decodeChildrenRaw(raw)
+		},
+	)
+}
+
+// OnAnchorWrite invalidates the anchor TreeCache's entries for account,
+// across every block number they were memoized under. State-write paths
+// that touch the address-tree contract's storage for account should call
+// this so a cache warmed before the write can't go on serving a stale
+// versionOf/childrenOf result afterward.
+func (evm *EVM) OnAnchorWrite(account common.Address) {
+	if !evm.IsAnchorEVM() {
+		return
+	}
+	evm.treeCache().OnWrite(account)
+}
+
+// OnChildAdded records a write attaching child under parent in the address
+// tree: it synthesizes a ChildAdded log so subscribers get a deterministic,
+// receipt-bloom-verifiable notification, and - in anchor-cache mode - feeds
+// the subtree bloom index against parent and every one of parent's own
+// ancestors up to the root, since isDescendantOf queries an arbitrary root
+// and every ancestor's bloom filter needs child, not just parent's.
+func (evm *EVM) OnChildAdded(parent, child common.Address) {
+	evm.StateDB.AddLog(events.ChildAdded(common.HexToAddress(systemcontracts.AddressTreeContract), parent, child))
+
+	if !evm.IsAnchorEVM() {
+		return
+	}
+	blockNumber := evm.Context.BlockNumber.Uint64()
+	idx := evm.treeIndex()
+
+	maxHops := new(big.Int).SetBytes(evm.cacheStateDepthOf(parent)).Uint64() + 1
+	ancestor := parent
+	for hop := uint64(0); hop < maxHops; hop++ {
+		idx.Add(ancestor, child, blockNumber)
+		parentRaw := evm.cacheStateParentOf(ancestor)
+		if len(parentRaw) == 0 {
+			break
+		}
+		ancestor = common.BytesToAddress(parentRaw)
+	}
+}
+
+// OnParentChanged synthesizes a ParentChanged log for a write setting
+// account's parent to newParent.
+func (evm *EVM) OnParentChanged(account, newParent common.Address) {
+	evm.StateDB.AddLog(events.ParentChanged(common.HexToAddress(systemcontracts.AddressTreeContract), account, newParent))
+}
+
+// OnDepthChanged synthesizes a DepthChanged log for a write setting
+// account's tree depth to newDepth.
+func (evm *EVM) OnDepthChanged(account common.Address, newDepth *big.Int) {
+	evm.StateDB.AddLog(events.DepthChanged(common.HexToAddress(systemcontracts.AddressTreeContract), account, newDepth))
+}
+
+// OnVersionChanged synthesizes a VersionChanged log for a write setting
+// account's tree version to newVersion.
+func (evm *EVM) OnVersionChanged(account common.Address, newVersion *big.Int) {
+	evm.StateDB.AddLog(events.VersionChanged(common.HexToAddress(systemcontracts.AddressTreeContract), account, newVersion))
+}
+
+// OnRewardPerShareAdvanced synthesizes a RewardPerShareAdvanced log for a
+// write advancing (pool, rewardToken)'s accumulated reward-per-share at
+// rangeIndex to newValue.
+func (evm *EVM) OnRewardPerShareAdvanced(pool, rewardToken common.Address, rangeIndex, newValue *big.Int) {
+	evm.StateDB.AddLog(events.RewardPerShareAdvanced(common.HexToAddress(systemcontracts.FarmContract), pool, rewardToken, rangeIndex, newValue))
+}
+
+// OnHolderDistributionChanged synthesizes a HolderDistributionChanged log
+// for a write rewriting tokenContract's holder range distribution table.
+func (evm *EVM) OnHolderDistributionChanged(tokenContract common.Address) {
+	evm.StateDB.AddLog(events.HolderDistributionChanged(common.HexToAddress(systemcontracts.FarmContract), tokenContract))
+}
+
+// OnChildrenHoldAmountChanged synthesizes a ChildrenHoldAmountChanged log
+// for a write rewriting (pool, parent)'s per-child hold amounts.
+func (evm *EVM) OnChildrenHoldAmountChanged(pool, parent common.Address) {
+	evm.StateDB.AddLog(events.ChildrenHoldAmountChanged(common.HexToAddress(systemcontracts.FarmContract), pool, parent))
+}
+
 func (evm *EVM) cacheStateDepthOf(account common.Address) []byte {
 	depthRaw, _ := (*evm.cacheDB).Get(anchor.DepthDBKey(account))
 	if depthRaw == nil {
@@ -855,3 +891,79 @@ func (evm *EVM) cacheStateDepthOf(account common.Address) []byte {
 	}
 	return depthRaw
 }
+
+// treeIndex returns the chain's shared subtree bloom index, lazily creating
+// it on first use - same pooled-EVM reasoning as treeCache above.
+func (evm *EVM) treeIndex() *treeindex.Indexer {
+	if evm.chainConfig.Anchor.TreeIndex == nil {
+		evm.chainConfig.Anchor.TreeIndex = treeindex.NewIndexer()
+	}
+	return evm.chainConfig.Anchor.TreeIndex
+}
+
+// rawChildrenOf returns account's flat children encoding from whichever
+// backing store is active: the anchor cacheDB in anchor-cache mode, or
+// on-chain storage otherwise. It's the shared read descendantsAtDepth and
+// treeIndex's Rebuild walker both need, regardless of which mode the chain
+// runs in.
+func (evm *EVM) rawChildrenOf(account common.Address) []byte {
+	if evm.IsAnchorEVM() {
+		raw, err := (*evm.cacheDB).Get(anchor.ChildrenDBKey(account))
+		if err != nil {
+			return nil
+		}
+		return raw
+	}
+	return evm.StateDB.GetRawState(common.HexToAddress(systemcontracts.AddressTreeContract), childrenOfSlot(account))
+}
+
+// isDescendantOf implements the isDescendantOf(address,address) hook: a
+// bloom-checked lookup against the tree index, verified (or, on a bloom
+// false positive, refuted) by walking cacheStateParentOf up from candidate
+// for at most cacheStateDepthOf(root) hops.
+func (evm *EVM) isDescendantOf(root, candidate common.Address, gas uint64) (ret []byte, leftOverGas uint64, err error) {
+	result := false
+	if evm.treeIndex().MaybeContains(root, candidate) {
+		maxHops := new(big.Int).SetBytes(evm.cacheStateDepthOf(root)).Uint64()
+		cur := candidate
+		for hop := uint64(0); hop <= maxHops; hop++ {
+			if cur == root {
+				result = true
+				break
+			}
+			parentRaw := evm.cacheStateParentOf(cur)
+			if len(parentRaw) == 0 {
+				break
+			}
+			cur = common.BytesToAddress(parentRaw)
+		}
+	}
+	word := make([]byte, 32)
+	if result {
+		word[31] = 1
+	}
+	return word, gas, nil
+}
+
+// descendantsAtDepth implements the descendantsAtDepth(address,uint64) hook:
+// a breadth-first expansion of root's children, depth levels deep, returned
+// as the same address[] encoding childrenOf uses.
+func (evm *EVM) descendantsAtDepth(root common.Address, depth uint64, gas uint64) (ret []byte, leftOverGas uint64, err error) {
+	frontier := []common.Address{root}
+	for level := uint64(0); level < depth && len(frontier) > 0; level++ {
+		var next []common.Address
+		for _, acc := range frontier {
+			next = append(next, decodeChildrenRaw(evm.rawChildrenOf(acc))...)
+		}
+		frontier = next
+	}
+
+	ret1 := [][]byte{
+		common.LeftPadBytes(big.NewInt(32).Bytes(), 32),
+		common.LeftPadBytes(big.NewInt(int64(len(frontier))).Bytes(), 32),
+	}
+	for _, acc := range frontier {
+		ret1 = append(ret1, common.LeftPadBytes(acc.Bytes(), 32))
+	}
+	return bytes.Join(ret1, []byte{}), gas, nil
+}
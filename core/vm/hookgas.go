@@ -0,0 +1,150 @@
+package vm
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
+	"golang.org/x/crypto/sha3"
+)
+
+// hookBaseGas is the fixed part of a hooked selector's charge: the
+// keccak-derived slot lookup(s) every handler does regardless of how much
+// data comes back.
+const hookBaseGas = 20000
+
+// hookPerWordGas is charged per stored item a handler reads (one address,
+// amount or range record each, see wordsHookGas), so a
+// childrenOf/childrenHoldAmount call over a large subtree can't be priced
+// the same as one over an empty one.
+const hookPerWordGas = 1000
+
+// StateReader is the minimal state-read surface a HookGasCost needs to size
+// its charge before the handler it prices actually runs.
+type StateReader interface {
+	GetRawState(addr common.Address, slot common.Hash) []byte
+}
+
+// HookGasCost prices one hooked selector's call given its ABI-encoded
+// arguments - input with the leading 4-byte selector already stripped, the
+// same slice callShortcut passes to Inputs.Unpack - and read-only access to
+// chain state, so its cost can scale with how much data the handler is about
+// to read and re-encode. It runs before the handler itself, and must not
+// mutate state.
+type HookGasCost func(input []byte, stateReader StateReader) uint64
+
+// fixedHookGas returns a HookGasCost that always charges base, for handlers
+// whose output size doesn't depend on state (e.g. a single reward-per-share
+// word).
+func fixedHookGas(base uint64) HookGasCost {
+	return func(input []byte, stateReader StateReader) uint64 {
+		return base
+	}
+}
+
+// wordsHookGas returns a HookGasCost charging base plus hookPerWordGas per
+// ceil(itemSize-divided entry) of rawLen(input, stateReader)'s payload, the
+// model childrenOf/childrenHoldAmount/holderRangeInfo all share: their gas
+// tracks how many items are stored under the slot they read, not a flat
+// guess (for holderRangeInfo that is every range, not the one returned).
+func wordsHookGas(base uint64, itemSize int, rawLen func(input []byte, stateReader StateReader) int) HookGasCost {
+	return func(input []byte, stateReader StateReader) uint64 {
+		n := rawLen(input, stateReader)
+		items := (n + itemSize - 1) / itemSize
+		return base + hookPerWordGas*uint64(items)
+	}
+}
+
+func childrenOfSlot(parent common.Address) common.Hash {
+	var slot common.Hash
+	harsher := sha3.NewLegacyKeccak256()
+	harsher.Write(common.LeftPadBytes(parent.Bytes(), 32))
+	harsher.Write(common.LeftPadBytes([]byte("__RAW_CHILDREN"), 32))
+	harsher.Sum(slot[:0])
+	return slot
+}
+
+func childrenHoldAmountSlot(pool, parent common.Address) common.Hash {
+	var slot common.Hash
+	harsher := sha3.NewLegacyKeccak256()
+	harsher.Write(common.LeftPadBytes([]byte("__ChildrenHoldAmount"), 32))
+	harsher.Write(common.LeftPadBytes(pool.Bytes(), 32))
+	harsher.Write(common.LeftPadBytes(parent.Bytes(), 32))
+	harsher.Sum(slot[:0])
+	return slot
+}
+
+func holderRangeInfoSlot(tokenContract common.Address) common.Hash {
+	var slot common.Hash
+	harsher := sha3.NewLegacyKeccak256()
+	harsher.Write(common.LeftPadBytes([]byte("__HolderDistribution"), 32))
+	harsher.Write(common.LeftPadBytes(tokenContract.Bytes(), 32))
+	harsher.Sum(slot[:0])
+	return slot
+}
+
+func rewardPerShareSlot(pool, rewardToken common.Address) common.Hash {
+	var slot common.Hash
+	harsher := sha3.NewLegacyKeccak256()
+	harsher.Write([]byte("__RewardPerShare"))
+	harsher.Write(pool.Bytes())
+	harsher.Write(rewardToken.Bytes())
+	harsher.Sum(slot[:0])
+	return slot
+}
+
+// childrenOfGasCost prices childrenOf(address) by the number of children
+// the address tree actually has to encode. GasCost runs before
+// shortcut.Inputs.Unpack validates the ABI encoding (callShortcut only
+// checks the 4-byte selector first), so input can be shorter than a real
+// childrenOf(address) call ever would be; charging hookBaseGas and leaving
+// the malformed call to Unpack's error instead of indexing into input is
+// the only safe option at that point.
+func childrenOfGasCost(input []byte, stateReader StateReader) uint64 {
+	if len(input) < common.AddressLength {
+		return hookBaseGas
+	}
+	cost := wordsHookGas(hookBaseGas, common.AddressLength, func(input []byte, stateReader StateReader) int {
+		parent := common.BytesToAddress(input[len(input)-common.AddressLength:])
+		raw := stateReader.GetRawState(common.HexToAddress(systemcontracts.AddressTreeContract), childrenOfSlot(parent))
+		return len(raw)
+	})
+	return cost(input, stateReader)
+}
+
+// childrenHoldAmountGasCost prices childrenHoldAmount(address,address) by
+// the number of per-child amounts it has to encode. See childrenOfGasCost's
+// comment for why a too-short input falls back to hookBaseGas rather than
+// indexing into it.
+func childrenHoldAmountGasCost(input []byte, stateReader StateReader) uint64 {
+	if len(input) < 64 {
+		return hookBaseGas
+	}
+	cost := wordsHookGas(hookBaseGas, 16, func(input []byte, stateReader StateReader) int {
+		pool := common.BytesToAddress(input[12:32])
+		parent := common.BytesToAddress(input[44:64])
+		raw := stateReader.GetRawState(common.HexToAddress(systemcontracts.FarmContract), childrenHoldAmountSlot(pool, parent))
+		return len(raw)
+	})
+	return cost(input, stateReader)
+}
+
+// holderRangeInfoGasCost prices holderRangeInfoOf(address,uint64) by the
+// number of ranges recorded for tokenContract, not just the one it returns,
+// since every range has to be walked to find rangeIndex's entry. See
+// childrenOfGasCost's comment for why a too-short input falls back to
+// hookBaseGas rather than indexing into it.
+func holderRangeInfoGasCost(input []byte, stateReader StateReader) uint64 {
+	if len(input) < 32 {
+		return hookBaseGas
+	}
+	cost := wordsHookGas(hookBaseGas, 7, func(input []byte, stateReader StateReader) int {
+		tokenContract := common.BytesToAddress(input[12:32])
+		raw := stateReader.GetRawState(common.HexToAddress(systemcontracts.FarmContract), holderRangeInfoSlot(tokenContract))
+		return len(raw)
+	})
+	return cost(input, stateReader)
+}
+
+// holderRangeAccRewardPerShareGasCost prices
+// holderRangeAccRewardPerShare(address,address,uint64): it always returns a
+// single word, so only the base keccak/lookup charge applies.
+var holderRangeAccRewardPerShareGasCost = fixedHookGas(hookBaseGas)
@@ -0,0 +1,97 @@
+// Copyright 2014 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// multicallSelector is '77a1fdc2', the selector for
+// multicall(bytes4[],bytes[]): parallel selector/argsBlob arrays rather than
+// a tuple[], since the hand-rolled ABI here has no generated Go struct to
+// decode a tuple array into.
+var multicallSelector = [4]byte{0x77, 0xa1, 0xfd, 0xc2}
+
+// multicallInputs/multicallOutputs are shared by every registered multicall
+// entry - the wire format doesn't depend on which contract it's aggregating
+// calls against.
+var (
+	multicallInputs  = mustArguments("bytes4[]", "bytes[]")
+	multicallOutputs = mustArguments("bool[]", "bytes[]")
+)
+
+// multicallPerEntryOverhead is charged per aggregated sub-call on top of its
+// own metered gas, covering the extra dispatch/bookkeeping multicall itself
+// does that a direct call wouldn't.
+const multicallPerEntryOverhead = 1000
+
+// registerMulticall wires the multicall(bytes4[],bytes[]) shortcut onto
+// target: it decodes input per multicallInputs, re-dispatches each
+// (selector, argsBlob) pair through callShortcut against target, and
+// Multicall3-style never reverts the whole batch on one sub-call's failure -
+// each entry gets its own success flag and result instead.
+func registerMulticall(target common.Address) {
+	RegisterShortcut(SystemContractShortcut{
+		Address:  target,
+		Selector: multicallSelector,
+		Inputs:   multicallInputs,
+		GasCost:  fixedHookGas(hookBaseGas),
+		Handler: func(evm *EVM, caller ContractRef, args []interface{}, gas uint64) ([]byte, uint64, error) {
+			selectors, ok := args[0].([][4]byte)
+			if !ok {
+				return nil, gas, fmt.Errorf("multicall: decoding selectors")
+			}
+			argsBlobs, ok := args[1].([][]byte)
+			if !ok {
+				return nil, gas, fmt.Errorf("multicall: decoding argsBlobs")
+			}
+			if len(selectors) != len(argsBlobs) {
+				return nil, gas, fmt.Errorf("multicall: %d selectors but %d argsBlobs", len(selectors), len(argsBlobs))
+			}
+
+			successes := make([]bool, len(selectors))
+			results := make([][]byte, len(selectors))
+
+			for i, selector := range selectors {
+				if gas < multicallPerEntryOverhead {
+					break // out of gas: leave this and every remaining entry as a zero-value failure
+				}
+				gas -= multicallPerEntryOverhead
+
+				subInput := make([]byte, 0, 4+len(argsBlobs[i]))
+				subInput = append(subInput, selector[:]...)
+				subInput = append(subInput, argsBlobs[i]...)
+
+				var hooked bool
+				ret, leftGas, err := evm.callShortcut(caller, target, subInput, gas, &hooked)
+				gas = leftGas
+				if err == nil && hooked {
+					successes[i] = true
+					results[i] = ret
+				}
+			}
+
+			encoded, err := multicallOutputs.Pack(successes, results)
+			if err != nil {
+				return nil, gas, fmt.Errorf("multicall: encoding results: %w", err)
+			}
+			return encoded, gas, nil
+		},
+	})
+}
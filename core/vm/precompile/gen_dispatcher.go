@@ -0,0 +1,183 @@
+package precompile
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"text/template"
+)
+
+// dispatcherTemplate emits a Run dispatcher that replaces the hand-rolled
+// selector switch evm.go's hook methods used to need: it ABI-decodes input's
+// argument blob per-method via accounts/abi, calls the matching Impl method,
+// and ABI-encodes the result, so a method's dynamic-array return no longer
+// needs its own hand-written LeftPadBytes head/tail encoding.
+var dispatcherTemplate = template.Must(template.New("dispatcher").Parse(`// Code generated by precompilebind. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+{{range .Methods}}
+var {{.Name}}Args = mustArguments({{range .Inputs}}"{{.Type}}", {{end}})
+var {{.Name}}Rets = mustArguments({{range .Outputs}}"{{.Type}}", {{end}})
+{{end}}
+
+// Run dispatches input's leading 4-byte selector to the matching {{.Interface}}
+// method on impl, ABI-decoding its arguments and ABI-encoding its result.
+func Run(impl {{.Interface}}, input []byte, gas uint64) ([]byte, uint64, error) {
+	if len(input) < 4 {
+		return nil, gas, fmt.Errorf("{{.Package}}: input too short for a selector")
+	}
+	var selector [4]byte
+	copy(selector[:], input[:4])
+
+	switch selector {
+{{range .Methods}}	case {{.Selector}}: // {{.Signature}}
+		args, err := {{.Name}}Args.Unpack(input[4:])
+		if err != nil {
+			return nil, gas, fmt.Errorf("{{$.Package}}: decoding {{.Name}} args: %w", err)
+		}
+		return run{{.Name}}(impl, args, gas)
+{{end}}	default:
+		return nil, gas, fmt.Errorf("{{.Package}}: no method for selector %x", selector)
+	}
+}
+`))
+
+// mustArgumentsSource is emitted once per package rather than once per file,
+// mirroring evm's own mustArguments helper in shortcuts_builtin.go.
+const mustArgumentsSource = `
+func mustArguments(types ...string) abi.Arguments {
+	args := make(abi.Arguments, 0, len(types))
+	for _, t := range types {
+		typ, err := abi.NewType(t, "", nil)
+		if err != nil {
+			panic(err)
+		}
+		args = append(args, abi.Argument{Type: typ})
+	}
+	return args
+}
+`
+
+type dispatcherMethod struct {
+	Method
+	Selector  string
+	Signature string
+}
+
+// GenerateDispatcher renders Run (plus one run<Method> helper per method)
+// for iface into pkg, with implType naming the Go interface the generated
+// code dispatches against (normally iface.Name itself).
+func GenerateDispatcher(iface *Interface, pkg, implType string) ([]byte, error) {
+	data := struct {
+		Package   string
+		Interface string
+		Methods   []dispatcherMethod
+	}{
+		Package:   pkg,
+		Interface: implType,
+	}
+	for _, m := range iface.Methods {
+		sel := m.Selector()
+		data.Methods = append(data.Methods, dispatcherMethod{
+			Method:    m,
+			Selector:  fmt.Sprintf("[4]byte{0x%02x, 0x%02x, 0x%02x, 0x%02x}", sel[0], sel[1], sel[2], sel[3]),
+			Signature: m.Signature(),
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := dispatcherTemplate.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("precompile: rendering dispatcher: %w", err)
+	}
+	for _, m := range data.Methods {
+		buf.WriteString(renderRunMethod(implType, m))
+	}
+	buf.WriteString(mustArgumentsSource)
+
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("precompile: formatting generated dispatcher: %w", err)
+	}
+	return out, nil
+}
+
+func renderRunMethod(implType string, m dispatcherMethod) string {
+	var call, assign bytes.Buffer
+	for i, a := range m.Inputs {
+		if i > 0 {
+			call.WriteString(", ")
+		}
+		fmt.Fprintf(&call, "args[%d].(%s)", i, abiGoType(a.Type))
+	}
+	retNames := make([]string, len(m.Outputs))
+	for i := range m.Outputs {
+		retNames[i] = fmt.Sprintf("ret%d", i)
+		if i > 0 {
+			assign.WriteString(", ")
+		}
+		assign.WriteString(retNames[i])
+	}
+	if assign.Len() > 0 {
+		assign.WriteString(", ")
+	}
+	assign.WriteString("err")
+
+	return fmt.Sprintf(`
+func run%s(impl %s, args []interface{}, gas uint64) ([]byte, uint64, error) {
+	%s := impl.%s(%s)
+	if err != nil {
+		return nil, gas, err
+	}
+	encoded, err := %sRets.Pack(%s)
+	if err != nil {
+		return nil, gas, fmt.Errorf("encoding %s result: %%w", err)
+	}
+	return encoded, gas, nil
+}
+`, m.Name, implType, assign.String(), m.Name, call.String(), m.Name, retArgList(retNames), m.Name)
+}
+
+func retArgList(names []string) string {
+	var buf bytes.Buffer
+	for i, n := range names {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(n)
+	}
+	return buf.String()
+}
+
+// abiGoType maps a Solidity type back to the Go type accounts/abi.Unpack
+// decodes it into, so the generated run<Method> can type-assert args safely.
+func abiGoType(solType string) string {
+	switch solType {
+	case "address":
+		return "common.Address"
+	case "address[]":
+		return "[]common.Address"
+	case "uint256":
+		return "*big.Int"
+	case "uint256[]":
+		return "[]*big.Int"
+	case "bytes":
+		return "[]byte"
+	case "bool":
+		return "bool"
+	case "uint64":
+		return "uint64"
+	case "string":
+		return "string"
+	default:
+		return "interface{}"
+	}
+}
@@ -0,0 +1,35 @@
+package precompile
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// GenerateSolidity renders iface as a Solidity interface, so contracts that
+// call the hooked precompile (e.g. FarmContract calling into a
+// self-addressed view hook) have a typed ABI to compile against instead of
+// a raw `staticcall` with hand-packed calldata.
+func GenerateSolidity(iface *Interface) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by precompilebind. DO NOT EDIT.\n")
+	fmt.Fprintf(&buf, "// SPDX-License-Identifier: LGPL-3.0-only\n")
+	fmt.Fprintf(&buf, "pragma solidity >=0.8.0;\n\n")
+	fmt.Fprintf(&buf, "interface %s {\n", iface.Name)
+	for _, m := range iface.Methods {
+		fmt.Fprintf(&buf, "    function %s(%s) external view returns (%s);\n",
+			m.Name, joinArgs(m.Inputs), joinArgs(m.Outputs))
+	}
+	fmt.Fprintf(&buf, "}\n")
+	return buf.Bytes()
+}
+
+func joinArgs(args []Arg) string {
+	var buf bytes.Buffer
+	for i, a := range args {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		fmt.Fprintf(&buf, "%s %s", a.Type, a.Name)
+	}
+	return buf.String()
+}
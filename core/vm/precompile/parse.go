@@ -0,0 +1,141 @@
+package precompile
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+)
+
+// goTypeNames maps the handful of Go types a view-hook method signature is
+// allowed to use to their canonical Solidity ABI type. Anything else is a
+// parse error - the generator deliberately doesn't try to support the full
+// Go type system, only the subset the hooked precompiles already use.
+var goTypeNames = map[string]string{
+	"common.Address":   "address",
+	"*big.Int":         "uint256",
+	"[]*big.Int":       "uint256[]",
+	"[]common.Address": "address[]",
+	"[]byte":           "bytes",
+	"bool":             "bool",
+	"uint64":           "uint64",
+	"string":           "string",
+}
+
+// ParseInterface reads src (a Go source file) and builds an Interface out of
+// the exported interface type named ifaceName, mapping each method's
+// parameter and result types to their Solidity equivalent via goTypeNames.
+// The final `error` result every method must declare is dropped from
+// Outputs; it's always the dispatcher's own error return instead.
+func ParseInterface(src []byte, ifaceName string) (*Interface, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, 0)
+	if err != nil {
+		return nil, fmt.Errorf("precompile: parsing source: %w", err)
+	}
+
+	var ifaceType *ast.InterfaceType
+	ast.Inspect(file, func(n ast.Node) bool {
+		ts, ok := n.(*ast.TypeSpec)
+		if !ok || ts.Name.Name != ifaceName {
+			return true
+		}
+		ifaceType, _ = ts.Type.(*ast.InterfaceType)
+		return false
+	})
+	if ifaceType == nil {
+		return nil, fmt.Errorf("precompile: no interface type %q found", ifaceName)
+	}
+
+	iface := &Interface{Name: ifaceName}
+	for _, field := range ifaceType.Methods.List {
+		ft, ok := field.Type.(*ast.FuncType)
+		if !ok || len(field.Names) != 1 {
+			continue
+		}
+		method := Method{Name: field.Names[0].Name}
+
+		inputs, err := fieldListToArgs(ft.Params, "arg")
+		if err != nil {
+			return nil, fmt.Errorf("precompile: %s: %w", method.Name, err)
+		}
+		method.Inputs = inputs
+
+		outputs, err := resultsToArgs(ft.Results)
+		if err != nil {
+			return nil, fmt.Errorf("precompile: %s: %w", method.Name, err)
+		}
+		method.Outputs = outputs
+
+		iface.Methods = append(iface.Methods, method)
+	}
+	return iface, nil
+}
+
+func fieldListToArgs(list *ast.FieldList, prefix string) ([]Arg, error) {
+	if list == nil {
+		return nil, nil
+	}
+	var args []Arg
+	n := 0
+	for _, field := range list.List {
+		typ, err := typeString(field.Type)
+		if err != nil {
+			return nil, err
+		}
+		solType, ok := goTypeNames[typ]
+		if !ok {
+			return nil, fmt.Errorf("unsupported type %q", typ)
+		}
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{{Name: fmt.Sprintf("%s%d", prefix, n)}}
+		}
+		for _, name := range names {
+			args = append(args, Arg{Name: name.Name, Type: solType})
+			n++
+		}
+	}
+	return args, nil
+}
+
+// resultsToArgs converts a method's result list to Outputs, requiring (and
+// dropping) the trailing error every view-hook method must return.
+func resultsToArgs(list *ast.FieldList) ([]Arg, error) {
+	if list == nil || len(list.List) == 0 {
+		return nil, fmt.Errorf("must return at least a trailing error")
+	}
+	last := list.List[len(list.List)-1]
+	if ident, ok := last.Type.(*ast.Ident); !ok || ident.Name != "error" {
+		return nil, fmt.Errorf("last result must be error")
+	}
+	trimmed := &ast.FieldList{List: list.List[:len(list.List)-1]}
+	return fieldListToArgs(trimmed, "ret")
+}
+
+func typeString(expr ast.Expr) (string, error) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name, nil
+	case *ast.SelectorExpr:
+		pkg, ok := t.X.(*ast.Ident)
+		if !ok {
+			return "", fmt.Errorf("unsupported selector expression")
+		}
+		return pkg.Name + "." + t.Sel.Name, nil
+	case *ast.StarExpr:
+		inner, err := typeString(t.X)
+		if err != nil {
+			return "", err
+		}
+		return "*" + inner, nil
+	case *ast.ArrayType:
+		inner, err := typeString(t.Elt)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + inner, nil
+	default:
+		return "", fmt.Errorf("unsupported type expression %T", expr)
+	}
+}
@@ -0,0 +1,54 @@
+// Package precompile supports generating the dispatch, Solidity interface,
+// and Go test-client code for an EVM state-view hook from a single Go
+// interface declaration, instead of hand-writing a selector switch and its
+// matching ABI head/tail encoding by hand (the pattern evm.go's
+// holderRangeAccRewardPerShare, childrenHoldAmount, childrenOf and friends
+// grew into one selector at a time).
+package precompile
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Arg is one method parameter or return value, already resolved to its
+// canonical Solidity type string (e.g. "address", "uint256[]").
+type Arg struct {
+	Name string
+	Type string
+}
+
+// Method is one view function a hooked precompile exposes. Outputs excludes
+// the trailing `error` every Go interface method returns - that's always
+// surfaced as the dispatcher's own error return, never ABI-encoded.
+type Method struct {
+	Name    string
+	Inputs  []Arg
+	Outputs []Arg
+}
+
+// Signature returns the canonical `name(type,type,...)` string a selector is
+// keccak'd from, per the Solidity ABI spec.
+func (m Method) Signature() string {
+	types := make([]string, len(m.Inputs))
+	for i, a := range m.Inputs {
+		types[i] = a.Type
+	}
+	return fmt.Sprintf("%s(%s)", m.Name, strings.Join(types, ","))
+}
+
+// Selector returns the 4-byte function selector the dispatcher switches on.
+func (m Method) Selector() [4]byte {
+	var sel [4]byte
+	copy(sel[:], crypto.Keccak256([]byte(m.Signature()))[:4])
+	return sel
+}
+
+// Interface is a precompile's full view surface, parsed from one Go
+// interface type - e.g. FarmView or AddressTreeView.
+type Interface struct {
+	Name    string
+	Methods []Method
+}
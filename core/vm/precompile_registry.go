@@ -0,0 +1,119 @@
+// Copyright 2014 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// StatefulPrecompile is a precompile that needs more than a pure
+// input-to-output function: it can read and mutate StateDB, consult
+// Context.BlockNumber, and must honor readOnly the same way an ordinary
+// contract call honors StaticCall. evm.runPrecompile dispatches to it in
+// place of RunPrecompiledContract whenever the PrecompileRegistry activated
+// one for the called address.
+type StatefulPrecompile interface {
+	Run(evm *EVM, caller ContractRef, input []byte, suppliedGas uint64, readOnly bool) ([]byte, uint64, error)
+}
+
+// PrecompileRegistryEntry is the genesis-config shape for a custom
+// precompile: {"address":..., "activateBlock":..., "config":...}. Config is
+// opaque here - it's whatever the concrete Precompile's own constructor
+// needed, already consumed by the time an entry is registered - and is kept
+// only so a node can echo back what it activated a precompile with.
+type PrecompileRegistryEntry struct {
+	Address       common.Address  `json:"address"`
+	ActivateBlock uint64          `json:"activateBlock"`
+	Config        json.RawMessage `json:"config,omitempty"`
+
+	// Precompile is exactly one of PrecompiledContract or StatefulPrecompile;
+	// Register panics if given anything else.
+	Precompile interface{} `json:"-"`
+}
+
+// PrecompileRegistry lets chain operators add precompiles activated at a
+// specific block number on top of EVM.precompile's built-in per-fork maps,
+// so a network can ship a new gas-metered system contract without
+// recompiling the node for it.
+type PrecompileRegistry struct {
+	mu      sync.RWMutex
+	entries []PrecompileRegistryEntry
+}
+
+// NewPrecompileRegistry returns an empty registry ready for Register calls.
+func NewPrecompileRegistry() *PrecompileRegistry {
+	return &PrecompileRegistry{}
+}
+
+// Register adds entry, activated once BlockContext.BlockNumber reaches
+// entry.ActivateBlock. A later Register for the same Address shadows an
+// earlier one from the block it activates at onward.
+func (r *PrecompileRegistry) Register(entry PrecompileRegistryEntry) {
+	switch entry.Precompile.(type) {
+	case PrecompiledContract, StatefulPrecompile:
+	default:
+		panic(fmt.Sprintf("vm: precompile registered at %s is neither a PrecompiledContract nor a StatefulPrecompile", entry.Address))
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entry)
+}
+
+// activeEntry returns the most recently registered entry for addr that has
+// activated by blockNumber, if any.
+func (r *PrecompileRegistry) activeEntry(addr common.Address, blockNumber *big.Int) (PrecompileRegistryEntry, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		if e := r.entries[i]; e.Address == addr && blockNumber.Uint64() >= e.ActivateBlock {
+			return e, true
+		}
+	}
+	return PrecompileRegistryEntry{}, false
+}
+
+// statefulPrecompile reports the StatefulPrecompile the registry has active
+// for addr at the EVM's current block, if the registry activated one there
+// instead of a stateless PrecompiledContract.
+func (evm *EVM) statefulPrecompile(addr common.Address) (StatefulPrecompile, bool) {
+	registry := evm.chainConfig.PrecompileRegistry
+	if registry == nil {
+		return nil, false
+	}
+	entry, ok := registry.activeEntry(addr, evm.Context.BlockNumber)
+	if !ok {
+		return nil, false
+	}
+	statefulP, ok := entry.Precompile.(StatefulPrecompile)
+	return statefulP, ok
+}
+
+// runPrecompile replaces a direct RunPrecompiledContract(p, input, gas) call
+// at every Call/CallCode/DelegateCall/StaticCall/AuthCall site: if the
+// registry instead activated a StatefulPrecompile for addr, it gets the same
+// call-site treatment - including readOnly - that a stateless p does.
+func (evm *EVM) runPrecompile(addr common.Address, p PrecompiledContract, caller ContractRef, input []byte, gas uint64, readOnly bool) (ret []byte, leftOverGas uint64, err error) {
+	if statefulP, ok := evm.statefulPrecompile(addr); ok {
+		return statefulP.Run(evm, caller, input, gas, readOnly)
+	}
+	return RunPrecompiledContract(p, input, gas)
+}
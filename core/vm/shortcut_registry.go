@@ -0,0 +1,150 @@
+// Copyright 2014 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ShortcutHandler is a SystemContractShortcut's business logic, given its
+// own selector's ABI-decoded arguments. It mirrors the signature the old
+// callHook branches each wrote inline, so the four that already existed
+// (holderRangeInfoOf, holderRangeAccRewardPerShare, childrenHoldAmount,
+// childrenOf/depthOf/parentOf/versionOf) move over with their bodies
+// unchanged.
+type ShortcutHandler func(evm *EVM, caller ContractRef, args []interface{}, gas uint64) (encodedRet []byte, leftGas uint64, err error)
+
+// SystemContractShortcut is a fast-path, off-interpreter implementation of
+// one system contract's view function - the registry-based replacement for
+// callHook's hardcoded (address, selector) matching. ActivateBlock lets a
+// shortcut be introduced at a specific height; Active, when set, adds a
+// runtime gate alongside it (e.g. "only in anchor-cache mode") for the
+// shortcuts that depend on more than just block height.
+type SystemContractShortcut struct {
+	Address       common.Address
+	Selector      [4]byte
+	Inputs        abi.Arguments
+	ActivateBlock uint64
+	Active        func(evm *EVM) bool
+	// GasCost meters the shortcut's call before Handler runs; nil adds no
+	// charge there, and the call then costs whatever gas Handler does not
+	// return as leftover (the anchor-cache read handlers return none).
+	GasCost HookGasCost
+	Handler ShortcutHandler
+}
+
+func (s SystemContractShortcut) active(evm *EVM) bool {
+	if evm.Context.BlockNumber.Uint64() < s.ActivateBlock {
+		return false
+	}
+	return s.Active == nil || s.Active(evm)
+}
+
+type shortcutKey struct {
+	address  common.Address
+	selector [4]byte
+}
+
+var (
+	shortcutRegistryMu sync.RWMutex
+	shortcutRegistry   = map[shortcutKey][]SystemContractShortcut{}
+)
+
+// RegisterShortcut adds shortcut to the package-wide registry callShortcut
+// consults for every call, so future system contracts (anchor tree
+// traversals, farm accounting, ...) can add a fast path without editing
+// callShortcut itself. Shortcuts for the same (Address, Selector) are tried
+// in registration order; the first whose active(evm) is true wins, so two
+// mutually-exclusive variants (e.g. anchor-cache vs. plain on-chain reads)
+// can share a selector as long as their Active predicates don't overlap.
+func RegisterShortcut(shortcut SystemContractShortcut) {
+	shortcutRegistryMu.Lock()
+	defer shortcutRegistryMu.Unlock()
+	key := shortcutKey{address: shortcut.Address, selector: shortcut.Selector}
+	shortcutRegistry[key] = append(shortcutRegistry[key], shortcut)
+}
+
+func lookupShortcut(evm *EVM, addr common.Address, selector [4]byte) (SystemContractShortcut, bool) {
+	shortcutRegistryMu.RLock()
+	candidates := append([]SystemContractShortcut(nil), shortcutRegistry[shortcutKey{address: addr, selector: selector}]...)
+	shortcutRegistryMu.RUnlock()
+
+	for _, c := range candidates {
+		if c.active(evm) {
+			return c, true
+		}
+	}
+	return SystemContractShortcut{}, false
+}
+
+// callShortcut is the registry-based replacement for the old callHook: it
+// looks up a SystemContractShortcut for (addr, input's 4-byte selector)
+// active at the EVM's current state, ABI-decodes input's arguments per the
+// shortcut's own Inputs, meters the call via its GasCost, and runs its
+// Handler. *hooked reports whether a shortcut matched, same as callHook's
+// *hooked did, so Call/StaticCall/DelegateCall can fall through to ordinary
+// execution when it doesn't; a matched-but-out-of-gas call still counts as
+// hooked; it's a revert, not a fallthrough to the selector's real bytecode.
+func (evm *EVM) callShortcut(caller ContractRef, addr common.Address, input []byte, gas uint64, hooked *bool) (ret []byte, leftOverGas uint64, err error) {
+	*hooked = false
+	if len(input) < 4 {
+		return nil, gas, nil
+	}
+	var selector [4]byte
+	copy(selector[:], input[:4])
+
+	shortcut, ok := lookupShortcut(evm, addr, selector)
+	if !ok {
+		return nil, gas, nil
+	}
+	*hooked = true
+
+	if shortcut.GasCost != nil {
+		cost := shortcut.GasCost(input[4:], evm.StateDB)
+		if cost > gas {
+			return nil, 0, ErrOutOfGas
+		}
+		gas -= cost
+	}
+
+	var args []interface{}
+	if len(shortcut.Inputs) > 0 {
+		if args, err = shortcut.Inputs.Unpack(input[4:]); err != nil {
+			return nil, gas, fmt.Errorf("vm: decoding shortcut args for %s: %w", addr, err)
+		}
+	}
+
+	return shortcut.Handler(evm, caller, args, gas)
+}
+
+// runShortcut is callShortcut plus the tracer CaptureEnter/CaptureExit pair
+// an interpreter-run call frame gets, so a shortcut hit still produces a
+// complete debug trace instead of vanishing from it. typ is only used for
+// that tracer event; Call/StaticCall/DelegateCall each pass their own opcode.
+func (evm *EVM) runShortcut(caller ContractRef, addr common.Address, input []byte, gas uint64, value *big.Int, typ OpCode) (ret []byte, leftOverGas uint64, hooked bool, err error) {
+	ret, leftOverGas, err = evm.callShortcut(caller, addr, input, gas, &hooked)
+	if hooked && evm.Config.Debug {
+		evm.Config.Tracer.CaptureEnter(typ, caller.Address(), addr, input, gas, value)
+		evm.Config.Tracer.CaptureExit(ret, gas-leftOverGas, err)
+	}
+	return ret, leftOverGas, hooked, err
+}
@@ -0,0 +1,176 @@
+// Copyright 2014 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/systemcontracts"
+)
+
+// mustArguments builds an abi.Arguments from a list of Solidity type
+// strings, panicking on a malformed type the same way NewEVM's treeABI
+// parsing panics on malformed ABI JSON - both only ever fail on a
+// programmer error in this file, never on untrusted input.
+func mustArguments(types ...string) abi.Arguments {
+	args := make(abi.Arguments, 0, len(types))
+	for _, t := range types {
+		typ, err := abi.NewType(t, "", nil)
+		if err != nil {
+			panic(err)
+		}
+		args = append(args, abi.Argument{Type: typ})
+	}
+	return args
+}
+
+func init() {
+	farmContract := common.HexToAddress(systemcontracts.FarmContract)
+	addressTreeContract := common.HexToAddress(systemcontracts.AddressTreeContract)
+
+	// holderRangeInfoOf(address,uint64) '0e603a1c'
+	RegisterShortcut(SystemContractShortcut{
+		Address:  farmContract,
+		Selector: [4]byte{0x0e, 0x60, 0x3a, 0x1c},
+		Inputs:   mustArguments("address", "uint64"),
+		GasCost:  holderRangeInfoGasCost,
+		Handler: func(evm *EVM, caller ContractRef, args []interface{}, gas uint64) ([]byte, uint64, error) {
+			tokenContract := args[0].(common.Address)
+			rangeIndex := args[1].(uint64)
+			return evm.holderRangeInfo(tokenContract, new(big.Int).SetUint64(rangeIndex), gas)
+		},
+	})
+
+	// holderRangeAccRewardPerShare(address,address,uint64) '24fc55d9'
+	RegisterShortcut(SystemContractShortcut{
+		Address:  farmContract,
+		Selector: [4]byte{0x24, 0xfc, 0x55, 0xd9},
+		Inputs:   mustArguments("address", "address", "uint64"),
+		GasCost:  holderRangeAccRewardPerShareGasCost,
+		Handler: func(evm *EVM, caller ContractRef, args []interface{}, gas uint64) ([]byte, uint64, error) {
+			pool := args[0].(common.Address)
+			rewardToken := args[1].(common.Address)
+			rangeIndex := args[2].(uint64)
+			return evm.holderRangeAccRewardPerShare(pool, rewardToken, new(big.Int).SetUint64(rangeIndex), gas)
+		},
+	})
+
+	// childrenHoldAmount(address,address) 'e8b23ad8'
+	RegisterShortcut(SystemContractShortcut{
+		Address:  farmContract,
+		Selector: [4]byte{0xe8, 0xb2, 0x3a, 0xd8},
+		Inputs:   mustArguments("address", "address"),
+		GasCost:  childrenHoldAmountGasCost,
+		Handler: func(evm *EVM, caller ContractRef, args []interface{}, gas uint64) ([]byte, uint64, error) {
+			pool := args[0].(common.Address)
+			parent := args[1].(common.Address)
+			return evm.childrenHoldAmount(pool, parent, gas)
+		},
+	})
+
+	// childrenOf(address) '42c4c0d0', plain on-chain reads - only active
+	// when the chain has no anchor cache to serve it from instead.
+	RegisterShortcut(SystemContractShortcut{
+		Address:  addressTreeContract,
+		Selector: [4]byte{0x42, 0xc4, 0xc0, 0xd0},
+		Inputs:   mustArguments("address"),
+		Active:   func(evm *EVM) bool { return evm.chainConfig.Anchor == nil },
+		GasCost:  childrenOfGasCost,
+		Handler: func(evm *EVM, caller ContractRef, args []interface{}, gas uint64) ([]byte, uint64, error) {
+			return evm.childrenOf(args[0].(common.Address), gas)
+		},
+	})
+
+	// Anchor-cache reads of the same address tree: depthOf/parentOf/
+	// versionOf/childrenOf served from evm.cacheDB instead of on-chain
+	// storage, only active once the chain is running in anchor-cache mode.
+	// GasCost is nil, so there is no size-proportional charge like the one
+	// the on-chain variants get from childrenOfGasCost et al. The handler
+	// returns no leftover gas, so such a call uses up all the gas passed to
+	// it, as the old callHook's anchor-cache branch did.
+	anchorCacheActive := func(evm *EVM) bool { return evm.chainConfig.Anchor != nil && evm.IsAnchorEVM() }
+	freeCacheRead := func(raw func(evm *EVM, account common.Address) []byte) ShortcutHandler {
+		return func(evm *EVM, caller ContractRef, args []interface{}, gas uint64) ([]byte, uint64, error) {
+			return raw(evm, args[0].(common.Address)), 0, nil
+		}
+	}
+	RegisterShortcut(SystemContractShortcut{
+		Address:  addressTreeContract,
+		Selector: [4]byte{0x7c, 0x31, 0x65, 0xb1}, // depthOf
+		Inputs:   mustArguments("address"),
+		Active:   anchorCacheActive,
+		Handler:  freeCacheRead(func(evm *EVM, account common.Address) []byte { return evm.cacheStateDepthOf(account) }),
+	})
+	RegisterShortcut(SystemContractShortcut{
+		Address:  addressTreeContract,
+		Selector: [4]byte{0xee, 0x08, 0x38, 0x8e}, // parentOf
+		Inputs:   mustArguments("address"),
+		Active:   anchorCacheActive,
+		Handler:  freeCacheRead(func(evm *EVM, account common.Address) []byte { return evm.cacheStateParentOf(account) }),
+	})
+	RegisterShortcut(SystemContractShortcut{
+		Address:  addressTreeContract,
+		Selector: [4]byte{0x0d, 0xb3, 0xff, 0x45}, // versionOf
+		Inputs:   mustArguments("address"),
+		Active:   anchorCacheActive,
+		Handler:  freeCacheRead(func(evm *EVM, account common.Address) []byte { return evm.cacheStateVersionOf(account) }),
+	})
+	RegisterShortcut(SystemContractShortcut{
+		Address:  addressTreeContract,
+		Selector: [4]byte{0x42, 0xc4, 0xc0, 0xd0}, // childrenOf
+		Inputs:   mustArguments("address"),
+		Active:   anchorCacheActive,
+		Handler:  freeCacheRead(func(evm *EVM, account common.Address) []byte { return evm.cacheStateChildrenOf(account) }),
+	})
+
+	// isDescendantOf(address,address) '4f4cccca': bloom-indexed subtree
+	// membership check, verified against cacheStateParentOf on a bloom hit.
+	RegisterShortcut(SystemContractShortcut{
+		Address:  addressTreeContract,
+		Selector: [4]byte{0x4f, 0x4c, 0xcc, 0xca},
+		Inputs:   mustArguments("address", "address"),
+		GasCost:  fixedHookGas(hookBaseGas),
+		Handler: func(evm *EVM, caller ContractRef, args []interface{}, gas uint64) ([]byte, uint64, error) {
+			return evm.isDescendantOf(args[0].(common.Address), args[1].(common.Address), gas)
+		},
+	})
+
+	// descendantsAtDepth(address,uint64) '4bc3020b': breadth-first subtree
+	// expansion down to a fixed depth, in one call instead of N recursive
+	// childrenOf round-trips.
+	RegisterShortcut(SystemContractShortcut{
+		Address:  addressTreeContract,
+		Selector: [4]byte{0x4b, 0xc3, 0x02, 0x0b},
+		Inputs:   mustArguments("address", "uint64"),
+		// descendantsAtDepth's cost isn't knowable from a single state read
+		// the way childrenOf's is - it fans out across a whole subtree - so
+		// it's priced on the base charge alone, same as any other fixed-cost
+		// hook; callers that want it metered by fan-out size should bound
+		// depth themselves.
+		GasCost: fixedHookGas(hookBaseGas),
+		Handler: func(evm *EVM, caller ContractRef, args []interface{}, gas uint64) ([]byte, uint64, error) {
+			return evm.descendantsAtDepth(args[0].(common.Address), args[1].(uint64), gas)
+		},
+	})
+
+	// multicall(bytes4[],bytes[]) '77a1fdc2': batches any of the above
+	// selectors for the same contract into one call.
+	registerMulticall(farmContract)
+	registerMulticall(addressTreeContract)
+}
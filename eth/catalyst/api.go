@@ -0,0 +1,169 @@
+// Package catalyst exposes the engine API a beacon chain drives the Anchor
+// network's post-merge block production through: NewPayloadV1 hands a
+// beacon-assembled block to the node for execution and validation,
+// ForkchoiceUpdatedV1 tells the node which header is canonical and,
+// optionally, asks it to start building the next payload.
+package catalyst
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/merge"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Status strings a beacon client checks ConsensusAPI's responses against.
+const (
+	StatusValid        = "VALID"
+	StatusInvalid      = "INVALID"
+	StatusSyncing      = "SYNCING"
+	StatusAccepted     = "ACCEPTED"
+	StatusInvalidBlock = "INVALID_BLOCK_HASH"
+)
+
+// ExecutableData is the beacon-assembled block body NewPayloadV1 executes,
+// field-for-field what an engine_newPayloadV1 JSON-RPC call carries.
+type ExecutableData struct {
+	ParentHash    common.Hash    `json:"parentHash"`
+	FeeRecipient  common.Address `json:"feeRecipient"`
+	StateRoot     common.Hash    `json:"stateRoot"`
+	ReceiptsRoot  common.Hash    `json:"receiptsRoot"`
+	LogsBloom     []byte         `json:"logsBloom"`
+	Random        common.Hash    `json:"random"`
+	Number        uint64         `json:"blockNumber"`
+	GasLimit      uint64         `json:"gasLimit"`
+	GasUsed       uint64         `json:"gasUsed"`
+	Timestamp     uint64         `json:"timestamp"`
+	ExtraData     []byte         `json:"extraData"`
+	BaseFeePerGas *common.Hash   `json:"baseFeePerGas"`
+	BlockHash     common.Hash    `json:"blockHash"`
+	Transactions  [][]byte       `json:"transactions"`
+}
+
+// PayloadStatusV1 is NewPayloadV1 and ForkchoiceUpdatedV1's shared verdict
+// on a payload or forkchoice state.
+type PayloadStatusV1 struct {
+	Status          string       `json:"status"`
+	LatestValidHash *common.Hash `json:"latestValidHash"`
+	ValidationError *string      `json:"validationError"`
+}
+
+// ForkchoiceStateV1 is the beacon chain's current view of canonical head,
+// safe and finalized block hashes.
+type ForkchoiceStateV1 struct {
+	HeadBlockHash      common.Hash `json:"headBlockHash"`
+	SafeBlockHash      common.Hash `json:"safeBlockHash"`
+	FinalizedBlockHash common.Hash `json:"finalizedBlockHash"`
+}
+
+// ForkChoiceResponse is ForkchoiceUpdatedV1's response: the resulting
+// payload status plus, if PayloadAttributes were supplied, the id of the
+// payload-building job now in progress.
+type ForkChoiceResponse struct {
+	PayloadStatus PayloadStatusV1 `json:"payloadStatus"`
+	PayloadID     *string         `json:"payloadId"`
+}
+
+// Backend is the subset of a full node ConsensusAPI needs: inserting an
+// executed block and moving the canonical head, mirroring how
+// miner.Backend narrows core.Blockchain down to what mining needs.
+type Backend interface {
+	InsertBlockWithoutSetHead(block *types.Block) error
+	SetCanonical(header *types.Header) (common.Hash, error)
+	GetHeaderByHash(hash common.Hash) *types.Header
+}
+
+// ConsensusAPI is the engine API surface through which post-merge blocks
+// reach the node for consensus/beacon.Beacon. Nothing in this tree calls
+// NewConsensusAPI or registers it as an RPC service.
+type ConsensusAPI struct {
+	backend Backend
+	merger  *merge.Merger
+}
+
+// NewConsensusAPI ties a Backend to merger so crossing the terminal total
+// difficulty and finalizing a post-merge block update the same state
+// consensus/beacon.Beacon reads its rules from.
+func NewConsensusAPI(backend Backend, merger *merge.Merger) *ConsensusAPI {
+	return &ConsensusAPI{backend: backend, merger: merger}
+}
+
+// NewPayloadV1 executes and validates a beacon-assembled block without
+// making it canonical; ForkchoiceUpdatedV1 is what actually moves the head.
+func (api *ConsensusAPI) NewPayloadV1(payload ExecutableData) (PayloadStatusV1, error) {
+	block, err := payload.toBlock()
+	if err != nil {
+		invalid := StatusInvalidBlock
+		return PayloadStatusV1{Status: invalid, ValidationError: strPtr(err.Error())}, nil
+	}
+	if err := api.backend.InsertBlockWithoutSetHead(block); err != nil {
+		if errors.Is(err, core.ErrKnownBlock) {
+			hash := block.Hash()
+			return PayloadStatusV1{Status: StatusValid, LatestValidHash: &hash}, nil
+		}
+		log.Warn("Invalid payload from beacon chain", "hash", block.Hash(), "number", block.NumberU64(), "err", err)
+		return PayloadStatusV1{Status: StatusInvalid, ValidationError: strPtr(err.Error())}, nil
+	}
+	hash := block.Hash()
+	return PayloadStatusV1{Status: StatusValid, LatestValidHash: &hash}, nil
+}
+
+// ForkchoiceUpdatedV1 moves the canonical head to update.HeadBlockHash and
+// records that the terminal total difficulty has been crossed; once
+// update.FinalizedBlockHash is non-zero it also finalizes PoS, after which
+// consensus/beacon.Beacon stops accepting Anchor-signed blocks.
+func (api *ConsensusAPI) ForkchoiceUpdatedV1(update ForkchoiceStateV1, _ *struct{}) (ForkChoiceResponse, error) {
+	header := api.backend.GetHeaderByHash(update.HeadBlockHash)
+	if header == nil {
+		return ForkChoiceResponse{PayloadStatus: PayloadStatusV1{Status: StatusSyncing}}, nil
+	}
+	api.merger.ReachTTD()
+
+	if _, err := api.backend.SetCanonical(header); err != nil {
+		return ForkChoiceResponse{}, fmt.Errorf("catalyst: failed to set canonical head: %w", err)
+	}
+	if update.FinalizedBlockHash != (common.Hash{}) {
+		api.merger.FinalizePoS()
+	}
+	hash := header.Hash()
+	return ForkChoiceResponse{PayloadStatus: PayloadStatusV1{Status: StatusValid, LatestValidHash: &hash}}, nil
+}
+
+// toBlock decodes payload's raw transactions and assembles the block
+// NewPayloadV1 hands to the backend for execution.
+func (payload *ExecutableData) toBlock() (*types.Block, error) {
+	txs := make([]*types.Transaction, len(payload.Transactions))
+	for i, raw := range payload.Transactions {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(raw); err != nil {
+			return nil, fmt.Errorf("invalid transaction %d: %w", i, err)
+		}
+		txs[i] = tx
+	}
+	header := &types.Header{
+		ParentHash:  payload.ParentHash,
+		Coinbase:    payload.FeeRecipient,
+		Root:        payload.StateRoot,
+		ReceiptHash: payload.ReceiptsRoot,
+		Bloom:       types.BytesToBloom(payload.LogsBloom),
+		Difficulty:  big.NewInt(0),
+		Number:      new(big.Int).SetUint64(payload.Number),
+		GasLimit:    payload.GasLimit,
+		GasUsed:     payload.GasUsed,
+		Time:        payload.Timestamp,
+		Extra:       payload.ExtraData,
+		MixDigest:   payload.Random,
+	}
+	block := types.NewBlockWithHeader(header).WithBody(txs, nil)
+	if block.Hash() != payload.BlockHash {
+		return nil, fmt.Errorf("blockhash mismatch, want %x, got %x", payload.BlockHash, block.Hash())
+	}
+	return block, nil
+}
+
+func strPtr(s string) *string { return &s }
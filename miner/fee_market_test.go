@@ -0,0 +1,127 @@
+package miner
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// TestEffectiveTipFloor exercises the same tip computation commitTransactions'
+// --miner.gasprice floor check compares against w.config.GasPrice: a
+// transaction's reward to the miner is capped by gasFeeCap-baseFee even
+// when gasTipCap alone would clear the floor.
+func TestEffectiveTipFloor(t *testing.T) {
+	baseFee := big.NewInt(10)
+	floor := big.NewInt(2)
+	to := common.HexToAddress("0x1")
+
+	tests := []struct {
+		name        string
+		gasFeeCap   int64
+		gasTipCap   int64
+		clearsFloor bool
+	}{
+		{"tip well above floor", 20, 5, true},
+		{"tip exactly at floor", 20, 2, true},
+		{"tip below floor", 20, 1, false},
+		{"fee cap squeezes tip below floor", 11, 5, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tx := types.NewTx(&types.DynamicFeeTx{
+				ChainID:   big.NewInt(1),
+				GasTipCap: big.NewInt(tc.gasTipCap),
+				GasFeeCap: big.NewInt(tc.gasFeeCap),
+				Gas:       21000,
+				To:        &to,
+			})
+			tip := effectiveTip(tx, baseFee)
+			if got := tip.Cmp(floor) >= 0; got != tc.clearsFloor {
+				t.Fatalf("tip=%s floor=%s: got clears=%v want=%v", tip, floor, got, tc.clearsFloor)
+			}
+		})
+	}
+}
+
+// TestDynamicFeeOrdering checks that the price/nonce ordering
+// DefaultOrderingStrategy wraps (types.NewTransactionsByPriceAndNonce) ranks
+// EIP-1559 transactions by effective tip after the base fee, not by
+// declared gasTipCap alone, and still preserves nonce order within an
+// account.
+func TestDynamicFeeOrdering(t *testing.T) {
+	signer := types.NewLondonSigner(big.NewInt(1))
+	baseFee := big.NewInt(10)
+	to := common.HexToAddress("0x2")
+
+	keyHigh, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	keyLow, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	// keyHigh's transactions pay a higher effective tip after the base fee
+	// (feeCap 100, tipCap 50 -> effective tip 50) than keyLow's (feeCap 30,
+	// tipCap 25 -> effective tip capped at 20 by the fee cap), even though
+	// keyLow's declared tipCap is closer to keyHigh's.
+	txHigh0 := mustSignDynamicFeeTx(t, signer, keyHigh, 0, big.NewInt(50), big.NewInt(100), to)
+	txHigh1 := mustSignDynamicFeeTx(t, signer, keyHigh, 1, big.NewInt(50), big.NewInt(100), to)
+	txLow0 := mustSignDynamicFeeTx(t, signer, keyLow, 0, big.NewInt(25), big.NewInt(30), to)
+
+	fromHigh, err := types.Sender(signer, txHigh0)
+	if err != nil {
+		t.Fatalf("recover sender: %v", err)
+	}
+	fromLow, err := types.Sender(signer, txLow0)
+	if err != nil {
+		t.Fatalf("recover sender: %v", err)
+	}
+
+	pending := map[common.Address]types.Transactions{
+		fromHigh: {txHigh0, txHigh1},
+		fromLow:  {txLow0},
+	}
+
+	it := &priceNonceIterator{types.NewTransactionsByPriceAndNonce(signer, pending, baseFee)}
+
+	first := it.Peek()
+	if first == nil || first.Hash() != txHigh0.Hash() {
+		t.Fatalf("expected keyHigh's first transaction to sort first, got %v", first)
+	}
+	it.Shift()
+
+	second := it.Peek()
+	if second == nil || second.Hash() != txHigh1.Hash() {
+		t.Fatalf("expected keyHigh's second transaction (nonce order preserved) next, got %v", second)
+	}
+	it.Shift()
+
+	third := it.Peek()
+	if third == nil || third.Hash() != txLow0.Hash() {
+		t.Fatalf("expected keyLow's transaction last, got %v", third)
+	}
+}
+
+func mustSignDynamicFeeTx(t *testing.T, signer types.Signer, key *ecdsa.PrivateKey, nonce uint64, tipCap, feeCap *big.Int, to common.Address) *types.Transaction {
+	t.Helper()
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   signer.ChainID(),
+		Nonce:     nonce,
+		GasTipCap: tipCap,
+		GasFeeCap: feeCap,
+		Gas:       21000,
+		To:        &to,
+	})
+	signed, err := types.SignTx(tx, signer, key)
+	if err != nil {
+		t.Fatalf("sign tx: %v", err)
+	}
+	return signed
+}
@@ -0,0 +1,44 @@
+package miner
+
+import (
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DoubleSignEvidence is the RPC-friendly pairing of the two conflicting
+// sealed headers recorded for one block height, returned by
+// miner_getDoubleSignEvidence.
+type DoubleSignEvidence struct {
+	HeaderA *types.Header `json:"headerA"`
+	HeaderB *types.Header `json:"headerB"`
+}
+
+// MinerAPI exposes operator-facing RPC methods backed by worker, under the
+// "miner" namespace. It would naturally sit on miner.Miner, the thin
+// wrapper upstream go-ethereum uses for exactly this kind of call (see the
+// same gap noted on BuildPayload in worker_payload.go), but that file isn't
+// part of this tree, so it wraps worker directly; wiring a MinerAPI
+// instance into the node's registered RPC services happens wherever the
+// "miner" namespace is already assembled, alongside the rest of Miner's
+// other RPC methods.
+type MinerAPI struct {
+	worker *worker
+}
+
+// NewMinerAPI wires a MinerAPI against worker.
+func NewMinerAPI(worker *worker) *MinerAPI {
+	return &MinerAPI{worker: worker}
+}
+
+// GetDoubleSignEvidence returns the persisted double-sign evidence for the
+// given block height, or nil if none was recorded, served as
+// miner_getDoubleSignEvidence.
+func (api *MinerAPI) GetDoubleSignEvidence(number uint64) (*DoubleSignEvidence, error) {
+	headerA, headerB, err := api.worker.GetDoubleSignEvidence(number)
+	if err != nil {
+		return nil, err
+	}
+	if headerA == nil || headerB == nil {
+		return nil, nil
+	}
+	return &DoubleSignEvidence{HeaderA: headerA, HeaderB: headerB}, nil
+}
@@ -0,0 +1,78 @@
+package miner
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// TxIterator abstracts the transaction queue commitTransactions drains: it
+// mirrors *types.TransactionsByPriceAndNonce, the strategy this interface
+// replaces as fillTransactions' hard-coded default, so commitTransactions
+// itself is unchanged - only how the iterator it's handed gets built varies.
+type TxIterator interface {
+	Peek() *types.Transaction
+	Shift()
+	Pop()
+	CurrentSize() int
+	Copy() TxIterator
+}
+
+// OrderingStrategy selects and orders the pending transactions fillTransactions
+// feeds into commitTransactions for one sealing round. The comment on
+// fillTransactions has promised this would be pluggable "in the future";
+// this is that plugin point.
+type OrderingStrategy interface {
+	Order(pending map[common.Address]types.Transactions, header *types.Header, state *state.StateDB) TxIterator
+	Name() string
+}
+
+// RegisterOrderingStrategy swaps in strategy for every future fillTransactions
+// call; pass nil to fall back to DefaultOrderingStrategy.
+func (w *worker) RegisterOrderingStrategy(strategy OrderingStrategy) {
+	w.orderingMu.Lock()
+	if strategy == nil {
+		strategy = NewDefaultOrderingStrategy(w.chainConfig)
+	}
+	w.orderingStrategy = strategy
+	w.orderingMu.Unlock()
+}
+
+func (w *worker) currentOrderingStrategy() OrderingStrategy {
+	w.orderingMu.RLock()
+	defer w.orderingMu.RUnlock()
+	return w.orderingStrategy
+}
+
+// priceNonceIterator adapts *types.TransactionsByPriceAndNonce, which this
+// package has no control over, to TxIterator - its own Copy() returns the
+// concrete type rather than the interface, so it can't satisfy TxIterator
+// without this thin wrapper.
+type priceNonceIterator struct {
+	*types.TransactionsByPriceAndNonce
+}
+
+func (it *priceNonceIterator) Copy() TxIterator {
+	return &priceNonceIterator{it.TransactionsByPriceAndNonce.Copy()}
+}
+
+// DefaultOrderingStrategy is the legacy behavior: highest effective gas price
+// first, nonce order preserved within each account.
+type DefaultOrderingStrategy struct {
+	chainConfig *params.ChainConfig
+}
+
+// NewDefaultOrderingStrategy builds the price/nonce strategy. chainConfig is
+// needed to derive the same signer env.signer already uses, since Order's
+// signature (shared by every OrderingStrategy) doesn't carry one directly.
+func NewDefaultOrderingStrategy(chainConfig *params.ChainConfig) *DefaultOrderingStrategy {
+	return &DefaultOrderingStrategy{chainConfig: chainConfig}
+}
+
+func (s *DefaultOrderingStrategy) Name() string { return "price-nonce" }
+
+func (s *DefaultOrderingStrategy) Order(pending map[common.Address]types.Transactions, header *types.Header, _ *state.StateDB) TxIterator {
+	signer := types.MakeSigner(s.chainConfig, header.Number)
+	return &priceNonceIterator{types.NewTransactionsByPriceAndNonce(signer, pending, header.BaseFee)}
+}
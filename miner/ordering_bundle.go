@@ -0,0 +1,155 @@
+package miner
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// BundleSource supplies pending atomic bundles for a sealing round, the same
+// shape core/txpool/bundlepool.BundlePool.Pending already exposes - so
+// Anchor's existing pool (reached via its BundlePool() accessor) can be
+// registered here directly instead of standing up a second bundle inbox.
+// Submission itself
+// still happens through anchor_mev_api.go's MEVAPI.SendBundle (mev_sendBundle);
+// that RPC already does exactly what a new SubmitBundle method would, so
+// this strategy only adds an alternate, engine-agnostic consumption path for
+// it rather than a second producer.
+type BundleSource interface {
+	Pending(number uint64, timestamp uint64) []*types.Bundle
+}
+
+// BundleAwareOrderingStrategy orders each pending bundle ahead of the plain
+// mempool, as an atomic group, before falling back to fallback (normally
+// DefaultOrderingStrategy) for everything else.
+//
+// For engines already wired to fillTransactionsAndBundles (Anchor, via
+// worker_bundles.go), bundles get real all-or-nothing inclusion there -
+// every transaction is applied for real and any revert outside
+// RevertingTxHashes unwinds the whole bundle via env.state.Snapshot. That
+// path doesn't go through OrderingStrategy at all. BundleAwareOrderingStrategy
+// exists for the rest of commitTransactions' callers (any future engine not
+// wired to fillTransactionsAndBundles) and can only afford a cheaper
+// admission check here: Order's signature carries no chain/vmconfig/gas pool,
+// so a bundle is verified by replaying its nonce and balance requirements
+// against a state snapshot rather than truly executing it. A bundle that
+// passes this check can still revert for an unrelated reason once
+// commitTransactions actually applies it; reasonString/commitInterruptOutOfGas
+// handling is unaffected either way since transaction failures there are
+// already tolerated per-tx, just not yet atomically per-bundle for this path.
+type BundleAwareOrderingStrategy struct {
+	source   BundleSource
+	fallback OrderingStrategy
+}
+
+// NewBundleAwareOrderingStrategy builds the strategy against source (e.g. an
+// *anchor.Anchor's BundlePool) and fallback for the non-bundle remainder.
+func NewBundleAwareOrderingStrategy(source BundleSource, fallback OrderingStrategy) *BundleAwareOrderingStrategy {
+	return &BundleAwareOrderingStrategy{source: source, fallback: fallback}
+}
+
+func (s *BundleAwareOrderingStrategy) Name() string { return "bundle-aware/" + s.fallback.Name() }
+
+func (s *BundleAwareOrderingStrategy) Order(pending map[common.Address]types.Transactions, header *types.Header, st *state.StateDB) TxIterator {
+	rest := s.fallback.Order(pending, header, st)
+	if s.source == nil {
+		return rest
+	}
+
+	bundles := s.source.Pending(header.Number.Uint64(), header.Time)
+	groups := make([]types.Transactions, 0, len(bundles))
+	for _, bundle := range bundles {
+		if s.admits(st, bundle) {
+			groups = append(groups, bundle.Txs)
+		}
+	}
+	if len(groups) == 0 {
+		return rest
+	}
+	return &bundleIterator{groups: groups, fallback: rest}
+}
+
+// admits runs the nonce/balance-only pre-check described on
+// BundleAwareOrderingStrategy, leaving st unmodified.
+func (s *BundleAwareOrderingStrategy) admits(st *state.StateDB, bundle *types.Bundle) bool {
+	snap := st.Snapshot()
+	defer st.RevertToSnapshot(snap)
+
+	for _, tx := range bundle.Txs {
+		from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+		if err != nil {
+			return false
+		}
+		if st.GetNonce(from) != tx.Nonce() {
+			return false
+		}
+		if st.GetBalance(from).Cmp(tx.Cost()) < 0 {
+			return false
+		}
+		st.SetNonce(from, tx.Nonce()+1)
+	}
+	return true
+}
+
+// bundleIterator drains each verified bundle as a contiguous group before
+// falling back to the plain iterator. Popping any transaction of a bundle
+// drops the rest of that bundle's remaining transactions together, so a
+// failure partway through stops it from landing partially - though, as noted
+// on BundleAwareOrderingStrategy, transactions from the same bundle already
+// committed earlier in the round are not unwound by this alone.
+type bundleIterator struct {
+	groups   []types.Transactions
+	groupIdx int
+	txIdx    int
+	fallback TxIterator
+}
+
+func (it *bundleIterator) currentGroup() (types.Transactions, bool) {
+	for it.groupIdx < len(it.groups) {
+		g := it.groups[it.groupIdx]
+		if it.txIdx < len(g) {
+			return g, true
+		}
+		it.groupIdx++
+		it.txIdx = 0
+	}
+	return nil, false
+}
+
+func (it *bundleIterator) Peek() *types.Transaction {
+	if g, ok := it.currentGroup(); ok {
+		return g[it.txIdx]
+	}
+	return it.fallback.Peek()
+}
+
+func (it *bundleIterator) Shift() {
+	if _, ok := it.currentGroup(); ok {
+		it.txIdx++
+		return
+	}
+	it.fallback.Shift()
+}
+
+func (it *bundleIterator) Pop() {
+	if _, ok := it.currentGroup(); ok {
+		it.groupIdx++
+		it.txIdx = 0
+		return
+	}
+	it.fallback.Pop()
+}
+
+func (it *bundleIterator) CurrentSize() int {
+	n := 0
+	for _, g := range it.groups[it.groupIdx:] {
+		n += len(g)
+	}
+	return n + it.fallback.CurrentSize()
+}
+
+func (it *bundleIterator) Copy() TxIterator {
+	groups := make([]types.Transactions, len(it.groups))
+	copy(groups, it.groups)
+	return &bundleIterator{groups: groups, groupIdx: it.groupIdx, txIdx: it.txIdx, fallback: it.fallback.Copy()}
+}
@@ -0,0 +1,118 @@
+package miner
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// touchTracker is a vm.EVMLogger that records every address seen at a call
+// boundary during one transaction's speculative execution. This tree's EVM
+// never calls CaptureState (there's no per-opcode hook wired into core/vm
+// here), so slot-level SLOAD/SSTORE tracking isn't observable; recording at
+// the CaptureStart/CaptureEnter granularity that does exist gives an
+// address-level approximation of a read/write set instead - coarser, but it
+// never understates a conflict, since every address a transaction calls into
+// is marked touched whether it only read from it or wrote to it.
+type touchTracker struct {
+	touched map[common.Address]struct{}
+}
+
+func newTouchTracker() *touchTracker {
+	return &touchTracker{touched: make(map[common.Address]struct{})}
+}
+
+func (t *touchTracker) mark(addrs ...common.Address) {
+	for _, addr := range addrs {
+		t.touched[addr] = struct{}{}
+	}
+}
+
+func (t *touchTracker) CaptureStart(env *vm.EVM, from, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.mark(from, to)
+}
+func (t *touchTracker) CaptureEnd(output []byte, gasUsed uint64, duration time.Duration, err error) {
+}
+func (t *touchTracker) CaptureEnter(typ vm.OpCode, from, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.mark(from, to)
+}
+func (t *touchTracker) CaptureExit(output []byte, gasUsed uint64, err error) {}
+
+// speculativeResult is the outcome of running tx against a disposable clone
+// of the state the rest of its batch also branched from.
+type speculativeResult struct {
+	tx      *types.Transaction
+	touched map[common.Address]struct{}
+	err     error
+}
+
+// speculateBatch runs each of txs concurrently, each against its own
+// state.CopyDoPrefetch() clone of env.state - the same prefetch-sharing copy
+// w.prefetcher already uses for its own background warm-up - so the
+// underlying trie/database caches env.state shares with every clone are hot
+// by the time commitTransactions applies these transactions for real,
+// sequentially, against env.state itself. The clones are never mutated back
+// into env.state; commitTransactions re-applies every transaction through
+// commitTransaction and uses a speculative result only for its touched set.
+func (w *worker) speculateBatch(env *environment, txs []*types.Transaction) []*speculativeResult {
+	results := make([]*speculativeResult, len(txs))
+	var wg sync.WaitGroup
+	for i, tx := range txs {
+		wg.Add(1)
+		go func(i int, tx *types.Transaction) {
+			defer wg.Done()
+			results[i] = w.speculateOne(env, tx)
+		}(i, tx)
+	}
+	wg.Wait()
+	return results
+}
+
+func (w *worker) speculateOne(env *environment, tx *types.Transaction) *speculativeResult {
+	tracker := newTouchTracker()
+	tracker.mark(env.coinbase)
+	if from, err := types.Sender(env.signer, tx); err == nil {
+		tracker.mark(from)
+	}
+	if to := tx.To(); to != nil {
+		tracker.mark(*to)
+	}
+
+	vmConfig := *w.chain.GetVMConfig()
+	vmConfig.Tracer = tracker
+
+	sim := env.state.CopyDoPrefetch()
+	header := types.CopyHeader(env.header)
+	gasPool := new(core.GasPool).AddGas(header.GasLimit)
+	_, err := core.ApplyTransaction(w.chainConfig, w.chain, &env.coinbase, gasPool, sim, header, tx, &header.GasUsed, vmConfig)
+	return &speculativeResult{tx: tx, touched: tracker.touched, err: err}
+}
+
+// conflictsWithPrior reports whether result's touched set overlaps addrs,
+// the union of every conflict-free transaction committed earlier in the
+// same batch. Overlap here doesn't necessarily mean result's transaction
+// would actually fail if committed next - it only means its speculative run
+// didn't see those transactions' effects, so its prewarmed caches can no
+// longer be trusted and it's committed the same way it would be without any
+// speculation at all, via commitTransaction's ordinary snapshot/apply path.
+func conflictsWithPrior(result *speculativeResult, addrs map[common.Address]struct{}) bool {
+	for addr := range result.touched {
+		if _, ok := addrs[addr]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// logSpeculativeBatch is a small hook point so commitTransactions' batch
+// loop stays readable; split out mainly because it's the one place in this
+// file that needs log imported.
+func logSpeculativeBatch(batch []*speculativeResult, conflicted int) {
+	log.Trace("Speculative transaction batch", "size", len(batch), "conflicted", conflicted)
+}
@@ -28,11 +28,13 @@ import (
 	mapset "github.com/deckarep/golang-set"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/consensus/parlia"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/systemcontracts"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
@@ -74,6 +76,17 @@ var (
 	writeBlockTimer    = metrics.NewRegisteredTimer("worker/writeblock", nil)
 	finalizeBlockTimer = metrics.NewRegisteredTimer("worker/finalizeblock", nil)
 
+	// interruptReasonCounters tracks why a sealing round stopped accepting
+	// transactions, one counter per commitInterrupt* reason, so operators can
+	// see whether BuildDeadline or GasFloor/GasTargetRatio are actually what's
+	// cutting rounds short instead of the regular new-head/resubmit churn.
+	interruptReasonCounters = map[int32]metrics.Counter{
+		commitInterruptNewHead:  metrics.NewRegisteredCounter("worker/interrupt_reason/newhead", nil),
+		commitInterruptResubmit: metrics.NewRegisteredCounter("worker/interrupt_reason/resubmit", nil),
+		commitInterruptTimeout:  metrics.NewRegisteredCounter("worker/interrupt_reason/timeout", nil),
+		commitInterruptOutOfGas: metrics.NewRegisteredCounter("worker/interrupt_reason/outofgas", nil),
+	}
+
 	errBlockInterruptedByNewHead  = errors.New("new head arrived while building block")
 	errBlockInterruptedByRecommit = errors.New("recommit interrupt while building block")
 	errBlockInterruptedByTimeout  = errors.New("timeout while building block")
@@ -96,19 +109,24 @@ type environment struct {
 	txs      []*types.Transaction
 	receipts []*types.Receipt
 	uncles   map[common.Hash]*types.Header
+
+	buildStart time.Time // when this round started accepting transactions, for GasTargetRatio's minRecommitInterval guard
+	sealReason string    // why this round stopped accepting transactions, see reasonString
 }
 
 // copy creates a deep copy of environment.
 func (env *environment) copy() *environment {
 	cpy := &environment{
-		signer:    env.signer,
-		state:     env.state.Copy(),
-		ancestors: env.ancestors.Clone(),
-		family:    env.family.Clone(),
-		tcount:    env.tcount,
-		coinbase:  env.coinbase,
-		header:    types.CopyHeader(env.header),
-		receipts:  copyReceipts(env.receipts),
+		signer:     env.signer,
+		state:      env.state.Copy(),
+		ancestors:  env.ancestors.Clone(),
+		family:     env.family.Clone(),
+		tcount:     env.tcount,
+		coinbase:   env.coinbase,
+		header:     types.CopyHeader(env.header),
+		receipts:   copyReceipts(env.receipts),
+		buildStart: env.buildStart,
+		sealReason: env.sealReason,
 	}
 	if env.gasPool != nil {
 		gasPool := *env.gasPool
@@ -146,10 +164,11 @@ func (env *environment) discard() {
 
 // task contains all information for consensus engine sealing and result submitting.
 type task struct {
-	receipts  []*types.Receipt
-	state     *state.StateDB
-	block     *types.Block
-	createdAt time.Time
+	receipts   []*types.Receipt
+	state      *state.StateDB
+	block      *types.Block
+	createdAt  time.Time
+	sealReason string // why the round that produced this block stopped, surfaced in the "Successfully sealed" log
 }
 
 const (
@@ -168,9 +187,10 @@ type newWorkReq struct {
 
 // getWorkReq represents a request for getting a new sealing work with provided parameters.
 type getWorkReq struct {
-	params *generateParams
-	err    error
-	result chan *types.Block
+	params   *generateParams
+	err      error
+	result   chan *types.Block
+	receipts types.Receipts // filled in by mainLoop alongside result, for callers like BuildPayload that need both
 }
 
 // worker is the main object which takes care of submitting new work to consensus engine
@@ -183,8 +203,39 @@ type worker struct {
 	eth         Backend
 	chain       *core.BlockChain
 
+	// acceptsUncles reports whether engine can validate and consume uncle
+	// blocks at all, computed once at construction (see unclesSupported).
+	// Parlia and Anchor never do, so when this is false the uncle-tracking
+	// machinery below (localUncles/remoteUncles, the chainSideCh
+	// subscription, commitUncle) is left unwired instead of maintained and
+	// then discarded on every side block.
+	acceptsUncles bool
+
 	// Feeds
 	pendingLogsFeed event.Feed
+	doubleSignFeed  event.Feed
+
+	// evidencedb persists double-sign evidence resultLoop observes, RLP-encoded
+	// under doubleSignEvidenceKey, namespaced within the shared chain database
+	// rather than a dedicated store (see doubleSignEvidencePrefix).
+	evidencedb ethdb.Database
+
+	builderMu sync.RWMutex
+	builder   BuilderClient // Registered via RegisterBuilder; see worker_builder.go.
+
+	orderingMu       sync.RWMutex
+	orderingStrategy OrderingStrategy // Registered via RegisterOrderingStrategy; see ordering.go.
+
+	payloadsMu sync.Mutex
+	payloads   map[PayloadID]*Payload // In-progress/completed builds; see StartPayloadBuild/GetPayload in worker_payload.go.
+
+	receiptProcessorsMu sync.RWMutex
+	receiptProcessors   []core.ReceiptProcessor // Registered via RegisterReceiptProcessor; see worker_receipts.go.
+
+	traceHookMu sync.RWMutex
+	traceHook   TraceHook // Registered via RegisterTraceHook; see worker_receipts.go.
+
+	minedTxFeed event.Feed // Posts MinedTxEvent; see worker_receipts.go.
 
 	// Subscriptions
 	mux          *event.TypeMux
@@ -223,6 +274,7 @@ type worker struct {
 
 	// atomic status counters
 	running int32 // The indicator whether the consensus engine is running or not.
+	syncing int32 // The indicator whether the node is currently syncing, see syncStatusLoop.
 
 	// External functions
 	isLocalBlock func(header *types.Header) bool // Function used to determine whether the specified block is mined by local miner.
@@ -237,6 +289,7 @@ type worker struct {
 
 func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus.Engine, eth Backend, mux *event.TypeMux, isLocalBlock func(header *types.Header) bool, init bool) *worker {
 	recentMinedBlocks, _ := lru.New(recentMinedCacheLimit)
+	acceptsUncles := unclesSupported(engine)
 	worker := &worker{
 		prefetcher:         core.NewStatePrefetcher(chainConfig, eth.BlockChain(), engine),
 		config:             config,
@@ -245,9 +298,9 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 		eth:                eth,
 		mux:                mux,
 		chain:              eth.BlockChain(),
+		acceptsUncles:      acceptsUncles,
+		evidencedb:         eth.ChainDb(),
 		isLocalBlock:       isLocalBlock,
-		localUncles:        make(map[common.Hash]*types.Block),
-		remoteUncles:       make(map[common.Hash]*types.Block),
 		unconfirmed:        newUnconfirmedBlocks(eth.BlockChain(), sealingLogAtDepth),
 		pendingTasks:       make(map[common.Hash]*task),
 		chainHeadCh:        make(chan core.ChainHeadEvent, chainHeadChanSize),
@@ -260,10 +313,20 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 		startCh:            make(chan struct{}, 1),
 		resubmitIntervalCh: make(chan time.Duration),
 		recentMinedBlocks:  recentMinedBlocks,
+		orderingStrategy:   NewDefaultOrderingStrategy(chainConfig),
 	}
 	// Subscribe events for blockchain
 	worker.chainHeadSub = eth.BlockChain().SubscribeChainHeadEvent(worker.chainHeadCh)
-	worker.chainSideSub = eth.BlockChain().SubscribeChainSideEvent(worker.chainSideCh)
+	if acceptsUncles {
+		worker.localUncles = make(map[common.Hash]*types.Block)
+		worker.remoteUncles = make(map[common.Hash]*types.Block)
+		worker.chainSideSub = eth.BlockChain().SubscribeChainSideEvent(worker.chainSideCh)
+	} else {
+		worker.chainSideSub = event.NewSubscription(func(unsubscribed <-chan struct{}) error {
+			<-unsubscribed
+			return nil
+		})
+	}
 
 	// Sanitize recommit interval if the user-specified one is too short.
 	recommit := worker.config.Recommit
@@ -272,11 +335,12 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 		recommit = minRecommitInterval
 	}
 
-	worker.wg.Add(4)
+	worker.wg.Add(5)
 	go worker.mainLoop()
 	go worker.newWorkLoop(recommit)
 	go worker.resultLoop()
 	go worker.taskLoop()
+	go worker.syncStatusLoop()
 
 	// Submit first work to initialize pending state.
 	if init {
@@ -313,8 +377,14 @@ func (w *worker) setRecommitInterval(interval time.Duration) {
 	}
 }
 
-// pending returns the pending state and corresponding block.
+// pending returns the pending state and corresponding block. While the node
+// is syncing, there's no trustworthy pending state to serve - the chain head
+// it would build on is itself stale - so this returns (nil, nil) instead of
+// a snapshot from before the sync started.
 func (w *worker) pending() (*types.Block, *state.StateDB) {
+	if w.isSyncing() {
+		return nil, nil
+	}
 	// return a snapshot to avoid contention on currentMu mutex
 	w.snapshotMu.RLock()
 	defer w.snapshotMu.RUnlock()
@@ -324,16 +394,23 @@ func (w *worker) pending() (*types.Block, *state.StateDB) {
 	return w.snapshotBlock, w.snapshotState.Copy()
 }
 
-// pendingBlock returns pending block.
+// pendingBlock returns pending block, or nil while the node is syncing (see pending).
 func (w *worker) pendingBlock() *types.Block {
+	if w.isSyncing() {
+		return nil
+	}
 	// return a snapshot to avoid contention on currentMu mutex
 	w.snapshotMu.RLock()
 	defer w.snapshotMu.RUnlock()
 	return w.snapshotBlock
 }
 
-// pendingBlockAndReceipts returns pending block and corresponding receipts.
+// pendingBlockAndReceipts returns pending block and corresponding receipts,
+// or (nil, nil) while the node is syncing (see pending).
 func (w *worker) pendingBlockAndReceipts() (*types.Block, types.Receipts) {
+	if w.isSyncing() {
+		return nil, nil
+	}
 	// return a snapshot to avoid contention on currentMu mutex
 	w.snapshotMu.RLock()
 	defer w.snapshotMu.RUnlock()
@@ -364,6 +441,20 @@ func (w *worker) close() {
 	w.wg.Wait()
 }
 
+// unclesSupported reports whether engine can validate and consume uncle
+// blocks at all. Parlia and Anchor already short-circuit chainSideCh
+// unconditionally elsewhere in this file - neither accepts uncles - so this
+// is checked once here, at worker construction, instead of on every side
+// block.
+func unclesSupported(engine consensus.Engine) bool {
+	switch engine.(type) {
+	case *parlia.Parlia, *anchor.Anchor:
+		return false
+	default:
+		return true
+	}
+}
+
 // newWorkLoop is a standalone goroutine to submit new sealing work upon received events.
 func (w *worker) newWorkLoop(recommit time.Duration) {
 	defer w.wg.Done()
@@ -406,12 +497,15 @@ func (w *worker) newWorkLoop(recommit time.Duration) {
 	for {
 		select {
 		case <-w.startCh:
+			if w.isSyncing() {
+				continue
+			}
 			clearPending(w.chain.CurrentBlock().NumberU64())
 			timestamp = time.Now().Unix()
 			commit(commitInterruptNewHead)
 
 		case head := <-w.chainHeadCh:
-			if !w.isRunning() {
+			if !w.isRunning() || w.isSyncing() {
 				continue
 			}
 			clearPending(head.Block.NumberU64())
@@ -445,7 +539,7 @@ func (w *worker) newWorkLoop(recommit time.Duration) {
 		case <-timer.C:
 			// If sealing is running resubmit a new work cycle periodically to pull in
 			// higher priced transactions. Disable this overhead for pending blocks.
-			if w.isRunning() &&
+			if w.isRunning() && !w.isSyncing() &&
 				((w.chainConfig.Ethash != nil) ||
 					(w.chainConfig.Clique != nil && w.chainConfig.Clique.Period > 0) ||
 					(w.chainConfig.Parlia != nil && w.chainConfig.Parlia.Period > 0)) {
@@ -491,23 +585,27 @@ func (w *worker) mainLoop() {
 	for {
 		select {
 		case req := <-w.newWorkCh:
+			if w.isSyncing() {
+				continue
+			}
 			w.commitWork(req.interruptCh, req.timestamp)
 
 		case req := <-w.getWorkCh:
-			block, err := w.generateWork(req.params)
+			block, receipts, err := w.generateWork(req.params)
 			if err != nil {
 				req.err = err
 				req.result <- nil
 			} else {
+				req.receipts = receipts
 				req.result <- block
 			}
 
 		case ev := <-w.chainSideCh:
-			// Short circuit for duplicate side blocks
-			if _, ok := w.engine.(*parlia.Parlia); ok {
-				continue
-			}
-			if _, ok := w.engine.(*anchor.Anchor); ok {
+			// Parlia/Anchor never accept uncles; this path isn't even
+			// subscribed to the chain's real side-block feed for them (see
+			// acceptsUncles in newWorker), so nothing other than postSideBlock
+			// (test-only) can land here in that case.
+			if !w.acceptsUncles {
 				continue
 			}
 			if _, exist := w.localUncles[ev.Block.Hash()]; exist {
@@ -661,27 +759,28 @@ func (w *worker) resultLoop() {
 			}
 
 			if prev, ok := w.recentMinedBlocks.Get(block.NumberU64()); ok {
-				doubleSign := false
-				prevParents, _ := prev.([]common.Hash)
-				for _, prevParent := range prevParents {
-					if prevParent == block.ParentHash() {
-						log.Error("Reject Double Sign!!", "block", block.NumberU64(),
-							"hash", block.Hash(),
-							"root", block.Root(),
-							"ParentHash", block.ParentHash())
-						doubleSign = true
+				priorHeaders, _ := prev.([]*types.Header)
+				var conflicting *types.Header
+				for _, priorHeader := range priorHeaders {
+					if priorHeader.ParentHash == block.ParentHash() {
+						conflicting = priorHeader
 						break
 					}
 				}
-				if doubleSign {
+				if conflicting != nil {
+					log.Error("Reject Double Sign!!", "block", block.NumberU64(),
+						"hash", block.Hash(),
+						"root", block.Root(),
+						"ParentHash", block.ParentHash())
+					w.reportDoubleSign(block.NumberU64(), conflicting, block.Header())
 					continue
 				}
-				prevParents = append(prevParents, block.ParentHash())
-				w.recentMinedBlocks.Add(block.NumberU64(), prevParents)
+				priorHeaders = append(priorHeaders, block.Header())
+				w.recentMinedBlocks.Add(block.NumberU64(), priorHeaders)
 			} else {
 				// Add() will call removeOldest internally to remove the oldest element
 				// if the LRU Cache is full
-				w.recentMinedBlocks.Add(block.NumberU64(), []common.Hash{block.ParentHash()})
+				w.recentMinedBlocks.Add(block.NumberU64(), []*types.Header{block.Header()})
 			}
 
 			// Broadcast the block and announce chain insertion event
@@ -697,7 +796,7 @@ func (w *worker) resultLoop() {
 			}
 			writeBlockTimer.UpdateSince(start)
 			log.Info("Successfully sealed new block", "number", block.Number(), "sealhash", sealhash, "hash", hash,
-				"elapsed", common.PrettyDuration(time.Since(task.createdAt)))
+				"reason", task.sealReason, "elapsed", common.PrettyDuration(time.Since(task.createdAt)))
 
 			// Insert the block into the set of pending ones to resultLoop for confirmations
 			w.unconfirmed.Insert(block.NumberU64(), block.Hash())
@@ -725,13 +824,14 @@ func (w *worker) makeEnv(parent *types.Block, header *types.Header, coinbase com
 
 	// Note the passed coinbase may be different with header.Coinbase.
 	env := &environment{
-		signer:    types.MakeSigner(w.chainConfig, header.Number),
-		state:     state,
-		coinbase:  coinbase,
-		ancestors: mapset.NewSet(),
-		family:    mapset.NewSet(),
-		header:    header,
-		uncles:    make(map[common.Hash]*types.Header),
+		signer:     types.MakeSigner(w.chainConfig, header.Number),
+		state:      state,
+		coinbase:   coinbase,
+		ancestors:  mapset.NewSet(),
+		family:     mapset.NewSet(),
+		header:     header,
+		uncles:     make(map[common.Hash]*types.Header),
+		buildStart: time.Now(),
 	}
 	// Keep track of transactions which return errors so they can be removed
 	env.tcount = 0
@@ -779,7 +879,15 @@ func (w *worker) updateSnapshot(env *environment) {
 func (w *worker) commitTransaction(env *environment, tx *types.Transaction, receiptProcessors ...core.ReceiptProcessor) ([]*types.Log, error) {
 	snap := env.state.Snapshot()
 
-	receipt, err := core.ApplyTransaction(w.chainConfig, w.chain, &env.coinbase, env.gasPool, env.state, env.header, tx, &env.header.GasUsed, *w.chain.GetVMConfig(), receiptProcessors...)
+	vmConfig := *w.chain.GetVMConfig()
+	hook := w.currentTraceHook()
+	var tracker *touchTracker
+	if hook != nil {
+		tracker = newTouchTracker()
+		vmConfig.Tracer = tracker
+	}
+
+	receipt, err := core.ApplyTransaction(w.chainConfig, w.chain, &env.coinbase, env.gasPool, env.state, env.header, tx, &env.header.GasUsed, vmConfig, receiptProcessors...)
 	if err != nil {
 		env.state.RevertToSnapshot(snap)
 		return nil, err
@@ -787,10 +895,33 @@ func (w *worker) commitTransaction(env *environment, tx *types.Transaction, rece
 	env.txs = append(env.txs, tx)
 	env.receipts = append(env.receipts, receipt)
 
+	if hook != nil {
+		hook(snap, tx, receipt, tracker.touched)
+	}
+	w.minedTxFeed.Send(MinedTxEvent{
+		Tx:           tx,
+		Receipt:      receipt,
+		Logs:         receipt.Logs,
+		GasUsed:      receipt.GasUsed,
+		EffectiveTip: effectiveTip(tx, env.header.BaseFee),
+	})
+
 	return receipt.Logs, nil
 }
 
-func (w *worker) commitTransactions(env *environment, txs *types.TransactionsByPriceAndNonce,
+// effectiveTip returns the miner's actual per-gas reward for tx once
+// baseFee is burned, the same EIP-1559 effective-tip this chain's own
+// reward accounting (consensus.SystemAddress payments) is ultimately driven
+// by.
+func effectiveTip(tx *types.Transaction, baseFee *big.Int) *big.Int {
+	tip, err := tx.EffectiveGasTip(baseFee)
+	if err != nil {
+		return new(big.Int)
+	}
+	return tip
+}
+
+func (w *worker) commitTransactions(env *environment, txs TxIterator,
 	interruptCh chan int32, stopTimer *time.Timer) error {
 	gasLimit := env.header.GasLimit
 	if env.gasPool == nil {
@@ -818,6 +949,34 @@ func (w *worker) commitTransactions(env *environment, txs *types.TransactionsByP
 	txCurr := &tx
 	w.prefetcher.PrefetchMining(txsPrefetch, env.header, env.gasPool.Gas(), env.state.CopyDoPrefetch(), *w.chain.GetVMConfig(), stopPrefetchCh, txCurr)
 
+	// speculativeQueue holds the results of speculatively executing, in
+	// parallel, the next batch of up to Config.ParallelExecution transactions
+	// (see speculateBatch); committedTouched accumulates the addresses every
+	// transaction actually committed so far in the current batch touched, so
+	// conflictsWithPrior can tell whether a later transaction's speculative
+	// run still reflects reality. Disabled (ParallelExecution <= 1) this is
+	// just the existing fully-serial loop.
+	var (
+		speculativeQueue []*speculativeResult
+		speculativeIdx   int
+		committedTouched map[common.Address]struct{}
+	)
+	refillSpeculative := func() {
+		ahead := txs.Copy()
+		batch := make([]*types.Transaction, 0, w.config.ParallelExecution)
+		for len(batch) < w.config.ParallelExecution {
+			next := ahead.Peek()
+			if next == nil {
+				break
+			}
+			batch = append(batch, next)
+			ahead.Shift()
+		}
+		speculativeQueue = w.speculateBatch(env, batch)
+		speculativeIdx = 0
+		committedTouched = make(map[common.Address]struct{})
+	}
+
 	signal := commitInterruptNone
 LOOP:
 	for {
@@ -834,6 +993,7 @@ LOOP:
 					// should never be here, since interruptCh should not be read before
 					log.Warn("commit transactions stopped unknown")
 				}
+				env.sealReason = reasonString(signal)
 				return signalToErr(signal)
 			default:
 			}
@@ -844,6 +1004,21 @@ LOOP:
 			signal = commitInterruptOutOfGas
 			break
 		}
+		// Once the block has used up its gas target, freeze it early instead of
+		// packing all the way to the gas limit, so a full block doesn't also
+		// become a slow one; minRecommitInterval keeps this from firing before
+		// the round has had a fair chance to fill up.
+		if w.config.GasTargetRatio > 0 && time.Since(env.buildStart) >= minRecommitInterval {
+			target := w.config.GasFloor
+			if target == 0 {
+				target = uint64(float64(env.header.GasLimit) * w.config.GasTargetRatio)
+			}
+			if env.header.GasUsed >= target {
+				log.Debug("Gas target reached for current block", "used", env.header.GasUsed, "target", target)
+				signal = commitInterruptOutOfGas
+				break
+			}
+		}
 		if stopTimer != nil {
 			select {
 			case <-stopTimer.C:
@@ -873,10 +1048,39 @@ LOOP:
 		}
 		//todo blacklist verification
 
+		// Enforce the configured tip floor (--miner.gasprice) on London
+		// blocks: a transaction whose effective reward to the miner, after
+		// the base fee is burned, doesn't clear w.config.GasPrice isn't
+		// worth the gas it occupies.
+		if w.chainConfig.IsLondon(env.header.Number) && w.config.GasPrice != nil {
+			if tip := effectiveTip(tx, env.header.BaseFee); tip.Cmp(w.config.GasPrice) < 0 {
+				txs.Pop()
+				continue
+			}
+		}
+
 		// Start executing the transaction
 		env.state.Prepare(tx.Hash(), env.tcount)
 
-		logs, err := w.commitTransaction(env, tx, bloomProcessors)
+		if w.config.ParallelExecution > 1 {
+			if speculativeIdx >= len(speculativeQueue) || speculativeQueue[speculativeIdx].tx.Hash() != tx.Hash() {
+				refillSpeculative()
+			}
+			if speculativeIdx < len(speculativeQueue) {
+				result := speculativeQueue[speculativeIdx]
+				speculativeIdx++
+				if conflictsWithPrior(result, committedTouched) {
+					log.Trace("Speculative execution conflict, re-executing serially", "hash", tx.Hash())
+				}
+			}
+		}
+
+		logs, err := w.commitTransaction(env, tx, append([]core.ReceiptProcessor{bloomProcessors}, w.currentReceiptProcessors()...)...)
+		if w.config.ParallelExecution > 1 && speculativeIdx > 0 && errors.Is(err, nil) {
+			for addr := range speculativeQueue[speculativeIdx-1].touched {
+				committedTouched[addr] = struct{}{}
+			}
+		}
 		switch {
 		case errors.Is(err, core.ErrGasLimitReached):
 			// Pop the current out-of-gas transaction without shifting in the next from the account
@@ -927,6 +1131,7 @@ LOOP:
 		}
 		w.pendingLogsFeed.Send(cpy)
 	}
+	env.sealReason = reasonString(signal)
 	return signalToErr(signal)
 }
 
@@ -940,6 +1145,17 @@ type generateParams struct {
 	noUncle    bool           // Flag whether the uncle block inclusion is allowed
 	noExtra    bool           // Flag whether the extra field assignment is allowed
 	prevWork   *environment
+	// withdrawals is threaded through for Engine-API-style external callers
+	// (see BuildPayload) but isn't applied to the block anywhere yet - this
+	// chain hasn't adopted EIP-4895/Shanghai withdrawals, so there's no
+	// WithdrawalsHash field on types.Header to populate from it.
+	withdrawals types.Withdrawals
+	// beaconRoot is the parent beacon block root an EIP-4788 payload
+	// attributes v3 caller (engine_forkchoiceUpdatedV3) supplies; like
+	// withdrawals above it has nowhere to land on types.Header yet, but is
+	// carried through generateParams so StartPayloadBuild's PayloadID
+	// derivation sees it.
+	beaconRoot *common.Hash
 }
 
 // prepareWork constructs the sealing task according to the given parameters,
@@ -983,13 +1199,13 @@ func (w *worker) prepareWork(genParams *generateParams) (*environment, error) {
 		header.MixDigest = genParams.random
 	}
 	// Set baseFee and GasLimit if we are on an EIP-1559 chain
-	//if w.chainConfig.IsLondon(header.Number) {
-	//	header.BaseFee = misc.CalcBaseFee(w.chainConfig, parent.Header())
-	//	if !w.chainConfig.IsLondon(parent.Number()) {
-	//		parentGasLimit := parent.GasLimit() * params.ElasticityMultiplier
-	//		header.GasLimit = core.CalcGasLimit(parentGasLimit, w.config.GasCeil)
-	//	}
-	//}
+	if w.chainConfig.IsLondon(header.Number) {
+		header.BaseFee = misc.CalcBaseFee(w.chainConfig, parent.Header())
+		if !w.chainConfig.IsLondon(parent.Number()) {
+			parentGasLimit := parent.GasLimit() * params.ElasticityMultiplier
+			header.GasLimit = core.CalcGasLimit(parentGasLimit, w.config.GasCeil)
+		}
+	}
 	// Run the consensus preparation with the default or customized consensus engine.
 	if err := w.engine.Prepare(w.chain, header); err != nil {
 		log.Error("Failed to prepare header for sealing", "err", err)
@@ -1008,8 +1224,9 @@ func (w *worker) prepareWork(genParams *generateParams) (*environment, error) {
 	// Handle upgrade build-in system contract code
 	systemcontracts.UpgradeBuildInSystemContract(w.chainConfig, header.Number, env.state)
 
-	// Accumulate the uncles for the sealing work only if it's allowed.
-	if !genParams.noUncle {
+	// Accumulate the uncles for the sealing work only if it's allowed and
+	// the active engine actually consumes them.
+	if !genParams.noUncle && w.acceptsUncles {
 		commitUncles := func(blocks map[common.Hash]*types.Block) {
 			for hash, uncle := range blocks {
 				if len(env.uncles) == 2 {
@@ -1030,8 +1247,9 @@ func (w *worker) prepareWork(genParams *generateParams) (*environment, error) {
 }
 
 // fillTransactions retrieves the pending transactions from the txpool and fills them
-// into the given sealing block. The transaction selection and ordering strategy can
-// be customized with the plugin in the future.
+// into the given sealing block. Selection and ordering is delegated to whatever
+// OrderingStrategy is currently registered (see ordering.go and RegisterOrderingStrategy);
+// this is the "pluggable in the future" this function's comment used to promise.
 func (w *worker) fillTransactions(interruptCh chan int32, env *environment, stopTimer *time.Timer) (err error) {
 	// Split the pending transactions into locals and remotes
 	// Fill the block with all available pending transactions.
@@ -1044,9 +1262,10 @@ func (w *worker) fillTransactions(interruptCh chan int32, env *environment, stop
 		}
 	}
 
+	strategy := w.currentOrderingStrategy()
 	err = nil
 	if len(localTxs) > 0 {
-		txs := types.NewTransactionsByPriceAndNonce(env.signer, localTxs, env.header.BaseFee)
+		txs := strategy.Order(localTxs, env.header, env.state)
 		err = w.commitTransactions(env, txs, interruptCh, stopTimer)
 		// we will abort here when:
 		//   1.new block was imported
@@ -1059,7 +1278,7 @@ func (w *worker) fillTransactions(interruptCh chan int32, env *environment, stop
 		}
 	}
 	if len(remoteTxs) > 0 {
-		txs := types.NewTransactionsByPriceAndNonce(env.signer, remoteTxs, env.header.BaseFee)
+		txs := strategy.Order(remoteTxs, env.header, env.state)
 		err = w.commitTransactions(env, txs, interruptCh, stopTimer)
 	}
 
@@ -1067,16 +1286,16 @@ func (w *worker) fillTransactions(interruptCh chan int32, env *environment, stop
 }
 
 // generateWork generates a sealing block based on the given parameters.
-func (w *worker) generateWork(params *generateParams) (*types.Block, error) {
+func (w *worker) generateWork(params *generateParams) (*types.Block, types.Receipts, error) {
 	work, err := w.prepareWork(params)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer work.discard()
 
-	w.fillTransactions(nil, work, nil)
-	block, _, err := w.engine.FinalizeAndAssemble(w.chain, work.header, work.state, work.txs, work.unclelist(), work.receipts)
-	return block, err
+	w.fillTransactionsAndBundles(nil, work, nil)
+	block, receipts, err := w.engine.FinalizeAndAssemble(w.chain, work.header, work.state, work.txs, work.unclelist(), work.receipts)
+	return block, receipts, err
 }
 
 // commitWork generates several new sealing tasks based on the parent block
@@ -1117,8 +1336,26 @@ func (w *worker) commitWork(interruptCh chan int32, timestamp int64) {
 		}
 	}()
 
+	// buildDeadlineTimer, when Config.BuildDeadline is set, delivers an extra
+	// commitInterruptTimeout independent of DelayLeftOver's stopTimer above, so
+	// an operator can arm a hard "stop packing" deadline ahead of Parlia's
+	// fixed slot without having to retune DelayLeftOver (which also governs
+	// engine.Delay/Seal timing). Re-armed every round and stopped whenever the
+	// round it was armed for ends, since interruptCh is only valid for one
+	// round at a time.
+	var buildDeadlineTimer *time.Timer
+	defer func() {
+		if buildDeadlineTimer != nil {
+			buildDeadlineTimer.Stop()
+		}
+	}()
+
 LOOP:
 	for {
+		if buildDeadlineTimer != nil {
+			buildDeadlineTimer.Stop()
+			buildDeadlineTimer = nil
+		}
 		work, err := w.prepareWork(&generateParams{
 			timestamp: uint64(timestamp),
 			coinbase:  coinbase,
@@ -1130,6 +1367,14 @@ LOOP:
 		prevWork = work
 		workList = append(workList, work)
 
+		if w.config.BuildDeadline > 0 {
+			if d := time.Until(time.Unix(int64(work.header.Time), 0)) - w.config.BuildDeadline; d > 0 {
+				buildDeadlineTimer = time.AfterFunc(d, func() {
+					w.fireInterrupt(interruptCh, commitInterruptTimeout)
+				})
+			}
+		}
+
 		delay := w.engine.Delay(w.chain, work.header, &w.config.DelayLeftOver)
 		if delay == nil {
 			log.Warn("commitWork delay is nil, something is wrong")
@@ -1151,7 +1396,7 @@ LOOP:
 
 		// Fill pending transactions from the txpool
 		fillStart := time.Now()
-		err = w.fillTransactions(interruptCh, work, stopTimer)
+		err = w.fillTransactionsAndBundles(interruptCh, work, stopTimer)
 		fillDuration := time.Since(fillStart)
 		switch {
 		case errors.Is(err, errBlockInterruptedByNewHead):
@@ -1219,17 +1464,33 @@ LOOP:
 		// so unsubscribe ASAP and Unsubscribe() is re-enterable, safe to call several time.
 		sub.Unsubscribe()
 	}
-	// get the most profitable work
+	// get the most profitable work. On a London block the coinbase's
+	// consensus.SystemAddress payment alone undercounts what this round
+	// actually earned for the network, since the base fee of every
+	// transaction is burned rather than paid to anyone - reward is that
+	// payment plus the burned amount, so a round that included more gas at
+	// the base fee isn't penalized relative to one that packed fewer, higher
+	// -tip transactions for the same coinbase payment.
 	bestWork := workList[0]
 	bestReward := new(big.Int)
 	for i, wk := range workList {
-		balance := wk.state.GetBalance(consensus.SystemAddress)
-		log.Debug("Get the most profitable work", "index", i, "balance", balance, "bestReward", bestReward)
-		if balance.Cmp(bestReward) > 0 {
+		reward := blockReward(wk)
+		log.Debug("Get the most profitable work", "index", i, "reward", reward, "bestReward", bestReward)
+		if reward.Cmp(bestReward) > 0 {
 			bestWork = wk
-			bestReward = balance
+			bestReward = reward
 		}
 	}
+	// Give a registered external builder a chance to beat bestWork before
+	// committing; see raceBuilderBid for the verify-then-compare contract.
+	parent := w.chain.GetBlockByHash(bestWork.header.ParentHash)
+	bestWork = w.raceBuilderBid(parent, bestWork, &generateParams{timestamp: uint64(timestamp), coinbase: coinbase})
+	if bestWork == nil {
+		// Only reachable in relay-only mode when no builder bid verified.
+		log.Warn("commitWork: relay-only and no builder bid verified, skipping round")
+		return
+	}
+
 	w.commit(bestWork, w.fullTaskHook, true, start)
 
 	// Swap out the old work with the new one, terminating any leftover
@@ -1270,7 +1531,7 @@ func (w *worker) commit(env *environment, interval func(), update bool, start ti
 		// If we're post merge, just ignore
 		if !w.isTTDReached(block.Header()) {
 			select {
-			case w.taskCh <- &task{receipts: receipts, state: env.state, block: block, createdAt: time.Now()}:
+			case w.taskCh <- &task{receipts: receipts, state: env.state, block: block, createdAt: time.Now(), sealReason: env.sealReason}:
 				w.unconfirmed.Shift(block.NumberU64() - 1)
 				log.Info("Commit new mining work", "number", block.Number(), "sealhash", w.engine.SealHash(block.Header()),
 					"uncles", len(env.uncles), "txs", env.tcount,
@@ -1321,6 +1582,19 @@ func (w *worker) isTTDReached(header *types.Header) bool {
 	return td != nil && ttd != nil && td.Cmp(ttd) >= 0
 }
 
+// blockReward is wk's consensus.SystemAddress payment plus, on a London
+// block, the base fee burned by every transaction it included - see the
+// comment on commitWork's "most profitable work" selection for why the
+// payment alone isn't the right comparison once EIP-1559 is active.
+func blockReward(wk *environment) *big.Int {
+	reward := new(big.Int).Set(wk.state.GetBalance(consensus.SystemAddress))
+	if wk.header.BaseFee != nil {
+		burned := new(big.Int).Mul(wk.header.BaseFee, new(big.Int).SetUint64(wk.header.GasUsed))
+		reward.Add(reward, burned)
+	}
+	return reward
+}
+
 // copyReceipts makes a deep copy of the given receipts.
 func copyReceipts(receipts []*types.Receipt) []*types.Receipt {
 	result := make([]*types.Receipt, len(receipts))
@@ -1339,9 +1613,46 @@ func (w *worker) postSideBlock(event core.ChainSideEvent) {
 	}
 }
 
+// fireInterrupt delivers signal on interruptCh without blocking. interruptCh
+// is owned by newWorkLoop, which closes it the moment the round it was handed
+// out for ends; buildDeadlineTimer in commitWork is best-effort stopped
+// before that happens, but can't be stopped from another goroutine, so a send
+// racing a close is still possible and would otherwise panic - recovered here
+// since missing one timeout delivery is harmless, a crashed worker is not.
+func (w *worker) fireInterrupt(interruptCh chan int32, signal int32) {
+	defer func() {
+		recover()
+	}()
+	select {
+	case interruptCh <- signal:
+	default:
+	}
+}
+
+// reasonString renders an interrupt signal as the short, log-friendly reason
+// surfaced on the "Successfully sealed" line, so operators tuning BuildDeadline
+// or GasFloor/GasTargetRatio can tell which one actually cut a round short.
+func reasonString(signal int32) string {
+	switch signal {
+	case commitInterruptNewHead:
+		return "new-head"
+	case commitInterruptResubmit:
+		return "resubmit"
+	case commitInterruptTimeout:
+		return "timeout"
+	case commitInterruptOutOfGas:
+		return "out-of-gas"
+	default:
+		return "complete"
+	}
+}
+
 // signalToErr converts the interruption signal to a concrete error type for return.
 // The given signal must be a valid interruption signal.
 func signalToErr(signal int32) error {
+	if counter, ok := interruptReasonCounters[signal]; ok {
+		counter.Inc(1)
+	}
 	switch signal {
 	case commitInterruptNone:
 		return nil
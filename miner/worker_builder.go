@@ -0,0 +1,128 @@
+package miner
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	builderBidLatencyTimer = metrics.NewRegisteredTimer("worker/builder/bidlatency", nil)
+	builderBidsAccepted    = metrics.NewRegisteredCounter("worker/builder/accepted", nil)
+	builderBidsRejected    = metrics.NewRegisteredCounter("worker/builder/rejected", nil)
+)
+
+// BuilderClient is an external, MEV-boost style block builder that races
+// worker's own fillTransactions loop: given the attributes of the round
+// currently being built, it returns zero or more candidate blocks claiming a
+// reward paid to consensus.SystemAddress, the same way Parlia/Anchor's local
+// system-reward transaction does. worker never trusts the claim - every
+// returned block is replayed through verifyBuilderBid before it can beat a
+// locally-built bestWork.
+type BuilderClient interface {
+	// RequestBid asks the builder for its best block(s) on top of params.
+	// ctx is bounded by Config.BuilderTimeout; commitWork falls back to
+	// local work (or, in Config.RelayOnly mode, to no block at all) if it
+	// expires before RequestBid returns.
+	RequestBid(ctx context.Context, params *generateParams) ([]*types.Block, error)
+}
+
+// RegisterBuilder wires client in as the external block-builder source for
+// every future commitWork round; pass nil to fall back to local-only
+// building.
+func (w *worker) RegisterBuilder(client BuilderClient) {
+	w.builderMu.Lock()
+	w.builder = client
+	w.builderMu.Unlock()
+}
+
+// raceBuilderBid asks the registered builder, if any, for bids on top of
+// parent and returns whichever of local or a verified bid pays
+// consensus.SystemAddress the most. It returns local unchanged if no builder
+// is registered (whatever Config.RelayOnly says), or if the request errors
+// or times out and Config.RelayOnly is false. With Config.RelayOnly set and a
+// builder registered it never returns local, returning nil
+// instead if no bid verified - callers must treat a nil result as "skip this
+// round" rather than silently sealing a local block relay-only was meant to
+// suppress.
+func (w *worker) raceBuilderBid(parent *types.Block, local *environment, genParams *generateParams) *environment {
+	w.builderMu.RLock()
+	client := w.builder
+	w.builderMu.RUnlock()
+	if client == nil {
+		return local
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.config.BuilderTimeout)
+	defer cancel()
+
+	start := time.Now()
+	bids, err := client.RequestBid(ctx, genParams)
+	builderBidLatencyTimer.UpdateSince(start)
+	if err != nil {
+		log.Debug("worker: builder bid request failed", "err", err)
+		if w.config.RelayOnly {
+			return nil
+		}
+		return local
+	}
+
+	// local, if chosen, is one of workList's own entries and is left for
+	// commitWork's existing workList cleanup to discard; only bid-replay
+	// environments created here need discarding directly, since they never
+	// enter workList.
+	best := local
+	bestReward := new(big.Int)
+	if local != nil {
+		bestReward = local.state.GetBalance(consensus.SystemAddress)
+	}
+	for _, bid := range bids {
+		verified, reward, err := w.verifyBuilderBid(parent, bid)
+		if err != nil {
+			log.Debug("worker: rejecting builder bid", "hash", bid.Hash(), "err", err)
+			builderBidsRejected.Inc(1)
+			continue
+		}
+		if reward.Cmp(bestReward) > 0 {
+			if best != local {
+				best.discard()
+			}
+			best = verified
+			bestReward = reward
+			builderBidsAccepted.Inc(1)
+		} else {
+			builderBidsRejected.Inc(1)
+			verified.discard()
+		}
+	}
+	if w.config.RelayOnly && best == local {
+		return nil
+	}
+	return best
+}
+
+// verifyBuilderBid replays bid's transactions against a fresh environment
+// built on top of parent with bid's own header (coinbase, gas limit,
+// timestamp), the same commitTransaction path fillTransactions uses, and
+// returns the resulting consensus.SystemAddress balance so raceBuilderBid
+// can compare it against bestReward without trusting anything the builder
+// claims about its own block.
+func (w *worker) verifyBuilderBid(parent *types.Block, bid *types.Block) (*environment, *big.Int, error) {
+	env, err := w.makeEnv(parent, types.CopyHeader(bid.Header()), bid.Header().Coinbase, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, tx := range bid.Transactions() {
+		if _, err := w.commitTransaction(env, tx); err != nil {
+			env.discard()
+			return nil, nil, fmt.Errorf("replay failed for tx %s: %w", tx.Hash(), err)
+		}
+	}
+	return env, env.state.GetBalance(consensus.SystemAddress), nil
+}
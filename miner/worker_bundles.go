@@ -0,0 +1,142 @@
+package miner
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/anchor"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// errBundleTxReverted marks a bundle whose failed transaction wasn't listed
+// in RevertingTxHashes, and so fails the whole bundle per BEP-322's
+// all-or-nothing inclusion rule.
+var errBundleTxReverted = errors.New("miner: bundle transaction reverted outside RevertingTxHashes")
+
+// fillTransactionsAndBundles fills env with every pending bundle it can
+// include profitably, atomically, before falling back to fillTransactions
+// for the plain mempool. Bundles are simulated in isolation on top of the
+// pending state so an unprofitable or reverting one (outside its own
+// RevertingTxHashes allowance) costs nothing beyond the simulation itself.
+func (w *worker) fillTransactionsAndBundles(interruptCh chan int32, env *environment, stopTimer *time.Timer) error {
+	engine, ok := w.engine.(*anchor.Anchor)
+	if !ok {
+		return w.fillTransactions(interruptCh, env, stopTimer)
+	}
+	pool := engine.BundlePool()
+	if pool == nil {
+		return w.fillTransactions(interruptCh, env, stopTimer)
+	}
+
+	pending := pool.Pending(env.header.Number.Uint64(), env.header.Time)
+	if len(pending) == 0 {
+		return w.fillTransactions(interruptCh, env, stopTimer)
+	}
+
+	type candidate struct {
+		bundle *types.Bundle
+		profit *big.Int
+	}
+	candidates := make([]candidate, 0, len(pending))
+	for _, bundle := range pending {
+		profit, err := w.simulateBundle(env, bundle)
+		if err != nil || profit.Sign() <= 0 {
+			log.Trace("Discarding unprofitable/invalid MEV bundle", "hash", bundle.Hash(), "profit", profit, "error", err)
+			continue
+		}
+		candidates = append(candidates, candidate{bundle: bundle, profit: profit})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].profit.Cmp(candidates[j].profit) > 0
+	})
+
+	for _, c := range candidates {
+		if err := w.commitBundle(env, c.bundle); err != nil {
+			log.Trace("Dropping MEV bundle that no longer applies", "hash", c.bundle.Hash(), "error", err)
+		}
+	}
+
+	return w.fillTransactions(interruptCh, env, stopTimer)
+}
+
+// simulateBundle applies bundle's transactions to a throwaway snapshot of
+// env.state and returns the coinbase balance delta (tips plus any direct
+// transfer to the coinbase) they produced, without retaining any of the
+// state changes. A revert outside bundle.RevertingTxHashes fails the whole
+// bundle, matching the all-or-nothing inclusion rule commitBundle enforces
+// for real.
+func (w *worker) simulateBundle(env *environment, bundle *types.Bundle) (*big.Int, error) {
+	snap := env.state.Snapshot()
+	defer env.state.RevertToSnapshot(snap)
+
+	before := new(big.Int).Set(env.state.GetBalance(env.coinbase))
+	gasPool := new(core.GasPool).AddGas(env.gasPool.Gas())
+	// Simulate against a header copy so the real env.header.GasUsed - which
+	// ApplyTransaction mutates through the pointer it's given - isn't left
+	// incremented once this simulation's state changes are reverted below.
+	header := types.CopyHeader(env.header)
+
+	for _, tx := range bundle.Txs {
+		receipt, err := core.ApplyTransaction(w.chainConfig, w.chain, &env.coinbase, gasPool, env.state, header, tx, &header.GasUsed, *w.chain.GetVMConfig())
+		if err != nil {
+			return nil, err
+		}
+		if receipt.Status == types.ReceiptStatusFailed && !reverts(bundle.RevertingTxHashes, tx.Hash()) {
+			return nil, errBundleTxReverted
+		}
+	}
+
+	after := env.state.GetBalance(env.coinbase)
+	return new(big.Int).Sub(after, before), nil
+}
+
+// commitBundle re-applies bundle for real, the same simulation
+// simulateBundle already ran, but against env's actual state/gas pool so
+// its effects stick. Any transaction failing outside RevertingTxHashes
+// rolls back every transaction this bundle already committed to env,
+// state included, leaving env exactly as it was before this call.
+func (w *worker) commitBundle(env *environment, bundle *types.Bundle) error {
+	snap := env.state.Snapshot()
+	startTxs, startReceipts := len(env.txs), len(env.receipts)
+	startGasUsed := env.header.GasUsed
+	var startGas core.GasPool
+	if env.gasPool != nil {
+		startGas = *env.gasPool
+	}
+
+	abort := func(err error) error {
+		env.state.RevertToSnapshot(snap)
+		env.txs = env.txs[:startTxs]
+		env.receipts = env.receipts[:startReceipts]
+		env.header.GasUsed = startGasUsed
+		if env.gasPool != nil {
+			*env.gasPool = startGas
+		}
+		return err
+	}
+
+	for _, tx := range bundle.Txs {
+		if _, err := w.commitTransaction(env, tx); err != nil {
+			return abort(err)
+		}
+		receipt := env.receipts[len(env.receipts)-1]
+		if receipt.Status == types.ReceiptStatusFailed && !reverts(bundle.RevertingTxHashes, tx.Hash()) {
+			return abort(errBundleTxReverted)
+		}
+	}
+	return nil
+}
+
+func reverts(allowed []common.Hash, hash common.Hash) bool {
+	for _, h := range allowed {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+)
+
+func TestReportDoubleSignPersistsAndFiresFeed(t *testing.T) {
+	w := &worker{evidencedb: memorydb.New()}
+
+	ch := make(chan DoubleSignEvent, 1)
+	sub := w.SubscribeDoubleSignEvent(ch)
+	defer sub.Unsubscribe()
+
+	headerA := &types.Header{Number: big.NewInt(10), Extra: []byte{0x01}}
+	headerB := &types.Header{Number: big.NewInt(10), Extra: []byte{0x02}}
+
+	w.reportDoubleSign(10, headerA, headerB)
+
+	select {
+	case event := <-ch:
+		if event.Number != 10 || event.HashA != headerA.Hash() || event.HashB != headerB.Hash() {
+			t.Errorf("unexpected DoubleSignEvent: %+v", event)
+		}
+	default:
+		t.Fatal("expected reportDoubleSign to fire a DoubleSignEvent")
+	}
+
+	gotA, gotB, err := w.GetDoubleSignEvidence(10)
+	if err != nil {
+		t.Fatalf("GetDoubleSignEvidence returned an unexpected error: %v", err)
+	}
+	if gotA == nil || gotB == nil || gotA.Hash() != headerA.Hash() || gotB.Hash() != headerB.Hash() {
+		t.Errorf("GetDoubleSignEvidence = (%v, %v), want the persisted headerA/headerB", gotA, gotB)
+	}
+}
+
+func TestGetDoubleSignEvidenceNoneRecorded(t *testing.T) {
+	w := &worker{evidencedb: memorydb.New()}
+	headerA, headerB, err := w.GetDoubleSignEvidence(123)
+	if err != nil || headerA != nil || headerB != nil {
+		t.Errorf("GetDoubleSignEvidence for an unrecorded number = (%v, %v, %v), want (nil, nil, nil)", headerA, headerB, err)
+	}
+}
@@ -0,0 +1,232 @@
+package miner
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// buildPayloadTimeout bounds how long StartPayloadBuild keeps improving a
+// payload in the background after seeding it; engine_getPayload callers are
+// expected to collect the result well before this via GetPayload, but a
+// caller that never does shouldn't leak the background goroutine forever.
+const buildPayloadTimeout = 12 * time.Second
+
+// BuildPayloadArgs groups the parameters an external proposer or relay
+// supplies to drive block production directly, the way a post-merge
+// consensus client drives an execution client via
+// engine_forkchoiceUpdated/engine_getPayload.
+type BuildPayloadArgs struct {
+	ParentHash   common.Hash    // Parent to build on; empty means the current chain head
+	Timestamp    uint64         // Timestamp the built block must carry
+	FeeRecipient common.Address // Coinbase of the built block
+	Random       common.Hash    // Beacon-chain randomness, empty before the merge
+	Withdrawals  types.Withdrawals
+	BeaconRoot   *common.Hash // Parent beacon block root, payload attributes v3 (EIP-4788)
+}
+
+// Id derives this request's PayloadID the same deterministic way
+// computePayloadID does, so a consensus client repeating identical payload
+// attributes across engine_forkchoiceUpdated calls always gets back the
+// same id instead of spawning a second, redundant build.
+func (args *BuildPayloadArgs) Id() PayloadID {
+	return computePayloadID(args.ParentHash, args)
+}
+
+// PayloadID identifies one in-progress or completed payload build requested
+// through StartPayloadBuild/GetPayload, mirroring the 8-byte id
+// engine_forkchoiceUpdatedV2/V3 return to identify a later
+// engine_getPayload call. Distinct from (and unrelated to) anchor's own
+// PayloadID in anchor_builder_api.go, which identifies a job on Anchor's
+// validator-authenticated BuilderAPI rather than this engine-agnostic,
+// Engine-API-shaped surface.
+type PayloadID [8]byte
+
+func (id PayloadID) String() string { return hexutil.Encode(id[:]) }
+
+// computePayloadID hashes every field that distinguishes one payload-build
+// request from another (parentHash plus args) and keeps the low 8 bytes, the
+// same scheme upstream go-ethereum's miner.computePayloadId uses so that
+// resubmitting identical payload attributes across repeated
+// engine_forkchoiceUpdated calls is idempotent rather than starting a second
+// redundant build.
+func computePayloadID(parentHash common.Hash, args *BuildPayloadArgs) PayloadID {
+	hasher := crypto.NewKeccakState()
+	hasher.Write(parentHash[:])
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], args.Timestamp)
+	hasher.Write(buf[:])
+	hasher.Write(args.Random[:])
+	hasher.Write(args.FeeRecipient[:])
+	if args.BeaconRoot != nil {
+		hasher.Write(args.BeaconRoot[:])
+	}
+	rlp.Encode(hasher, args.Withdrawals)
+	var out [32]byte
+	hasher.Read(out[:])
+	var id PayloadID
+	copy(id[:], out[:8])
+	return id
+}
+
+// Payload tracks one in-progress external block-building request: the block
+// BuildPayload seeded it with, kept up to date by a background
+// improvePayload goroutine until GetPayload collects it or
+// buildPayloadTimeout elapses.
+type Payload struct {
+	id       PayloadID
+	mu       sync.Mutex
+	block    *types.Block
+	receipts types.Receipts
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// update replaces the held block/receipts with candidate if it uses more of
+// the block's gas limit than what's held - the same proxy for "more
+// profitable" commitWork's bestWork selection already leans on absent a full
+// replay, and one BuildPayload's returned *types.Block/types.Receipts pair
+// alone is enough to compute without re-executing anything.
+func (p *Payload) update(block *types.Block, receipts types.Receipts) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.block == nil || block.GasUsed() > p.block.GasUsed() {
+		p.block = block
+		p.receipts = receipts
+	}
+}
+
+// Resolve returns the best block/receipts gathered so far and stops further
+// background improvement; safe to call more than once.
+func (p *Payload) Resolve() (*types.Block, types.Receipts) {
+	p.stopOnce.Do(func() { close(p.stop) })
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.block, p.receipts
+}
+
+// BuildPayload runs generateWork on top of args.ParentHash (not necessarily
+// the current chain head) and returns the assembled, unsealed block together with its
+// receipts, letting an external builder/relay drive block production for
+// this chain without running the local mining loop - the same getWorkCh
+// path getSealingBlock already uses for the post-merge Engine API, just
+// with the parameters it needs exposed directly instead of wired through
+// eth/catalyst. This would naturally sit on miner.Miner - the thin wrapper
+// upstream go-ethereum puts over worker for exactly this kind of
+// external-facing call - but that file isn't part of this tree, so it's
+// added directly on worker, which already owns getWorkCh and generateWork.
+func (w *worker) BuildPayload(args *BuildPayloadArgs) (*types.Block, types.Receipts, error) {
+	req := &getWorkReq{
+		params: &generateParams{
+			timestamp:   args.Timestamp,
+			forceTime:   true,
+			parentHash:  args.ParentHash,
+			coinbase:    args.FeeRecipient,
+			random:      args.Random,
+			withdrawals: args.Withdrawals,
+			beaconRoot:  args.BeaconRoot,
+			noUncle:     true,
+			noExtra:     true,
+		},
+		result: make(chan *types.Block, 1),
+	}
+	select {
+	case w.getWorkCh <- req:
+		block := <-req.result
+		if block == nil {
+			return nil, nil, req.err
+		}
+		return block, req.receipts, nil
+	case <-w.exitCh:
+		return nil, nil, errors.New("miner closed")
+	}
+}
+
+// StartPayloadBuild seeds a Payload for args via one BuildPayload call, then
+// keeps rebuilding it in the background (picking up newly arrived
+// transactions, the same reason commitWork re-runs its own local sealing
+// round on every recommit) until GetPayload(args.Id()) collects it or
+// buildPayloadTimeout elapses - the payload-attributes-v2/v3 analogue of
+// engine_forkchoiceUpdated starting a build job a later engine_getPayload
+// retrieves by id. Calling it again with the same args.Id() (identical
+// attributes) returns the existing in-progress Payload instead of starting a
+// second one.
+func (w *worker) StartPayloadBuild(args *BuildPayloadArgs) (PayloadID, error) {
+	id := args.Id()
+
+	w.payloadsMu.Lock()
+	_, exists := w.payloads[id]
+	w.payloadsMu.Unlock()
+	if exists {
+		return id, nil
+	}
+
+	block, receipts, err := w.BuildPayload(args)
+	if err != nil {
+		return PayloadID{}, err
+	}
+	payload := &Payload{id: id, block: block, receipts: receipts, stop: make(chan struct{})}
+
+	w.payloadsMu.Lock()
+	if w.payloads == nil {
+		w.payloads = make(map[PayloadID]*Payload)
+	}
+	w.payloads[id] = payload
+	w.payloadsMu.Unlock()
+
+	go w.improvePayload(payload, args)
+	return id, nil
+}
+
+// improvePayload re-runs BuildPayload for args every minRecommitInterval,
+// replacing payload's held block whenever the new attempt looks more
+// profitable, until payload.stop closes (via Resolve) or buildPayloadTimeout
+// elapses.
+func (w *worker) improvePayload(payload *Payload, args *BuildPayloadArgs) {
+	timer := time.NewTimer(buildPayloadTimeout)
+	defer timer.Stop()
+	ticker := time.NewTicker(minRecommitInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-payload.stop:
+			return
+		case <-timer.C:
+			return
+		case <-ticker.C:
+			block, receipts, err := w.BuildPayload(args)
+			if err != nil {
+				continue
+			}
+			payload.update(block, receipts)
+		}
+	}
+}
+
+// GetPayload collects the best block/receipts built so far for id, stopping
+// any further background improvement and forgetting id - a second call with
+// the same id returns an error, matching engine_getPayload's "this is a
+// destructive read" semantics.
+func (w *worker) GetPayload(id PayloadID) (*types.Block, types.Receipts, error) {
+	w.payloadsMu.Lock()
+	payload, ok := w.payloads[id]
+	if ok {
+		delete(w.payloads, id)
+	}
+	w.payloadsMu.Unlock()
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown payload id %s", id)
+	}
+
+	block, receipts := payload.Resolve()
+	return block, receipts, nil
+}
@@ -0,0 +1,75 @@
+package miner
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// TraceHook is called once per successfully applied transaction, right after
+// commitTransaction commits it to env.state, so an in-process indexer or
+// plugin can stream execution results out of the miner without patching
+// this tree - the in-process analogue of plugeth's plugin hooks. snapshot is
+// the env.state.Snapshot() id commitTransaction took before applying tx (the
+// same one it would roll back to on failure), letting a hook correlate this
+// callback with state history if it's tracking snapshots itself. touched is
+// the address-level read/write set touchTracker (see speculative.go)
+// recorded while tx ran - the same coarser-than-slot-level approximation
+// speculateBatch relies on, for the same reason: this tree's EVM never
+// calls CaptureState.
+type TraceHook func(snapshot int, tx *types.Transaction, receipt *types.Receipt, touched map[common.Address]struct{})
+
+// MinedTxEvent is posted on worker's mined-tx feed for every transaction
+// commitTransaction successfully applies, so downstream services (MEV
+// dashboards, mempool analytics) can subscribe without a full indexer.
+type MinedTxEvent struct {
+	Tx           *types.Transaction
+	Receipt      *types.Receipt
+	Logs         []*types.Log
+	GasUsed      uint64
+	EffectiveTip *big.Int
+}
+
+// RegisterReceiptProcessor adds proc to the set run by every future
+// commitTransaction call, alongside the internal AsyncReceiptBloomGenerator
+// commitTransactions already wires in.
+func (w *worker) RegisterReceiptProcessor(proc core.ReceiptProcessor) {
+	w.receiptProcessorsMu.Lock()
+	w.receiptProcessors = append(w.receiptProcessors, proc)
+	w.receiptProcessorsMu.Unlock()
+}
+
+func (w *worker) currentReceiptProcessors() []core.ReceiptProcessor {
+	w.receiptProcessorsMu.RLock()
+	defer w.receiptProcessorsMu.RUnlock()
+	if len(w.receiptProcessors) == 0 {
+		return nil
+	}
+	procs := make([]core.ReceiptProcessor, len(w.receiptProcessors))
+	copy(procs, w.receiptProcessors)
+	return procs
+}
+
+// RegisterTraceHook installs hook as the per-tx trace callback for every
+// future commitTransaction call; pass nil to stop tracing.
+func (w *worker) RegisterTraceHook(hook TraceHook) {
+	w.traceHookMu.Lock()
+	w.traceHook = hook
+	w.traceHookMu.Unlock()
+}
+
+func (w *worker) currentTraceHook() TraceHook {
+	w.traceHookMu.RLock()
+	defer w.traceHookMu.RUnlock()
+	return w.traceHook
+}
+
+// SubscribeMinedTxEvent registers ch to receive every MinedTxEvent worker
+// posts; the returned subscription works the same as
+// SubscribeDoubleSignEvent's.
+func (w *worker) SubscribeMinedTxEvent(ch chan<- MinedTxEvent) event.Subscription {
+	return w.minedTxFeed.Subscribe(ch)
+}
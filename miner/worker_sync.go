@@ -0,0 +1,52 @@
+package miner
+
+import (
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// isSyncing reports whether the node is currently syncing, as last reported
+// by syncStatusLoop. newWorkLoop and mainLoop check this to suspend block
+// building, and pending()/pendingBlock()/pendingBlockAndReceipts() check it
+// to avoid serving a pending state/block built on a stale head.
+func (w *worker) isSyncing() bool {
+	return atomic.LoadInt32(&w.syncing) == 1
+}
+
+// syncStatusLoop tracks downloader.StartEvent/DoneEvent/FailedEvent on the
+// shared event mux and toggles w.syncing accordingly, resuming block
+// building automatically once the downloader reports it's caught up.
+func (w *worker) syncStatusLoop() {
+	defer w.wg.Done()
+	sub := w.mux.Subscribe(downloader.StartEvent{}, downloader.DoneEvent{}, downloader.FailedEvent{})
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev, ok := <-sub.Chan():
+			if !ok {
+				return
+			}
+			switch ev.Data.(type) {
+			case downloader.StartEvent:
+				if atomic.CompareAndSwapInt32(&w.syncing, 0, 1) {
+					log.Info("Suspending miner while node is syncing")
+				}
+			case downloader.DoneEvent, downloader.FailedEvent:
+				if atomic.CompareAndSwapInt32(&w.syncing, 1, 0) {
+					log.Info("Resuming miner, sync finished")
+					if w.isRunning() {
+						select {
+						case w.startCh <- struct{}{}:
+						case <-w.exitCh:
+						}
+					}
+				}
+			}
+		case <-w.exitCh:
+			return
+		}
+	}
+}